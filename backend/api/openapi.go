@@ -0,0 +1,399 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
+	"github.com/Jsanchez767/InfluencePower/backend/handlers"
+	"github.com/Jsanchez767/InfluencePower/backend/health"
+	"github.com/Jsanchez767/InfluencePower/backend/mergepatch"
+	"github.com/Jsanchez767/InfluencePower/backend/models"
+	"github.com/Jsanchez767/InfluencePower/backend/openapi"
+	"github.com/Jsanchez767/InfluencePower/backend/overrides"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+	"github.com/Jsanchez767/InfluencePower/backend/ratelimit"
+	"github.com/Jsanchez767/InfluencePower/backend/review"
+	"github.com/Jsanchez767/InfluencePower/backend/scheduler"
+	"github.com/Jsanchez767/InfluencePower/backend/softdelete"
+	"github.com/gorilla/mux"
+)
+
+// basePath is the prefix of all documented routes
+const basePath = "/api/v1"
+
+// HealthStatus is the response of HealthCheck
+type HealthStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// errorResponse is the error envelope written by apierror.Write
+type errorResponse struct {
+	Error apierror.Error `json:"error"`
+}
+
+// endpoint documents one route. Every route registered in SetupRoutes must
+// have an endpoint and vice versa; BuildSpec fails otherwise.
+type endpoint struct {
+	Method   string
+	Path     string // mux path template relative to basePath
+	ID       string // operationId, also the generated client method name
+	Summary  string
+	Tag      string
+	Params   map[string]*openapi.Schema // path parameter schemas; integer by default
+	Query    []openapi.Parameter
+	Body     interface{} // request body, if any
+	Response interface{} // success body; nil for 204 No Content
+	Status   int         // success status; 200 by default
+	Role     auth.Role   // minimum role, RoleAnonymous for public routes
+
+	// Unavailable documents a 503 carrying the success body, for checks
+	// that report failure through their status code
+	Unavailable bool
+
+	// Conflict documents a 409 with this description
+	Conflict string
+
+	// IfMatch documents an If-Match header and the 412 sent when it no
+	// longer matches
+	IfMatch bool
+
+	// Gone documents a removed endpoint, deprecated and answering 410 with
+	// this description instead of a success
+	Gone string
+}
+
+func queryParam(name, typ, description string, required bool) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Required: required, Schema: &openapi.Schema{Type: typ}}
+}
+
+var endpoints = []endpoint{
+	{Method: "GET", Path: "/health", ID: "getHealth", Summary: "Check API health", Tag: "health", Response: HealthStatus{}},
+	{Method: "GET", Path: "/health/live", ID: "getLiveness", Summary: "Check that the process is up", Tag: "health", Response: HealthStatus{}},
+	{Method: "GET", Path: "/health/ready", ID: "getReadiness", Summary: "Check the database and data freshness", Tag: "health", Response: health.Report{}, Unavailable: true},
+	{Method: "GET", Path: "/openapi.json", ID: "getOpenApi", Summary: "This OpenAPI document", Tag: "health", Response: json.RawMessage{}},
+
+	{Method: "GET", Path: "/officials", ID: "getOfficials", Summary: "List current officials", Tag: "officials", Response: []models.Official{}},
+	{Method: "GET", Path: "/officials/{id}", ID: "getOfficialById", Summary: "Get an official", Tag: "officials", Response: models.Official{}},
+	{Method: "POST", Path: "/officials", ID: "createOfficial", Summary: "Removed; create a person with POST /people and give them a position with POST /positions/{id}/terms", Tag: "officials",
+		Gone: "Removed in favour of POST /people and POST /positions/{id}/terms"},
+	{Method: "PUT", Path: "/officials/{id}", ID: "updateOfficial", Summary: "Removed; change a person with PATCH /people/{id}", Tag: "officials",
+		Gone: "Removed in favour of PATCH /people/{id}"},
+	{Method: "DELETE", Path: "/officials/{id}", ID: "deleteOfficial", Summary: "Delete an official with their terms and votes; admins can restore them", Tag: "officials", Status: http.StatusNoContent, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/officials/party/{party}", ID: "getOfficialsByParty", Summary: "List officials by party", Tag: "officials", Response: []models.Official{}},
+	{Method: "GET", Path: "/officials/ward/{ward}", ID: "getOfficialsByWard", Summary: "List officials by ward", Tag: "officials", Response: []models.Official{}},
+
+	{Method: "POST", Path: "/people", ID: "createPerson", Summary: "Create a person", Tag: "people", Body: handlers.PersonRequest{}, Response: models.Person{}, Status: http.StatusCreated, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/people/{id}", ID: "getPerson", Summary: "Get a person", Tag: "people", Response: models.Person{}},
+	{Method: "PATCH", Path: "/people/{id}", ID: "updatePerson", Summary: "Change fields of a person with a JSON Merge Patch", Tag: "people",
+		Body: handlers.PersonPatch{}, Response: models.Person{}, Conflict: "Person changed while it was being updated", IfMatch: true, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/positions", ID: "createPosition", Summary: "Create a position", Tag: "people", Body: handlers.PositionRequest{}, Response: models.Position{}, Status: http.StatusCreated, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/positions/{id}", ID: "getPosition", Summary: "Get a position", Tag: "people", Response: models.Position{}},
+	{Method: "PATCH", Path: "/positions/{id}", ID: "updatePosition", Summary: "Change fields of a position with a JSON Merge Patch", Tag: "people",
+		Body: handlers.PositionPatch{}, Response: models.Position{}, Conflict: "More people hold the position than the seats left, or it changed while it was being updated", IfMatch: true, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/positions/{id}/terms", ID: "listPositionTerms", Summary: "List the terms of a position, latest first", Tag: "people", Response: []models.Term{}},
+	{Method: "POST", Path: "/positions/{id}/terms", ID: "createTerm", Summary: "Start a term of a person on a position", Tag: "people",
+		Body: handlers.TermRequest{}, Response: models.Term{}, Status: http.StatusCreated, Conflict: "Every seat of the position is held by another term over these dates", Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/terms/{id}", ID: "getTerm", Summary: "Get a term", Tag: "people", Response: models.Term{}},
+	{Method: "PATCH", Path: "/terms/{id}", ID: "updateTerm", Summary: "Change the dates or details of a term with a JSON Merge Patch", Tag: "people",
+		Body: handlers.TermPatch{}, Response: models.Term{}, Conflict: "Every seat of the position is held by another term over the new dates, or the term changed while it was being updated", IfMatch: true, Role: auth.RoleAdmin},
+	{Method: "DELETE", Path: "/terms/{id}", ID: "deleteTerm", Summary: "Delete a term; admins can restore it", Tag: "people", Status: http.StatusNoContent, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/terms/{id}/close", ID: "closeTerm", Summary: "End a current term", Tag: "people",
+		Body: handlers.CloseTermRequest{}, Response: models.Term{}, Conflict: "Term has already ended, or it changed while it was being closed", IfMatch: true, Role: auth.RoleAdmin},
+
+	{Method: "GET", Path: "/people/{id}/provenance", ID: "getPersonProvenance", Summary: "List where each field of a person came from", Tag: "provenance", Response: []provenance.Field{}},
+
+	{Method: "GET", Path: "/officials/{id}/voting-records", ID: "getVotingRecords", Summary: "List an official's voting records", Tag: "votes", Response: []models.VotingRecord{}},
+	{Method: "POST", Path: "/voting-records", ID: "createVotingRecord", Summary: "Create a voting record on a stored matter, named by ID or name", Tag: "votes", Body: models.VotingRecord{}, Response: models.VotingRecord{}, Status: http.StatusCreated, Role: auth.RoleAdmin},
+	{Method: "DELETE", Path: "/voting-records/{id}", ID: "deleteVotingRecord", Summary: "Delete a voting record; admins can restore it", Tag: "votes", Status: http.StatusNoContent, Role: auth.RoleAdmin},
+
+	{Method: "GET", Path: "/wards/{ward}/statistics", ID: "getWardStatistics", Summary: "Get ward statistics", Tag: "wards", Response: models.WardStatistic{}},
+
+	{Method: "GET", Path: "/committees", ID: "getCommittees", Summary: "List committees", Tag: "committees", Response: []models.Committee{}},
+	{Method: "GET", Path: "/officials/{id}/committees", ID: "getOfficialCommittees", Summary: "List an official's committee memberships", Tag: "committees", Response: []models.OfficialCommittee{}},
+
+	{Method: "GET", Path: "/officials/{id}/metrics", ID: "getOfficialMetrics", Summary: "Get an official's metrics", Tag: "metrics", Response: models.PersonMetrics{}},
+	{Method: "GET", Path: "/wards/{ward}/metrics", ID: "getWardMetrics", Summary: "Get the current official and metrics for a ward", Tag: "metrics", Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/officials/{id}/voting-allies", ID: "getVotingAllies", Summary: "List the officials who vote most like an official", Tag: "metrics", Response: []models.VotingAlly{}},
+	{Method: "GET", Path: "/officials/{id}/recent-votes", ID: "getRecentVotes", Summary: "List an official's recent votes", Tag: "votes", Response: []models.RecentVote{}},
+
+	{Method: "GET", Path: "/search", ID: "search", Summary: "Search people, legislation, committees and meetings", Tag: "search",
+		Query: []openapi.Parameter{
+			queryParam("q", "string", "Search text", true),
+			queryParam("type", "string", "Comma-separated result types: person, matter, committee, meeting", false),
+			queryParam("limit", "integer", "Maximum hits to return (default 20, max 100)", false),
+			queryParam("offset", "integer", "Hits to skip", false),
+		},
+		Response: handlers.SearchResponse{}},
+
+	{Method: "GET", Path: "/overrides", ID: "listOverrides", Summary: "List pinned field values", Tag: "overrides",
+		Query: []openapi.Parameter{
+			queryParam("entity", "string", "Only overrides of this entity: person, term or matter", false),
+			queryParam("entity_id", "string", "Only overrides of this row: a person or term id, or a matter_id", false),
+		},
+		Response: []overrides.Override{}, Role: auth.RoleEditor},
+	{Method: "PUT", Path: "/overrides", ID: "setOverride", Summary: "Pin a field of a person, term or matter to a value that syncs keep", Tag: "overrides",
+		Body: handlers.SetOverrideRequest{}, Response: overrides.Override{}, Role: auth.RoleEditor},
+	{Method: "DELETE", Path: "/overrides/{id}", ID: "deleteOverride", Summary: "Unpin a field; the next sync writes the upstream value", Tag: "overrides", Status: http.StatusNoContent, Role: auth.RoleEditor},
+
+	{Method: "GET", Path: "/admin/api-keys", ID: "listApiKeys", Summary: "List API keys", Tag: "admin", Response: []auth.APIKey{}, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/api-keys", ID: "createApiKey", Summary: "Create an API key", Tag: "admin", Body: handlers.CreateAPIKeyRequest{}, Response: handlers.CreateAPIKeyResponse{}, Status: http.StatusCreated, Role: auth.RoleAdmin},
+	{Method: "DELETE", Path: "/admin/api-keys/{id}", ID: "revokeApiKey", Summary: "Revoke an API key", Tag: "admin", Params: map[string]*openapi.Schema{"id": {Type: "string", Format: "uuid"}}, Status: http.StatusNoContent, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/usage", ID: "getUsage", Summary: "Get per-client usage since startup", Tag: "admin",
+		Query:    []openapi.Parameter{queryParam("client", "string", "Client prefix filter, e.g. ip: or key:", false)},
+		Response: []ratelimit.Usage{}, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/cache/purge", ID: "purgeCache", Summary: "Drop cached responses, optionally only those built from the given entities", Tag: "admin", Body: handlers.PurgeCacheRequest{}, Response: handlers.PurgeCacheResponse{}, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/jobs", ID: "listJobs", Summary: "List scheduled jobs with their next and last runs", Tag: "admin", Response: []scheduler.JobStatus{}, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/jobs/runs", ID: "listJobRuns", Summary: "List recent job runs, newest first", Tag: "admin",
+		Query: []openapi.Parameter{
+			queryParam("job", "string", "Only runs of this job, e.g. votes or sync", false),
+			queryParam("limit", "integer", "Maximum runs to return (default 50, max 500)", false),
+		},
+		Response: []scheduler.Run{}, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/jobs/{name}/runs", ID: "triggerJob", Summary: "Start a job now", Tag: "admin", Params: map[string]*openapi.Schema{"name": {Type: "string"}},
+		Response: scheduler.Run{}, Status: http.StatusAccepted, Conflict: "Job is already running", Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/queue", ID: "getQueueStats", Summary: "Count queued units of work by kind and status", Tag: "admin", Response: []handlers.QueueCount{}, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/queue/tasks", ID: "listQueueTasks", Summary: "List queued units of work, newest first", Tag: "admin",
+		Query: []openapi.Parameter{
+			queryParam("kind", "string", "Only units of this kind: votes or event_items", false),
+			queryParam("status", "string", "Only units with this status: pending, running, done or dead", false),
+			queryParam("limit", "integer", "Maximum units to return (default 50, max 500)", false),
+		},
+		Response: []queue.Task{}, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/queue/tasks/{id}/retry", ID: "retryQueueTask", Summary: "Make a dead unit of work pending again", Tag: "admin", Response: queue.Task{}, Role: auth.RoleAdmin},
+	{Method: "DELETE", Path: "/admin/queue/tasks/{id}", ID: "discardQueueTask", Summary: "Delete a dead unit of work", Tag: "admin", Status: http.StatusNoContent, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/review/items", ID: "listReviewItems", Summary: "List sync decisions left for review, oldest first", Tag: "admin",
+		Query: []openapi.Parameter{
+			queryParam("kind", "string", "Only items of this kind: person_match, position or term_start", false),
+			queryParam("status", "string", "Only items with this status: open (default), resolved or dismissed", false),
+			queryParam("limit", "integer", "Maximum items to return (default 50, max 500)", false),
+		},
+		Response: []review.Item{}, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/review/items/{id}", ID: "getReviewItem", Summary: "Get a review item with its upstream record and candidates", Tag: "admin", Response: review.Item{}, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/review/items/{id}/resolve", ID: "resolveReviewItem", Summary: "Decide a review item; the next sync applies the decision", Tag: "admin",
+		Body: review.Resolution{}, Response: review.Item{}, Conflict: "Review item is already resolved or dismissed", Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/review/items/{id}/dismiss", ID: "dismissReviewItem", Summary: "Close a review item without a decision; the sync skips its record", Tag: "admin",
+		Body: handlers.DismissReviewItemRequest{}, Response: review.Item{}, Conflict: "Review item is already resolved or dismissed", Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/audit", ID: "listAuditLog", Summary: "List writes through the API and sync jobs, newest first", Tag: "admin",
+		Query: []openapi.Parameter{
+			queryParam("entity", "string", "Only writes to this table, e.g. people", false),
+			queryParam("entity_id", "string", "Only writes to this row of entity", false),
+			queryParam("actor", "string", "Only writes by this actor, e.g. key:admin or scheduler", false),
+			queryParam("before_id", "integer", "Only entries older than this one, to page back", false),
+			queryParam("limit", "integer", "Maximum entries to return (default 100, max 1000)", false),
+		},
+		Response: []audit.Entry{}, Role: auth.RoleAdmin},
+	{Method: "GET", Path: "/admin/deleted/{entity}", ID: "listDeleted", Summary: "List deleted people, terms or votes, most recently deleted first", Tag: "admin",
+		Query:    []openapi.Parameter{queryParam("limit", "integer", "Maximum rows to return (default 50, max 500)", false)},
+		Response: []softdelete.Row{}, Role: auth.RoleAdmin},
+	{Method: "POST", Path: "/admin/deleted/{entity}/{id}/restore", ID: "restoreDeleted", Summary: "Restore a deleted row and the rows deleted with it", Tag: "admin",
+		Response: []softdelete.Row{}, Conflict: "Row is not deleted, or its person is deleted", Role: auth.RoleAdmin},
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// BuildSpec builds the OpenAPI document for router, checking that the
+// documented endpoints match the registered routes exactly
+func BuildSpec(router *mux.Router) (*openapi.Document, error) {
+	registered := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(tpl, basePath+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, m := range methods {
+			registered[m+" "+strings.TrimPrefix(tpl, basePath)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	documented := make(map[string]bool)
+	for _, e := range endpoints {
+		key := e.Method + " " + e.Path
+		documented[key] = true
+		if !registered[key] {
+			problems = append(problems, "documented but not routed: "+key)
+		}
+	}
+	for key := range registered {
+		if !documented[key] {
+			problems = append(problems, "routed but not documented: "+key)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("OpenAPI document is out of date: %s", strings.Join(problems, "; "))
+	}
+
+	return buildDocument(), nil
+}
+
+// buildDocument renders endpoints as an OpenAPI document
+func buildDocument() *openapi.Document {
+	schemas := openapi.NewSchemas()
+	schemas.Named("APIError", apierror.Error{}) // "Error" would shadow the built-in in TypeScript
+	errorSchema := schemas.Named("ErrorResponse", errorResponse{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "InfluencePower API",
+			Description: "Chicago City Council officials, legislation and votes",
+			Version:     "1.0.0",
+		},
+		Servers: []openapi.Server{{URL: basePath}},
+		Paths:   make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"apiKey":     {Type: "apiKey", In: "header", Name: auth.APIKeyHeader},
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "API key or JWT"},
+			},
+		},
+	}
+
+	errorResp := func(description string) *openapi.Response {
+		return &openapi.Response{
+			Description: description,
+			Content:     map[string]openapi.MediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+
+	for _, e := range endpoints {
+		op := &openapi.Operation{
+			OperationID: e.ID,
+			Summary:     e.Summary,
+			Tags:        []string{e.Tag},
+			Responses:   make(map[string]*openapi.Response),
+		}
+
+		for _, m := range pathParamPattern.FindAllStringSubmatch(e.Path, -1) {
+			schema, ok := e.Params[m[1]]
+			if !ok {
+				schema = &openapi.Schema{Type: "integer"}
+				if m[1] == "party" {
+					schema = &openapi.Schema{Type: "string"}
+				}
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+		}
+		op.Parameters = append(op.Parameters, e.Query...)
+		if e.IfMatch {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        "If-Match",
+				In:          "header",
+				Description: "ETag of the representation the change was made to; the write is refused if it changed since",
+				Schema:      &openapi.Schema{Type: "string"},
+			})
+		}
+
+		if e.Body != nil {
+			schema := schemas.For(e.Body)
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]openapi.MediaType{"application/json": {Schema: schema}},
+			}
+			if e.Method == "PATCH" {
+				op.RequestBody.Content[mergepatch.ContentType] = openapi.MediaType{Schema: schema}
+			}
+		}
+
+		status := e.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &openapi.Response{Description: http.StatusText(status)}
+		if e.Response != nil {
+			success.Content = map[string]openapi.MediaType{"application/json": {Schema: schemas.For(e.Response)}}
+		}
+		if e.Gone != "" {
+			op.Deprecated = true
+			op.Responses["410"] = errorResp(e.Gone)
+		} else {
+			op.Responses[strconv.Itoa(status)] = success
+		}
+		if e.Unavailable {
+			op.Responses["503"] = &openapi.Response{Description: "Service unavailable", Content: success.Content}
+		}
+		if policy, ok := cache.DefaultPolicies[basePath+e.Path]; ok && !policy.NoStore && e.Method == "GET" {
+			op.Responses["304"] = &openapi.Response{Description: "Not modified since the ETag in If-None-Match"}
+		}
+
+		if len(op.Parameters) > 0 || e.Body != nil {
+			op.Responses["400"] = errorResp("Invalid parameters or request body")
+		}
+		if e.Body != nil {
+			op.Responses["422"] = errorResp("Request body failed validation")
+		}
+		if e.Role > auth.RoleAnonymous {
+			op.Security = []map[string][]string{{"apiKey": {}}, {"bearerAuth": {}}}
+			op.Summary += fmt.Sprintf(" (requires %s role)", e.Role)
+			op.Responses["401"] = errorResp("Missing or invalid credentials")
+			op.Responses["403"] = errorResp("Insufficient role")
+		}
+		if strings.Contains(e.Path, "{") {
+			op.Responses["404"] = errorResp("Not found")
+		}
+		if e.Conflict != "" {
+			op.Responses["409"] = errorResp(e.Conflict)
+		}
+		if e.IfMatch {
+			op.Responses["412"] = errorResp("Changed since the ETag in If-Match")
+		}
+		op.Responses["429"] = errorResp("Rate limit exceeded")
+		op.Responses["500"] = errorResp("Internal or upstream error")
+
+		path := basePath + e.Path
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(e.Method)] = op
+	}
+
+	doc.Components.Schemas = schemas.Components()
+	return doc
+}
+
+// OpenAPIHandler serves the OpenAPI document for router. The document is
+// built on first use, once all routes have been registered.
+func OpenAPIHandler(router *mux.Router) http.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc *openapi.Document
+			if doc, err = BuildSpec(router); err == nil {
+				body, err = json.MarshalIndent(doc, "", "  ")
+			}
+		})
+		if err != nil {
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
@@ -3,8 +3,16 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
 	"github.com/Jsanchez767/InfluencePower/backend/handlers"
+	"github.com/Jsanchez767/InfluencePower/backend/health"
+	"github.com/Jsanchez767/InfluencePower/backend/metrics"
+	"github.com/Jsanchez767/InfluencePower/backend/ratelimit"
+	"github.com/Jsanchez767/InfluencePower/backend/server"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
 	"github.com/gorilla/mux"
 )
 
@@ -12,22 +20,48 @@ import (
 func SetupRoutes(router *mux.Router) {
 	// API version prefix
 	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(metrics.Instrument, tracing.Middleware, ratelimit.LimitFailedAuth, auth.Authenticate, ratelimit.Limit, cache.Middleware)
+
+	// Writes to people and votes are restricted to admins, fixes to synced
+	// data to editors
+	admin := auth.Require(auth.RoleAdmin)
+	editor := auth.Require(auth.RoleEditor)
 
 	// Health check
 	api.HandleFunc("/health", HealthCheck).Methods("GET")
+	api.HandleFunc("/health/live", HealthCheck).Methods("GET")
+	api.HandleFunc("/health/ready", ReadinessCheck).Methods("GET")
 
 	// Officials routes
 	api.HandleFunc("/officials", handlers.GetOfficials).Methods("GET")
 	api.HandleFunc("/officials", handlers.CreateOfficial).Methods("POST")
 	api.HandleFunc("/officials/{id}", handlers.GetOfficialByID).Methods("GET")
 	api.HandleFunc("/officials/{id}", handlers.UpdateOfficial).Methods("PUT")
-	api.HandleFunc("/officials/{id}", handlers.DeleteOfficial).Methods("DELETE")
+	api.Handle("/officials/{id}", admin(http.HandlerFunc(handlers.DeleteOfficial))).Methods("DELETE")
 	api.HandleFunc("/officials/party/{party}", handlers.GetOfficialsByParty).Methods("GET")
 	api.HandleFunc("/officials/ward/{ward}", handlers.GetOfficialsByWard).Methods("GET")
 
+	// People, positions and terms routes
+	api.Handle("/people", admin(http.HandlerFunc(handlers.CreatePerson))).Methods("POST")
+	api.HandleFunc("/people/{id}", handlers.GetPerson).Methods("GET")
+	api.Handle("/people/{id}", admin(http.HandlerFunc(handlers.UpdatePerson))).Methods("PATCH")
+	api.Handle("/positions", admin(http.HandlerFunc(handlers.CreatePosition))).Methods("POST")
+	api.HandleFunc("/positions/{id}", handlers.GetPosition).Methods("GET")
+	api.Handle("/positions/{id}", admin(http.HandlerFunc(handlers.UpdatePosition))).Methods("PATCH")
+	api.HandleFunc("/positions/{id}/terms", handlers.ListPositionTerms).Methods("GET")
+	api.Handle("/positions/{id}/terms", admin(http.HandlerFunc(handlers.CreateTerm))).Methods("POST")
+	api.HandleFunc("/terms/{id}", handlers.GetTerm).Methods("GET")
+	api.Handle("/terms/{id}", admin(http.HandlerFunc(handlers.UpdateTerm))).Methods("PATCH")
+	api.Handle("/terms/{id}", admin(http.HandlerFunc(handlers.DeleteTerm))).Methods("DELETE")
+	api.Handle("/terms/{id}/close", admin(http.HandlerFunc(handlers.CloseTerm))).Methods("POST")
+
+	// Provenance routes
+	api.HandleFunc("/people/{id}/provenance", handlers.GetPersonProvenance).Methods("GET")
+
 	// Voting records routes
 	api.HandleFunc("/officials/{id}/voting-records", handlers.GetVotingRecords).Methods("GET")
-	api.HandleFunc("/voting-records", handlers.CreateVotingRecord).Methods("POST")
+	api.Handle("/voting-records", admin(http.HandlerFunc(handlers.CreateVotingRecord))).Methods("POST")
+	api.Handle("/voting-records/{id}", admin(http.HandlerFunc(handlers.DeleteVotingRecord))).Methods("DELETE")
 
 	// Ward statistics routes
 	api.HandleFunc("/wards/{ward}/statistics", handlers.GetWardStatistics).Methods("GET")
@@ -41,13 +75,65 @@ func SetupRoutes(router *mux.Router) {
 	api.HandleFunc("/wards/{ward}/metrics", handlers.GetWardMetrics).Methods("GET")
 	api.HandleFunc("/officials/{id}/voting-allies", handlers.GetVotingAllies).Methods("GET")
 	api.HandleFunc("/officials/{id}/recent-votes", handlers.GetRecentVotes).Methods("GET")
+
+	// Search routes
+	api.HandleFunc("/search", handlers.Search).Methods("GET")
+
+	// Field overrides
+	api.Handle("/overrides", editor(http.HandlerFunc(handlers.ListOverrides))).Methods("GET")
+	api.Handle("/overrides", editor(http.HandlerFunc(handlers.SetOverride))).Methods("PUT")
+	api.Handle("/overrides/{id}", editor(http.HandlerFunc(handlers.DeleteOverride))).Methods("DELETE")
+
+	// API key management routes
+	api.Handle("/admin/api-keys", admin(http.HandlerFunc(handlers.ListAPIKeys))).Methods("GET")
+	api.Handle("/admin/api-keys", admin(http.HandlerFunc(handlers.CreateAPIKey))).Methods("POST")
+	api.Handle("/admin/api-keys/{id}", admin(http.HandlerFunc(handlers.RevokeAPIKey))).Methods("DELETE")
+	api.Handle("/admin/usage", admin(http.HandlerFunc(handlers.GetUsage))).Methods("GET")
+	api.Handle("/admin/cache/purge", admin(http.HandlerFunc(handlers.PurgeCache))).Methods("POST")
+	api.Handle("/admin/jobs", admin(http.HandlerFunc(handlers.ListJobs))).Methods("GET")
+	api.Handle("/admin/jobs/runs", admin(http.HandlerFunc(handlers.ListJobRuns))).Methods("GET")
+	api.Handle("/admin/jobs/{name}/runs", admin(http.HandlerFunc(handlers.TriggerJob))).Methods("POST")
+	api.Handle("/admin/queue", admin(http.HandlerFunc(handlers.GetQueueStats))).Methods("GET")
+	api.Handle("/admin/queue/tasks", admin(http.HandlerFunc(handlers.ListQueueTasks))).Methods("GET")
+	api.Handle("/admin/queue/tasks/{id}/retry", admin(http.HandlerFunc(handlers.RetryQueueTask))).Methods("POST")
+	api.Handle("/admin/queue/tasks/{id}", admin(http.HandlerFunc(handlers.DiscardQueueTask))).Methods("DELETE")
+	api.Handle("/admin/review/items", admin(http.HandlerFunc(handlers.ListReviewItems))).Methods("GET")
+	api.Handle("/admin/review/items/{id}", admin(http.HandlerFunc(handlers.GetReviewItem))).Methods("GET")
+	api.Handle("/admin/review/items/{id}/resolve", admin(http.HandlerFunc(handlers.ResolveReviewItem))).Methods("POST")
+	api.Handle("/admin/review/items/{id}/dismiss", admin(http.HandlerFunc(handlers.DismissReviewItem))).Methods("POST")
+	api.Handle("/admin/audit", admin(http.HandlerFunc(handlers.ListAuditLog))).Methods("GET")
+	api.Handle("/admin/deleted/{entity}", admin(http.HandlerFunc(handlers.ListDeleted))).Methods("GET")
+	api.Handle("/admin/deleted/{entity}/{id}/restore", admin(http.HandlerFunc(handlers.RestoreDeleted))).Methods("POST")
+
+	// API description, generated from the routes above
+	api.HandleFunc("/openapi.json", OpenAPIHandler(router)).Methods("GET")
 }
 
-// HealthCheck returns the health status of the API
+// HealthCheck reports that the process is up. It checks no dependencies, so
+// the platform only restarts the instance when it stops responding.
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "healthy",
-		"message": "InfluencePower API is running",
+	json.NewEncoder(w).Encode(HealthStatus{
+		Status:  "healthy",
+		Message: "InfluencePower API is running",
 	})
 }
+
+// ReadinessCheck reports whether this instance should receive traffic. It
+// returns 503 when the database is unreachable or shutdown has begun, and
+// 200 with status "degraded" when synced data is older than its threshold,
+// since stale data is not fixed by routing elsewhere.
+func ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	var report health.Report
+	if server.Draining() {
+		report = health.Report{Status: health.StatusDraining, CheckedAt: time.Now().UTC(), Components: []health.Component{}}
+	} else {
+		report = health.Default.Check()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == health.StatusDown || report.Status == health.StatusDraining {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/handlers"
+)
+
+// TestSearchIndexPagesOffices checks that the in-process index reads every
+// office of a chunk of people when the offices fill more than one page
+func TestSearchIndexPagesOffices(t *testing.T) {
+	cache.Init(cache.Config{Disabled: true})
+	defer cache.Init(cache.Config{})
+	backend := handlers.SearchBackend
+	handlers.SearchBackend = "memory"
+	defer func() { handlers.SearchBackend = backend }()
+	handlers.ResetSearchIndex()
+	defer handlers.ResetSearchIndex()
+
+	// 300 people holding two offices each fill a 500-row page of offices
+	const people = 300
+	var officeReads int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		rows := []map[string]interface{}{}
+		switch path.Base(r.URL.Path) {
+		case "people":
+			if q.Get("id") == "" {
+				for id := 1; id <= people; id++ {
+					rows = append(rows, map[string]interface{}{"id": id, "full_name": "Person " + strconv.Itoa(id)})
+				}
+			}
+		case "current_officials":
+			if officeReads++; officeReads > 10 {
+				t.Errorf("current_officials read %d times", officeReads)
+				http.Error(w, "too many reads", http.StatusBadRequest)
+				return
+			}
+			ids := strings.Split(strings.Trim(strings.TrimPrefix(q.Get("person_id"), "in."), "()"), ",")
+			for _, id := range ids {
+				personID, _ := strconv.Atoi(id)
+				for _, title := range []string{"Alderperson", "Committee Chair"} {
+					rows = append(rows, map[string]interface{}{"person_id": personID, "title": title, "district_name": "Ward " + id})
+				}
+			}
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			if offset > len(rows) {
+				offset = len(rows)
+			}
+			if limit > 0 && offset+limit < len(rows) {
+				rows = rows[offset : offset+limit]
+			} else {
+				rows = rows[offset:]
+			}
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	defer srv.Close()
+	db.InitSupabase(srv.URL, "test")
+
+	router, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, basePath+"/search?q=Committee+Chair&type=person&limit=50", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if officeReads != 2 {
+		t.Errorf("expected offices read in two pages, got %d reads", officeReads)
+	}
+	var body struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Total != people {
+		t.Errorf("expected all %d people found by their last office, got %s", people, rec.Body.String())
+	}
+}
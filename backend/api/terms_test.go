@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+)
+
+// TestCreateTermSeatTaken checks that a term refused by the seats trigger,
+// because another write took the seat after the handler's check, is a 409
+func TestCreateTermSeatTaken(t *testing.T) {
+	keys, _ := auth.ParseStaticKeys("contract:admin:" + auth.HashAPIKey("ipk_contract"))
+	auth.Init(auth.Config{KeyStores: []auth.KeyStore{keys}})
+	defer auth.Init(auth.Config{})
+	cache.Init(cache.Config{Disabled: true})
+	defer cache.Init(cache.Config{})
+
+	// The position has no terms when the handler looks, but the insert
+	// fails as it would when a concurrent term was written first
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		table := path.Base(r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && table == "terms":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code": "23P01", "message": "position 3 has no seat free from 2024-06-01 to now", "details": null, "hint": null}`))
+		case table == "positions", table == "people":
+			w.Write([]byte(fixtures[table]))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer srv.Close()
+	db.InitSupabase(srv.URL, "test")
+
+	router, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, basePath+"/positions/3/terms", strings.NewReader(`{"person_id": 7, "start_date": "2024-06-01"}`))
+	req.Header.Set(auth.APIKeyHeader, "ipk_contract")
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != "conflict" {
+		t.Errorf("expected code conflict, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,158 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+// Error codes returned in the "code" field of error responses
+const (
+	CodeBadRequest          = "bad_request"
+	CodeInvalidJSON         = "invalid_json"
+	CodeValidationFailed    = "validation_failed"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeConflict            = "conflict"
+	CodePreconditionFailed  = "precondition_failed"
+	CodeGone                = "gone"
+	CodeUnprocessable       = "unprocessable_entity"
+	CodePayloadTooLarge     = "payload_too_large"
+	CodeRateLimited         = "rate_limited"
+	CodeInternal            = "internal_error"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeUpstreamTimeout     = "upstream_timeout"
+)
+
+// Error is an API error rendered as a JSON envelope
+type Error struct {
+	Status    int         `json:"-"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+
+	// cause is the underlying error; it is logged but never sent to clients
+	cause error
+}
+
+// envelope is the top-level JSON body of an error response
+type envelope struct {
+	Error *Error `json:"error"`
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithDetails returns a copy of e carrying details
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap returns a copy of e recording cause for server-side logging
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// New creates an API error
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest creates a 400 error
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Unauthorized creates a 401 error
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden creates a 403 error
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound creates a 404 error
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict creates a 409 error
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// PreconditionFailed creates a 412 error
+func PreconditionFailed(message string) *Error {
+	return New(http.StatusPreconditionFailed, CodePreconditionFailed, message)
+}
+
+// Gone creates a 410 error, for removed endpoints
+func Gone(message string) *Error {
+	return New(http.StatusGone, CodeGone, message)
+}
+
+// Internal creates a 500 error that hides cause from the client
+func Internal(cause error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "An unexpected error occurred").Wrap(cause)
+}
+
+// From converts any error into an API error. Errors that already are API
+// errors pass through; everything else is treated as an upstream failure.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return FromUpstream(err)
+}
+
+// Write renders err as a JSON error response. Server errors are logged with
+// their cause; clients only see the sanitized message.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := *From(err)
+	apiErr.RequestID = middleware.RequestIDFromContext(r.Context())
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "code", apiErr.Code, "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(envelope{Error: &apiErr})
+}
+
+// NotFoundHandler renders unknown routes as JSON errors
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, NotFound("Route not found"))
+	})
+}
+
+// MethodNotAllowedHandler renders unsupported methods as JSON errors
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed"))
+	})
+}
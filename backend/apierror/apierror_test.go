@@ -0,0 +1,63 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+func TestFromUpstreamStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{errors.New("(PGRST116) JSON object requested, multiple (or no) rows returned"), http.StatusNotFound},
+		{errors.New("(23505) duplicate key value violates unique constraint \"people_pkey\""), http.StatusConflict},
+		{errors.New("(23503) insert or update on table \"votes\" violates foreign key constraint"), http.StatusUnprocessableEntity},
+		{errors.New("(22P02) invalid input syntax for type integer: \"abc\""), http.StatusBadRequest},
+		{errors.New("(42P01) relation \"public.officials\" does not exist"), http.StatusBadGateway},
+		{fmt.Errorf("failed to search people: %w", errors.New("(57014) canceling statement due to statement timeout")), http.StatusGatewayTimeout},
+		{errors.New("dial tcp: lookup example.supabase.co: no such host"), http.StatusServiceUnavailable},
+		{errors.New("something else"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		if got := FromUpstream(c.err).Status; got != c.status {
+			t.Errorf("%v: expected %d, got %d", c.err, c.status, got)
+		}
+	}
+}
+
+func TestWriteEnvelopeHidesUpstreamMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/officials", nil)
+	req = req.WithContext(middleware.WithRequestID(req.Context(), "req-123"))
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, FromUpstream(errors.New("(42703) column people.secret does not exist")))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret") {
+		t.Errorf("response leaked upstream error: %s", rec.Body.String())
+	}
+
+	var body struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != CodeUpstreamError || body.Error.RequestID != "req-123" || body.Error.Message == "" {
+		t.Errorf("unexpected envelope: %+v", body.Error)
+	}
+}
@@ -0,0 +1,80 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// postgrestErrorPattern matches the "(code) message" errors produced by
+// postgrest-go, including when wrapped with a "context: " prefix
+var postgrestErrorPattern = regexp.MustCompile(`(?:^|: )\(([0-9A-Z]+)\) `)
+
+// UpstreamCode extracts the PostgREST or Postgres error code from err, or ""
+func UpstreamCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if m := postgrestErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// FromUpstream maps a database or upstream API error to an API error. The raw
+// upstream message is kept as the cause and never exposed to clients.
+// See https://postgrest.org/en/stable/references/errors.html
+func FromUpstream(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return New(http.StatusGatewayTimeout, CodeUpstreamTimeout, "The database did not respond in time").Wrap(err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return New(http.StatusGatewayTimeout, CodeUpstreamTimeout, "The database did not respond in time").Wrap(err)
+		}
+		return New(http.StatusServiceUnavailable, CodeUpstreamUnavailable, "The database is unavailable").Wrap(err)
+	}
+
+	code := UpstreamCode(err)
+	switch {
+	case code == "PGRST116":
+		return NotFound("Resource not found").Wrap(err)
+	case code == "PGRST103":
+		return New(http.StatusRequestedRangeNotSatisfiable, CodeBadRequest, "Requested range is not satisfiable").Wrap(err)
+	case code == "PGRST100", code == "PGRST102", code == "PGRST118", code == "PGRST120":
+		return BadRequest("Invalid query or request body").Wrap(err)
+	case code == "23505":
+		return New(http.StatusConflict, CodeConflict, "A record with the same unique key already exists").Wrap(err)
+	case code == "23503":
+		return New(http.StatusUnprocessableEntity, CodeUnprocessable, "The request references a record that does not exist").Wrap(err)
+	case code == "23502", code == "23514", code == "23P01":
+		return New(http.StatusUnprocessableEntity, CodeUnprocessable, "The request violates a data constraint").Wrap(err)
+	case code == "22P02", code == "22007", code == "22008", code == "22003", code == "22001":
+		return BadRequest("A value has an invalid format").Wrap(err)
+	case code == "57014":
+		return New(http.StatusGatewayTimeout, CodeUpstreamTimeout, "The database query timed out").Wrap(err)
+	case code == "PGRST000", code == "PGRST001", code == "PGRST002", code == "53300", code == "08006":
+		return New(http.StatusServiceUnavailable, CodeUpstreamUnavailable, "The database is unavailable").Wrap(err)
+	case code != "":
+		return New(http.StatusBadGateway, CodeUpstreamError, "The database returned an error").Wrap(err)
+	}
+
+	// postgrest-go reports transport failures as plain errors
+	msg := err.Error()
+	if strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host") {
+		return New(http.StatusServiceUnavailable, CodeUpstreamUnavailable, "The database is unavailable").Wrap(err)
+	}
+	if strings.HasPrefix(msg, "error parsing error response") {
+		return New(http.StatusBadGateway, CodeUpstreamError, "The database returned an unexpected response").Wrap(err)
+	}
+
+	return Internal(err)
+}
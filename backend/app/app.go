@@ -0,0 +1,130 @@
+// Package app assembles the API server from its configuration
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/api"
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
+	"github.com/Jsanchez767/InfluencePower/backend/config"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/handlers"
+	"github.com/Jsanchez767/InfluencePower/backend/health"
+	"github.com/Jsanchez767/InfluencePower/backend/metrics"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/overrides"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+	"github.com/Jsanchez767/InfluencePower/backend/ratelimit"
+	"github.com/Jsanchez767/InfluencePower/backend/review"
+	"github.com/Jsanchez767/InfluencePower/backend/scheduler"
+	"github.com/Jsanchez767/InfluencePower/backend/server"
+	"github.com/gorilla/mux"
+	"github.com/rs/cors"
+)
+
+// Serve runs the API server until ctx is done, then drains it. The database
+// client must already be initialized.
+func Serve(ctx context.Context, cfg config.Config) error {
+	// Initialize authentication
+	authConfig, err := loadAuthConfig(cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("invalid auth configuration: %w", err)
+	}
+	auth.Init(authConfig)
+
+	// Initialize rate limiting; tiers were checked by config validation
+	tiers, _ := ratelimit.ParseTiers(cfg.RateLimit.Tiers)
+	ratelimit.Init(ratelimit.Config{Tiers: tiers, TrustProxy: cfg.RateLimit.TrustProxy})
+
+	handlers.SearchBackend = cfg.Search.Backend
+
+	// Readiness checks; thresholds were checked by config validation
+	freshness, _ := health.ParseFreshness(cfg.Health.Freshness)
+	health.Init(health.Config{Freshness: freshness, Timeout: cfg.Health.Timeout})
+
+	// Initialize the response cache, invalidated when sync jobs record a sync
+	cache.Init(cache.Config{MaxEntries: cfg.Cache.MaxEntries, Disabled: cfg.Cache.Disabled})
+	go handlers.WatchSyncs(ctx, cfg.Cache.SyncPollInterval)
+
+	// Scheduled jobs run only when enabled, but admins can always trigger
+	// one. Running jobs are cancelled and recorded before Serve returns.
+	queue.Default = NewQueue(cfg.Queue)
+	review.Default = review.NewPostgrestStore()
+	overrides.Default = overrides.NewPostgrestStore()
+	scheduler.Default = NewScheduler(cfg, cfg.Scheduler.Enabled, queue.Default)
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Default.Start(schedulerCtx)
+	}()
+	defer func() {
+		stopScheduler()
+		<-schedulerDone
+	}()
+
+	// Setup router
+	router := mux.NewRouter()
+	router.NotFoundHandler = metrics.Instrument(apierror.NotFoundHandler())
+	router.MethodNotAllowedHandler = metrics.Instrument(apierror.MethodNotAllowedHandler())
+	api.SetupRoutes(router)
+
+	// Prometheus metrics, outside /api/v1 so scrapes skip auth, rate limits
+	// and the response cache
+	if cfg.Metrics.Enabled {
+		router.Handle("/metrics", metrics.Default.Handler(cfg.Metrics.Token)).Methods("GET")
+	}
+
+	// CORS configuration. Credentials are only allowed for an explicit origin
+	// list; browsers reject them with a wildcard origin anyway.
+	origins := cfg.CORS.AllowedOrigins
+	c := cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", auth.APIKeyHeader, middleware.RequestIDHeader, "If-None-Match", "If-Match", "traceparent", "tracestate"},
+		ExposedHeaders:   append([]string{middleware.RequestIDHeader, "ETag", "X-Cache"}, ratelimit.Headers...),
+		AllowCredentials: origins[0] != "*",
+	})
+
+	handler := middleware.RequestID(middleware.AccessLog(c.Handler(router)))
+
+	srv := server.New(":"+strconv.Itoa(cfg.Port), handler, cfg.Server)
+	slog.Info("server starting", "port", cfg.Port)
+	return srv.Run(ctx)
+}
+
+// loadAuthConfig builds the authenticator configuration
+func loadAuthConfig(cfg config.Auth) (auth.Config, error) {
+	authConfig := auth.Config{
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+	}
+
+	if cfg.APIKeys != "" {
+		static, err := auth.ParseStaticKeys(cfg.APIKeys)
+		if err != nil {
+			return authConfig, err
+		}
+		authConfig.KeyStores = append(authConfig.KeyStores, static)
+	}
+
+	if cfg.DBKeys {
+		auth.Keys = auth.NewPostgrestKeyStore(db.Client)
+		authConfig.KeyStores = append(authConfig.KeyStores, auth.Keys)
+	}
+
+	if cfg.JWKSFile != "" {
+		jwks, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return authConfig, err
+		}
+		authConfig.JWKS = jwks
+	}
+
+	return authConfig, nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/config"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/jobs"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+	"github.com/Jsanchez767/InfluencePower/backend/scheduler"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
+)
+
+// NewQueue returns the work queue store cfg selects
+func NewQueue(cfg config.Queue) queue.Store {
+	if cfg.Backend == "memory" {
+		return queue.NewMemoryStore()
+	}
+	return queue.NewPostgrestStore()
+}
+
+// JobOptions returns the options jobs run with by default, queueing their
+// units of work in units
+func JobOptions(cfg config.Config, units queue.Store) jobs.Options {
+	return jobs.Options{
+		Source: jobs.SourceLegistar,
+		Queue:  units,
+		QueueConfig: queue.Config{
+			Workers:     cfg.Sync.Workers,
+			MaxAttempts: cfg.Queue.MaxAttempts,
+			Backoff:     cfg.Queue.Backoff,
+			Lease:       cfg.Queue.Lease,
+		},
+		RequestRate: cfg.Sync.RequestRate,
+		BatchSize:   cfg.Sync.BatchSize,
+		Retention:   cfg.Sync.PurgeRetention,
+	}
+}
+
+// NewScheduler builds the scheduler of the sync, metrics, headshots and purge
+// jobs, recording locks and runs in the database and queueing units of work
+// in units. Schedules are only set when scheduled is true; jobs can always be
+// triggered through the admin API.
+func NewScheduler(cfg config.Config, scheduled bool, units queue.Store) *scheduler.Scheduler {
+	// Schedules and the timezone were checked by config validation
+	var schedules map[string]*scheduler.Schedule
+	if scheduled {
+		schedules, _ = scheduler.ParseSchedules(cfg.Scheduler.Schedules, jobs.Known)
+	}
+	location, _ := time.LoadLocation(cfg.Scheduler.Timezone)
+
+	names := []string{"sync", jobs.ComputeMetrics.Name, jobs.RefreshHeadshots.Name, jobs.PurgeDeleted.Name}
+	for _, job := range jobs.SyncJobs() {
+		names = append(names, job.Name)
+	}
+	opts := JobOptions(cfg, units)
+	// Manual runs are audited with the admin who triggered them, under the
+	// same request ID
+	opts.Actor = "scheduler"
+	tasks := make([]scheduler.Task, len(names))
+	for i, name := range names {
+		tasks[i] = jobTask(name, cfg.Sync.Jurisdiction, opts)
+	}
+
+	return scheduler.New(scheduler.Config{
+		Schedules: schedules,
+		Location:  location,
+		LockTTL:   cfg.Scheduler.LockTTL,
+	}, scheduler.NewPostgrestStore(), tasks)
+}
+
+// jobTask runs the jobs named name against jurisdiction, as "influencepower
+// sync" would with its default flags
+func jobTask(name, jurisdiction string, opts jobs.Options) scheduler.Task {
+	selected, _ := jobs.Named(name)
+	return scheduler.Task{
+		Name: name,
+		Run: func(ctx context.Context) (map[string]db.SyncCounts, error) {
+			ctx, span := tracing.StartJob(ctx, name)
+			defer span.End()
+
+			env, err := jobs.NewEnv(ctx, jurisdiction, opts)
+			if err != nil {
+				return nil, err
+			}
+			ctx = logging.With(ctx, "jurisdiction_id", env.Jurisdiction.ID)
+
+			counts, err := jobs.Execute(ctx, env, selected)
+			return counts.Values(), err
+		},
+	}
+}
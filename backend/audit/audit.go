@@ -0,0 +1,171 @@
+// Package audit keeps an append-only log of every write to the data,
+// whether made through the API or by a sync job: who made it, what it did
+// to which row, the row before and after, and the request ID of the call or
+// run that made it. Entries are never updated or deleted.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Actions of an entry. audit_log.action is not checked by the database, and
+// the schema comment predates restore; this is the full list.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRevoke  = "revoke"  // an API key
+	ActionRetry   = "retry"   // a dead unit of work
+	ActionTrigger = "trigger" // a job run
+	ActionRestore = "restore" // a soft-deleted row
+)
+
+// Entry is one write, as stored in audit_log
+type Entry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`               // the principal, e.g. "key:admin", or the job runner, e.g. "cli:jane"
+	Action    string          `json:"action"`              // e.g. "update"
+	Entity    string          `json:"entity"`              // the table written, e.g. "people"
+	EntityID  string          `json:"entity_id,omitempty"` // the row's key
+	Before    json.RawMessage `json:"before,omitempty"`    // the row, or the fields changed, before the write
+	After     json.RawMessage `json:"after,omitempty"`     // and after it
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// New returns an entry for a write, encoding before and after as JSON. A nil
+// before or after is left out.
+func New(actor, action, entity, entityID string, before, after interface{}, requestID string) Entry {
+	return Entry{
+		Actor:     actor,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Before:    encode(before),
+		After:     encode(after),
+		RequestID: requestID,
+	}
+}
+
+// encode returns v as JSON, or nil for nil or a value that cannot be encoded
+func encode(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return b
+}
+
+// Filter selects entries to list
+type Filter struct {
+	Entity   string
+	EntityID string
+	Actor    string
+	BeforeID int64 // only entries older than this one, to page back
+	Limit    int
+}
+
+// pageSize bounds the entries written per request
+const pageSize = 500
+
+// entryRow is the audit_log table representation. Every row of a bulk
+// insert must have the same keys, so none are omitted.
+type entryRow struct {
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  *string         `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID *string         `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// orNull returns raw, or JSON null if it is empty
+func orNull(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	return raw
+}
+
+// Append adds entries to the log
+func Append(client *postgrest.Client, entries []Entry) error {
+	now := time.Now().UTC()
+	for start := 0; start < len(entries); start += pageSize {
+		end := start + pageSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		rows := make([]entryRow, 0, end-start)
+		for _, e := range entries[start:end] {
+			created := e.CreatedAt
+			if created.IsZero() {
+				created = now
+			}
+			rows = append(rows, entryRow{
+				Actor:     e.Actor,
+				Action:    e.Action,
+				Entity:    e.Entity,
+				EntityID:  optional(e.EntityID),
+				Before:    orNull(e.Before),
+				After:     orNull(e.After),
+				RequestID: optional(e.RequestID),
+				CreatedAt: created,
+			})
+		}
+		_, _, err := client.From("audit_log").Insert(rows, false, "", "minimal", "").Execute()
+		if err != nil {
+			return fmt.Errorf("failed to append to audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the entries matching filter, newest first
+func List(client *postgrest.Client, filter Filter) ([]Entry, error) {
+	query := client.From("audit_log").
+		Select("id,actor,action,entity,entity_id,before,after,request_id,created_at", "", false)
+	if filter.Entity != "" {
+		query = query.Eq("entity", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Eq("entity_id", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query = query.Eq("actor", filter.Actor)
+	}
+	if filter.BeforeID > 0 {
+		query = query.Lt("id", fmt.Sprint(filter.BeforeID))
+	}
+	query = query.Order("id", &postgrest.OrderOpts{Ascending: false})
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit, "")
+	}
+
+	var entries []Entry
+	if _, err := query.ExecuteTo(&entries); err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,17 @@
+package audit
+
+import "testing"
+
+func TestNewLeavesOutNull(t *testing.T) {
+	var before map[string]interface{}
+	e := New("key:admin", ActionCreate, "people", "7", before, map[string]interface{}{"full_name": "Jane Doe"}, "abc")
+	if e.Before != nil {
+		t.Errorf("expected no before for a nil map, got %s", e.Before)
+	}
+	if string(e.After) != `{"full_name":"Jane Doe"}` {
+		t.Errorf("unexpected after %s", e.After)
+	}
+	if e := New("key:admin", ActionRevoke, "api_keys", "k1", nil, nil, ""); e.Before != nil || e.After != nil {
+		t.Errorf("expected neither before nor after, got %+v", e)
+	}
+}
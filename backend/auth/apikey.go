@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// APIKeyPrefix marks InfluencePower API keys so they are easy to recognize in leaks
+const APIKeyPrefix = "ipk_"
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is ever stored.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      Role       `json:"role"`
+	Prefix    string     `json:"prefix"` // First characters of the key, for identification
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// KeyStore looks up API keys by hash
+type KeyStore interface {
+	// Lookup returns the active key with the given hash, or nil if none exists
+	Lookup(ctx context.Context, hash string) (*APIKey, error)
+}
+
+// HashAPIKey returns the hex SHA-256 hash under which a key is stored. API keys
+// carry 256 bits of entropy, so a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey returns a new random API key and its hash
+func GenerateAPIKey() (key, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+// StaticKeyStore holds keys from configuration, keyed by hash
+type StaticKeyStore map[string]*APIKey
+
+// ParseStaticKeys parses "name:role:sha256hex" entries separated by commas or
+// semicolons, as used by the API_KEYS environment variable
+func ParseStaticKeys(spec string) (StaticKeyStore, error) {
+	store := make(StaticKeyStore)
+	for _, entry := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ';' }) {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid API key entry %q: expected name:role:sha256", entry)
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid API key entry %q: %w", entry, err)
+		}
+		hash := strings.ToLower(parts[2])
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid API key entry %q: hash must be 64 hex characters", entry)
+		}
+		store[hash] = &APIKey{ID: "static:" + parts[0], Name: parts[0], Role: role}
+	}
+	return store, nil
+}
+
+// Lookup implements KeyStore
+func (s StaticKeyStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	return s[hash], nil
+}
+
+// Caching of PostgrestKeyStore lookups
+const (
+	// keyCacheTTL bounds how long a revoked key may keep working
+	keyCacheTTL = time.Minute
+	// missCacheTTL is shorter, as most misses are mistyped or guessed keys
+	// that are not sent again, and a key created since should work soon
+	missCacheTTL = 5 * time.Second
+	// maxCachedKeys bounds the cache against lookups of many distinct keys
+	maxCachedKeys = 1000
+)
+
+// PostgrestKeyStore stores API keys in the api_keys table (db/schema_auth.sql)
+type PostgrestKeyStore struct {
+	client *postgrest.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+type cachedKey struct {
+	key     *APIKey
+	expires time.Time
+}
+
+// apiKeyRow is the api_keys table representation
+type apiKeyRow struct {
+	ID        string     `json:"id,omitempty"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	KeyPrefix string     `json:"key_prefix"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (row apiKeyRow) toKey() (*APIKey, error) {
+	role, err := ParseRole(row.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &APIKey{
+		ID:        row.ID,
+		Name:      row.Name,
+		Role:      role,
+		Prefix:    row.KeyPrefix,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+		RevokedAt: row.RevokedAt,
+	}, nil
+}
+
+// NewPostgrestKeyStore creates a key store backed by client
+func NewPostgrestKeyStore(client *postgrest.Client) *PostgrestKeyStore {
+	return &PostgrestKeyStore{client: client, cache: make(map[string]cachedKey)}
+}
+
+// Lookup implements KeyStore. Results are cached briefly, misses for less
+// time than keys, so that repeated requests don't each query the database.
+func (s *PostgrestKeyStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	s.mu.Lock()
+	if c, ok := s.cache[hash]; ok && time.Now().Before(c.expires) {
+		s.mu.Unlock()
+		return c.key, nil
+	}
+	s.mu.Unlock()
+
+	var rows []apiKeyRow
+	_, err := s.client.From("api_keys").
+		Select("id, name, role, key_prefix, created_at, expires_at, revoked_at", "", false).
+		Eq("key_hash", hash).
+		Is("revoked_at", "null").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	var key *APIKey
+	if len(rows) > 0 && (rows[0].ExpiresAt == nil || rows[0].ExpiresAt.After(time.Now())) {
+		if key, err = rows[0].toKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.store(hash, key)
+	s.mu.Unlock()
+	return key, nil
+}
+
+// store caches the result of looking up hash. When the cache is full,
+// expired entries are swept first, and the result is not cached if none
+// were. The caller holds s.mu.
+func (s *PostgrestKeyStore) store(hash string, key *APIKey) {
+	now := time.Now()
+	if len(s.cache) >= maxCachedKeys {
+		for h, c := range s.cache {
+			if !now.Before(c.expires) {
+				delete(s.cache, h)
+			}
+		}
+		if len(s.cache) >= maxCachedKeys {
+			return
+		}
+	}
+	ttl := keyCacheTTL
+	if key == nil {
+		ttl = missCacheTTL
+	}
+	s.cache[hash] = cachedKey{key: key, expires: now.Add(ttl)}
+}
+
+// Create stores a new key and returns it with its plaintext, which is not
+// recoverable afterwards
+func (s *PostgrestKeyStore) Create(ctx context.Context, name string, role Role, expiresAt *time.Time) (*APIKey, string, error) {
+	plaintext, hash, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	insert := map[string]interface{}{
+		"name":       name,
+		"role":       role.String(),
+		"key_prefix": plaintext[:len(APIKeyPrefix)+6],
+		"key_hash":   hash,
+		"expires_at": expiresAt,
+	}
+
+	var created []apiKeyRow
+	_, err = s.client.From("api_keys").
+		Insert(insert, false, "", "representation", "").
+		ExecuteTo(&created)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	if len(created) == 0 {
+		return nil, "", fmt.Errorf("failed to create API key: no row returned")
+	}
+
+	key, err := created[0].toKey()
+	return key, plaintext, err
+}
+
+// List returns all keys, including revoked ones
+func (s *PostgrestKeyStore) List(ctx context.Context) ([]*APIKey, error) {
+	var rows []apiKeyRow
+	_, err := s.client.From("api_keys").
+		Select("id, name, role, key_prefix, created_at, expires_at, revoked_at", "", false).
+		Order("created_at", nil).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]*APIKey, 0, len(rows))
+	for _, row := range rows {
+		key, err := row.toKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Revoke marks a key as revoked. It reports false if no active key had id.
+func (s *PostgrestKeyStore) Revoke(ctx context.Context, id string) (bool, error) {
+	var rows []apiKeyRow
+	_, err := s.client.From("api_keys").
+		Update(map[string]interface{}{"revoked_at": time.Now().UTC()}, "representation", "").
+		Eq("id", id).
+		Is("revoked_at", "null").
+		ExecuteTo(&rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	// Drop cached lookups so the revocation applies immediately on this instance
+	s.mu.Lock()
+	s.cache = make(map[string]cachedKey)
+	s.mu.Unlock()
+
+	return len(rows) > 0, nil
+}
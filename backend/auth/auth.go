@@ -0,0 +1,234 @@
+// Package auth authenticates API clients with API keys or Supabase-issued
+// JWTs and enforces role-based access on routes.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+)
+
+// Role is an access level. Higher roles include the permissions of lower ones.
+type Role int
+
+const (
+	RoleAnonymous Role = iota
+	RoleReader
+	RoleEditor
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleReader:
+		return "reader"
+	case RoleEditor:
+		return "editor"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "anonymous"
+}
+
+// MarshalText encodes the role as its name
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a role name
+func (r *Role) UnmarshalText(text []byte) error {
+	role, err := ParseRole(string(text))
+	if err != nil {
+		return err
+	}
+	*r = role
+	return nil
+}
+
+// ParseRole parses "reader", "editor" or "admin"
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "reader":
+		return RoleReader, nil
+	case "editor":
+		return RoleEditor, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+	return RoleAnonymous, fmt.Errorf("unknown role %q", s)
+}
+
+// Principal is an authenticated client
+type Principal struct {
+	Subject string // API key name or JWT subject
+	Role    Role
+	Method  string // "api_key" or "jwt"
+	KeyID   string // API key ID, if authenticated with one
+}
+
+// ErrInvalidCredentials is returned when credentials are present but not valid
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// APIKeyHeader is the header clients may use instead of Authorization: Bearer
+const APIKeyHeader = "X-API-Key"
+
+// Config configures the authenticator
+type Config struct {
+	KeyStores []KeyStore // Consulted in order for API keys
+	JWKS      *JWKS      // Keys for verifying JWTs; nil disables JWT auth
+	Issuer    string     // Required JWT "iss", if set
+	Audience  string     // Required JWT "aud", if set
+}
+
+// Authenticator resolves request credentials to a Principal
+type Authenticator struct {
+	cfg Config
+}
+
+// NewAuthenticator creates an authenticator from cfg
+func NewAuthenticator(cfg Config) *Authenticator {
+	return &Authenticator{cfg: cfg}
+}
+
+// Default is the authenticator used by the Authenticate middleware
+var Default = NewAuthenticator(Config{})
+
+// Keys is the database key store used by the key management endpoints, or
+// nil when API keys are only configured statically
+var Keys *PostgrestKeyStore
+
+// Init configures the default authenticator
+func Init(cfg Config) {
+	Default = NewAuthenticator(cfg)
+}
+
+// Authenticate returns the principal for the credentials in r, nil if the
+// request carries no credentials, or ErrInvalidCredentials
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := r.Header.Get(APIKeyHeader)
+	if token == "" {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			return nil, nil
+		}
+		scheme, value, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(value) == "" {
+			return nil, ErrInvalidCredentials
+		}
+		token = strings.TrimSpace(value)
+	}
+
+	if strings.Count(token, ".") == 2 {
+		if a.cfg.JWKS == nil {
+			return nil, ErrInvalidCredentials
+		}
+		claims, err := a.cfg.JWKS.Verify(token, a.cfg.Issuer, a.cfg.Audience)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		return principalFromClaims(claims), nil
+	}
+
+	hash := HashAPIKey(token)
+	for _, store := range a.cfg.KeyStores {
+		key, err := store.Lookup(r.Context(), hash)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			return &Principal{Subject: key.Name, Role: key.Role, Method: "api_key", KeyID: key.ID}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// HasCredentials reports whether r carries an API key or an Authorization
+// header, whether or not they are valid
+func HasCredentials(r *http.Request) bool {
+	return r.Header.Get(APIKeyHeader) != "" || r.Header.Get("Authorization") != ""
+}
+
+// principalFromClaims maps Supabase JWT claims to a principal. Roles are read
+// from app_metadata, which only the service role can write; user_metadata is
+// user-editable and deliberately ignored.
+func principalFromClaims(claims map[string]interface{}) *Principal {
+	p := &Principal{Role: RoleReader, Method: "jwt"}
+	if sub, ok := claims["sub"].(string); ok {
+		p.Subject = sub
+	}
+	if meta, ok := claims["app_metadata"].(map[string]interface{}); ok {
+		if s, ok := meta["role"].(string); ok {
+			if role, err := ParseRole(s); err == nil {
+				p.Role = role
+			}
+		}
+	}
+	return p
+}
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, or nil for anonymous requests
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey).(*Principal)
+	return p
+}
+
+// RoleFromContext returns the role of the request's principal
+func RoleFromContext(ctx context.Context) Role {
+	if p := PrincipalFromContext(ctx); p != nil {
+		return p.Role
+	}
+	return RoleAnonymous
+}
+
+// Authenticate is middleware that attaches the request's principal to its
+// context. Anonymous requests pass through; invalid credentials are rejected.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := Default.Authenticate(r)
+		if err != nil {
+			if errors.Is(err, ErrInvalidCredentials) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="influencepower", error="invalid_token"`)
+				apierror.Write(w, r, apierror.Unauthorized("Invalid API key or token"))
+				return
+			}
+			apierror.Write(w, r, apierror.FromUpstream(err))
+			return
+		}
+		if p != nil {
+			r = r.WithContext(WithPrincipal(r.Context(), p))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Require returns middleware that only admits principals with at least role
+func Require(role Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := PrincipalFromContext(r.Context())
+			if p == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="influencepower"`)
+				apierror.Write(w, r, apierror.Unauthorized("Authentication required"))
+				return
+			}
+			if p.Role < role {
+				apierror.Write(w, r, apierror.Forbidden(fmt.Sprintf("This action requires the %s role", role)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
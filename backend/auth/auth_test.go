@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signToken builds a JWT signed with key, which is an *rsa.PrivateKey,
+// *ecdsa.PrivateKey or []byte
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	}
+	return signed + "." + b64(sig)
+}
+
+func testJWKS(t *testing.T) (*JWKS, *rsa.PrivateKey, *ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	set := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "oct", "kid": "hs1", "k": %q},
+		{"kty": "RSA", "kid": "enc1", "use": "enc", "n": "AQAB", "e": "AQAB"}
+	]}`,
+		b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b64(ecKey.X.Bytes()), b64(ecKey.Y.Bytes()),
+		b64(secret))
+
+	jwks, err := ParseJWKS([]byte(set))
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	return jwks, rsaKey, ecKey, secret
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":          "user-1",
+		"iss":          "https://example.supabase.co/auth/v1",
+		"aud":          "authenticated",
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"app_metadata": map[string]interface{}{"role": "editor"},
+	}
+}
+
+func TestVerifySupportedAlgorithms(t *testing.T) {
+	jwks, rsaKey, ecKey, secret := testJWKS(t)
+
+	tokens := map[string]string{
+		"RS256": signToken(t, "RS256", "rsa1", rsaKey, validClaims()),
+		"ES256": signToken(t, "ES256", "ec1", ecKey, validClaims()),
+		"HS256": signToken(t, "HS256", "hs1", secret, validClaims()),
+	}
+	for alg, token := range tokens {
+		claims, err := jwks.Verify(token, "https://example.supabase.co/auth/v1", "authenticated")
+		if err != nil {
+			t.Errorf("%s: %v", alg, err)
+			continue
+		}
+		if claims["sub"] != "user-1" {
+			t.Errorf("%s: unexpected claims %v", alg, claims)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	jwks, rsaKey, _, secret := testJWKS(t)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	noExp := validClaims()
+	delete(noExp, "exp")
+	wrongAud := validClaims()
+	wrongAud["aud"] = []string{"other"}
+
+	valid := signToken(t, "RS256", "rsa1", rsaKey, validClaims())
+	cases := map[string]string{
+		"expired":        signToken(t, "RS256", "rsa1", rsaKey, expired),
+		"no expiry":      signToken(t, "RS256", "rsa1", rsaKey, noExp),
+		"wrong audience": signToken(t, "RS256", "rsa1", rsaKey, wrongAud),
+		"unknown kid":    signToken(t, "RS256", "missing", rsaKey, validClaims()),
+		"alg confusion":  signToken(t, "HS256", "rsa1", secret, validClaims()),
+		"tampered":       valid[:len(valid)-4] + "AAAA",
+		"malformed":      "not.a.jwt",
+	}
+	for name, token := range cases {
+		if _, err := jwks.Verify(token, "", "authenticated"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseStaticKeys(t *testing.T) {
+	hash := HashAPIKey("ipk_secret")
+	store, err := ParseStaticKeys("frontend:reader:" + HashAPIKey("ipk_other") + "; ops:admin:" + hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := store.Lookup(context.Background(), hash)
+	if key == nil || key.Name != "ops" || key.Role != RoleAdmin {
+		t.Errorf("unexpected key %+v", key)
+	}
+
+	for _, spec := range []string{"ops:admin", "ops:root:" + hash, "ops:admin:abc"} {
+		if _, err := ParseStaticKeys(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	jwks, rsaKey, _, _ := testJWKS(t)
+	keys, _ := ParseStaticKeys("ops:admin:" + HashAPIKey("ipk_secret"))
+	a := NewAuthenticator(Config{KeyStores: []KeyStore{keys}, JWKS: jwks})
+
+	cases := []struct {
+		name    string
+		header  string
+		value   string
+		role    Role
+		invalid bool
+	}{
+		{"anonymous", "", "", RoleAnonymous, false},
+		{"api key header", APIKeyHeader, "ipk_secret", RoleAdmin, false},
+		{"api key bearer", "Authorization", "Bearer ipk_secret", RoleAdmin, false},
+		{"jwt", "Authorization", "Bearer " + signToken(t, "RS256", "rsa1", rsaKey, validClaims()), RoleEditor, false},
+		{"unknown key", APIKeyHeader, "ipk_wrong", RoleAnonymous, true},
+		{"basic auth", "Authorization", "Basic dXNlcjpwYXNz", RoleAnonymous, true},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.header != "" {
+			r.Header.Set(c.header, c.value)
+		}
+		p, err := a.Authenticate(r)
+		if c.invalid {
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("%s: expected invalid credentials, got %v", c.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		role := RoleAnonymous
+		if p != nil {
+			role = p.Role
+		}
+		if role != c.role {
+			t.Errorf("%s: expected role %s, got %s", c.name, c.role, role)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	keys, _ := ParseStaticKeys("reader:reader:" + HashAPIKey("ipk_reader") + ",admin:admin:" + HashAPIKey("ipk_admin"))
+	Init(Config{KeyStores: []KeyStore{keys}})
+	defer Init(Config{})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := Authenticate(Require(RoleAdmin)(ok))
+
+	cases := map[string]int{
+		"":           http.StatusUnauthorized,
+		"ipk_bogus":  http.StatusUnauthorized,
+		"ipk_reader": http.StatusForbidden,
+		"ipk_admin":  http.StatusNoContent,
+	}
+	for key, status := range cases {
+		r := httptest.NewRequest(http.MethodDelete, "/api/v1/officials/1", nil)
+		if key != "" {
+			r.Header.Set(APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != status {
+			t.Errorf("%q: expected %d, got %d", key, status, rec.Code)
+		}
+	}
+}
+
+func TestPostgrestKeyStoreCacheBounded(t *testing.T) {
+	s := NewPostgrestKeyStore(nil)
+	for i := 0; i < maxCachedKeys; i++ {
+		s.store(fmt.Sprint(i), nil)
+	}
+	if c := s.cache["0"]; c.expires.After(time.Now().Add(missCacheTTL)) {
+		t.Errorf("miss cached until %v, expected at most %v", c.expires, missCacheTTL)
+	}
+
+	s.store("full", nil)
+	if _, ok := s.cache["full"]; ok || len(s.cache) != maxCachedKeys {
+		t.Fatalf("expected a full cache to refuse new entries, has %d", len(s.cache))
+	}
+
+	s.cache["0"] = cachedKey{expires: time.Now().Add(-time.Second)}
+	s.store("full", &APIKey{Name: "full"})
+	if _, ok := s.cache["0"]; ok {
+		t.Error("expected the expired entry to be swept")
+	}
+	if c, ok := s.cache["full"]; !ok || c.key == nil || c.expires.Before(time.Now().Add(keyCacheTTL-time.Second)) {
+		t.Errorf("expected the key cached for %v, got %+v", keyCacheTTL, c)
+	}
+}
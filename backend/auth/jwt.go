@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking exp and nbf
+const clockSkew = time.Minute
+
+// JWKS is a set of keys for verifying JWTs, loaded from a local JSON Web Key
+// Set file such as the one published at
+// https://<project>.supabase.co/auth/v1/.well-known/jwks.json
+type JWKS struct {
+	keys map[string]jwk
+}
+
+// jwk is a parsed JSON Web Key
+type jwk struct {
+	alg string // "RS256", "ES256" or "HS256"
+	key interface{}
+}
+
+// rawJWK is the wire format of a JSON Web Key
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS reads a JWKS file
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set. Keys with unsupported types or uses are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	jwks := &JWKS{keys: make(map[string]jwk)}
+	for _, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		k, err := parseJWK(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", raw.Kid, err)
+		}
+		jwks.keys[raw.Kid] = k
+	}
+	if len(jwks.keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return jwks, nil
+}
+
+func parseJWK(raw rawJWK) (jwk, error) {
+	switch raw.Kty {
+	case "RSA":
+		n, err := decodeBigInt(raw.N)
+		if err != nil {
+			return jwk{}, err
+		}
+		e, err := decodeBigInt(raw.E)
+		if err != nil {
+			return jwk{}, err
+		}
+		return jwk{alg: "RS256", key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		if raw.Crv != "P-256" {
+			return jwk{}, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := decodeBigInt(raw.X)
+		if err != nil {
+			return jwk{}, err
+		}
+		y, err := decodeBigInt(raw.Y)
+		if err != nil {
+			return jwk{}, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return jwk{}, errors.New("point is not on curve")
+		}
+		return jwk{alg: "ES256", key: pub}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(raw.K)
+		if err != nil || len(secret) == 0 {
+			return jwk{}, errors.New("invalid symmetric key")
+		}
+		return jwk{alg: "HS256", key: secret}, nil
+	}
+	return jwk{}, fmt.Errorf("unsupported key type %q", raw.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Verify checks the signature and registered claims of token and returns its
+// claims. issuer and audience are only checked when non-empty.
+func (s *JWKS) Verify(token, issuer, audience string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+
+	key, ok := s.keys[header.Kid]
+	if !ok && header.Kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			key, ok = k, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	// The algorithm is fixed by the key type, never by the token, to prevent
+	// algorithm confusion attacks
+	if header.Alg != key.alg {
+		return nil, fmt.Errorf("unexpected algorithm %q", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+
+	switch k := key.key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, errors.New("invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		if len(sig) != 64 {
+			return nil, errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		sVal := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, sVal) {
+			return nil, errors.New("invalid signature")
+		}
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return nil, errors.New("invalid signature")
+		}
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	if err := checkClaims(claims, issuer, audience, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims validates exp, nbf, iss and aud
+func checkClaims(claims map[string]interface{}, issuer, audience string, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+
+	if issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+
+	if audience != "" {
+		matched := false
+		switch aud := claims["aud"].(type) {
+		case string:
+			matched = aud == audience
+		case []interface{}:
+			for _, a := range aud {
+				if a == audience {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return errors.New("unexpected audience")
+		}
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
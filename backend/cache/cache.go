@@ -0,0 +1,310 @@
+// Package cache caches read-only API responses in process and handles HTTP
+// revalidation with ETags.
+//
+// Responses are cached per route according to a Policy, keyed on the request
+// path and canonical query string, and tagged with the data entities they are
+// built from so that a finished sync can invalidate exactly the affected
+// entries.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Data entities used as cache tags. They match the entity names recorded by
+// sync jobs in the sync_state table.
+const (
+	TagPeople  = "people"
+	TagTerms   = "terms"
+	TagMatters = "matters"
+	TagVotes   = "votes"
+	TagEvents  = "events"
+	TagBodies  = "bodies"
+	TagMetrics = "metrics"
+)
+
+// Policy controls caching for one route
+type Policy struct {
+	MaxAge  time.Duration // Cache-Control max-age for clients
+	TTL     time.Duration // How long the server keeps the response; 0 disables server caching
+	Tags    []string      // Entities the response is built from
+	NoStore bool          // Forbid caching anywhere, e.g. for admin data
+}
+
+// CacheControl renders the Cache-Control header value for p
+func (p Policy) CacheControl() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	return fmt.Sprintf("public, max-age=%d", int(p.MaxAge.Seconds()))
+}
+
+var (
+	officialTags = []string{TagPeople, TagTerms, TagMetrics}
+	voteTags     = []string{TagVotes, TagMatters}
+	metricTags   = []string{TagMetrics, TagVotes, TagPeople, TagTerms}
+)
+
+// DefaultPolicies are the policies for routes, keyed by mux path template.
+// Data only changes when a sync runs, so server TTLs are generous and rely on
+// invalidation; client max-ages are kept short so browsers revalidate.
+var DefaultPolicies = map[string]Policy{
+	"/api/v1/officials":                     {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: officialTags},
+	"/api/v1/officials/{id}":                {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: officialTags},
+	"/api/v1/officials/party/{party}":       {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: officialTags},
+	"/api/v1/officials/ward/{ward}":         {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: officialTags},
+	"/api/v1/officials/{id}/voting-records": {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: voteTags},
+	"/api/v1/officials/{id}/recent-votes":   {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: voteTags},
+	"/api/v1/officials/{id}/committees":     {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagBodies}},
+	"/api/v1/committees":                    {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagBodies}},
+	"/api/v1/wards/{ward}/statistics":       {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: officialTags},
+	"/api/v1/people/{id}":                   {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagPeople}},
+	"/api/v1/people/{id}/provenance":        {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagPeople}},
+	"/api/v1/positions/{id}":                {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagTerms}},
+	"/api/v1/positions/{id}/terms":          {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagTerms}},
+	"/api/v1/terms/{id}":                    {MaxAge: 5 * time.Minute, TTL: time.Hour, Tags: []string{TagTerms}},
+	"/api/v1/officials/{id}/metrics":        {MaxAge: 10 * time.Minute, TTL: time.Hour, Tags: metricTags},
+	"/api/v1/wards/{ward}/metrics":          {MaxAge: 10 * time.Minute, TTL: time.Hour, Tags: metricTags},
+	"/api/v1/officials/{id}/voting-allies":  {MaxAge: 10 * time.Minute, TTL: time.Hour, Tags: metricTags},
+	"/api/v1/search":                        {MaxAge: time.Minute, TTL: 10 * time.Minute, Tags: []string{TagPeople, TagTerms, TagMatters, TagBodies, TagEvents}},
+	"/api/v1/openapi.json":                  {MaxAge: time.Hour, TTL: 24 * time.Hour},
+	"/api/v1/health":                        {NoStore: true},
+	"/api/v1/health/live":                   {NoStore: true},
+	"/api/v1/health/ready":                  {NoStore: true},
+	"/api/v1/admin/api-keys":                {NoStore: true},
+	"/api/v1/admin/usage":                   {NoStore: true},
+	"/api/v1/admin/jobs":                    {NoStore: true},
+	"/api/v1/admin/jobs/runs":               {NoStore: true},
+	"/api/v1/admin/queue":                   {NoStore: true},
+	"/api/v1/admin/queue/tasks":             {NoStore: true},
+	"/api/v1/admin/review/items":            {NoStore: true},
+	"/api/v1/admin/review/items/{id}":       {NoStore: true},
+	"/api/v1/admin/audit":                   {NoStore: true},
+	"/api/v1/admin/deleted/{entity}":        {NoStore: true},
+	"/api/v1/overrides":                     {NoStore: true},
+}
+
+// maxEntryBytes is the largest response body kept in the cache
+const maxEntryBytes = 1 << 20
+
+// Entry is a cached response
+type Entry struct {
+	Status      int
+	ContentType string
+	Body        []byte
+	ETag        string
+	Tags        []string
+}
+
+// Config configures a Cache
+type Config struct {
+	MaxEntries int               // Default 1000
+	Policies   map[string]Policy // Default DefaultPolicies
+	Disabled   bool              // Disables server caching; ETags and Cache-Control still apply
+}
+
+// Cache caches responses according to per-route policies
+type Cache struct {
+	cfg Config
+	lru *LRU
+}
+
+// New creates a cache from cfg
+func New(cfg Config) *Cache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+	if cfg.Policies == nil {
+		cfg.Policies = DefaultPolicies
+	}
+	return &Cache{cfg: cfg, lru: NewLRU(cfg.MaxEntries)}
+}
+
+// Default is the cache used by Middleware
+var Default = New(Config{})
+
+// Init configures the default cache
+func Init(cfg Config) {
+	Default = New(cfg)
+}
+
+// Invalidate drops cached responses built from any of the given entities. With
+// no entities it drops everything. It returns the number of entries removed.
+func (c *Cache) Invalidate(entities ...string) int {
+	if len(entities) == 0 {
+		return c.lru.RemoveIf(func(*Entry) bool { return true })
+	}
+	return c.lru.RemoveIf(func(e *Entry) bool {
+		for _, tag := range e.Tags {
+			for _, entity := range entities {
+				if tag == entity {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// Len returns the number of cached responses
+func (c *Cache) Len() int {
+	return c.lru.Len()
+}
+
+// Key returns the cache key for r: its path and canonical query string
+func Key(r *http.Request) string {
+	// Encode sorts by key, so equivalent queries share an entry
+	return r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// ETag returns a strong entity tag for body
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Middleware applies the default cache. It must be added with Router.Use so
+// that the matched route is known.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default.ServeHTTP(w, r, next)
+	})
+}
+
+// ServeHTTP serves r from the cache when possible, otherwise calls next and
+// caches a successful response
+func (c *Cache) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	policy, ok := c.policy(r)
+	if !ok || r.Method != http.MethodGet {
+		next.ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", policy.CacheControl())
+	if policy.NoStore {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	key := Key(r)
+	useCache := !c.cfg.Disabled && policy.TTL > 0
+	if useCache && !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		if e, ok := c.lru.Get(key); ok {
+			w.Header().Set("X-Cache", "HIT")
+			write(w, r, e)
+			return
+		}
+	}
+
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	if rec.passthrough {
+		return
+	}
+
+	e := &Entry{
+		Status:      rec.status,
+		ContentType: rec.Header().Get("Content-Type"),
+		Body:        rec.buf.Bytes(),
+		ETag:        ETag(rec.buf.Bytes()),
+		Tags:        policy.Tags,
+	}
+	if useCache {
+		w.Header().Set("X-Cache", "MISS")
+		c.lru.Set(key, e, policy.TTL)
+	}
+	write(w, r, e)
+}
+
+func (c *Cache) policy(r *http.Request) (Policy, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return Policy{}, false
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return Policy{}, false
+	}
+	p, ok := c.cfg.Policies[tpl]
+	return p, ok
+}
+
+// write sends e, or 304 Not Modified if the client already has it
+func write(w http.ResponseWriter, r *http.Request, e *Entry) {
+	h := w.Header()
+	h.Set("ETag", e.ETag)
+	if matchesETag(r.Header.Get("If-None-Match"), e.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", e.ContentType)
+	h.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	w.WriteHeader(e.Status)
+	w.Write(e.Body)
+}
+
+// matchesETag reports whether an If-None-Match header matches etag. Per RFC
+// 9110 If-None-Match uses weak comparison, so W/ prefixes are ignored.
+func matchesETag(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder buffers a successful response so it can be cached. Other statuses
+// and oversized bodies are passed straight through to the client.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	passthrough bool
+	buf         bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+	if status != http.StatusOK {
+		rec.passthrough = true
+		rec.ResponseWriter.Header().Del("Cache-Control")
+		rec.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.passthrough {
+		return rec.ResponseWriter.Write(b)
+	}
+	if rec.buf.Len()+len(b) > maxEntryBytes {
+		// Too large to cache: flush what we have and stream the rest
+		rec.passthrough = true
+		rec.ResponseWriter.WriteHeader(rec.status)
+		if _, err := rec.ResponseWriter.Write(rec.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		rec.buf.Reset()
+		return rec.ResponseWriter.Write(b)
+	}
+	return rec.buf.Write(b)
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLRUEvictionAndExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", &Entry{Body: []byte("a")}, time.Minute)
+	c.Set("b", &Entry{Body: []byte("b")}, time.Minute)
+	c.Get("a") // a is now most recently used
+	c.Set("c", &Entry{Body: []byte("c")}, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to be kept")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to expire")
+	}
+}
+
+// testServer routes /items/{id} through a cache and counts handler calls
+func testServer(c *Cache, status int) (*mux.Router, *int) {
+	calls := 0
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.ServeHTTP(w, r, next)
+		})
+	})
+	router.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"id": %q, "call": %d}`, mux.Vars(r)["id"], calls)
+	})
+	return router, &calls
+}
+
+func get(router http.Handler, url string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, url, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMiddlewareCachesAndRevalidates(t *testing.T) {
+	c := New(Config{Policies: map[string]Policy{
+		"/items/{id}": {MaxAge: time.Minute, TTL: time.Hour, Tags: []string{TagVotes}},
+	}})
+	router, calls := testServer(c, http.StatusOK)
+
+	first := get(router, "/items/1?b=2&a=1")
+	if first.Header().Get("X-Cache") != "MISS" || first.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("unexpected headers %v", first.Header())
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" || etag != ETag(first.Body.Bytes()) {
+		t.Fatalf("expected strong ETag of body, got %q", etag)
+	}
+
+	// Same query in a different order is served from the cache
+	second := get(router, "/items/1?a=1&b=2")
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() || *calls != 1 {
+		t.Errorf("expected cache hit, got %s after %d calls", second.Header().Get("X-Cache"), *calls)
+	}
+
+	notModified := get(router, "/items/1?a=1&b=2", "If-None-Match", `"other", W/`+etag)
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Errorf("expected 304 with empty body, got %d %q", notModified.Code, notModified.Body.String())
+	}
+
+	// no-cache bypasses the stored response
+	get(router, "/items/1?a=1&b=2", "Cache-Control", "no-cache")
+	if *calls != 2 {
+		t.Errorf("expected no-cache to reach the handler, got %d calls", *calls)
+	}
+
+	if n := c.Invalidate(TagPeople); n != 0 {
+		t.Errorf("expected unrelated invalidation to keep entries, removed %d", n)
+	}
+	if n := c.Invalidate(TagVotes); n != 1 {
+		t.Errorf("expected 1 entry invalidated, got %d", n)
+	}
+	if rec := get(router, "/items/1?a=1&b=2"); rec.Header().Get("X-Cache") != "MISS" || *calls != 3 {
+		t.Errorf("expected miss after invalidation")
+	}
+}
+
+func TestMiddlewareSkipsErrors(t *testing.T) {
+	c := New(Config{Policies: map[string]Policy{"/items/{id}": {MaxAge: time.Minute, TTL: time.Hour}}})
+	router, calls := testServer(c, http.StatusNotFound)
+
+	for i := 0; i < 2; i++ {
+		rec := get(router, "/items/1")
+		if rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" || rec.Header().Get("Cache-Control") != "" {
+			t.Errorf("expected uncached 404, got %d %v", rec.Code, rec.Header())
+		}
+	}
+	if *calls != 2 || c.Len() != 0 {
+		t.Errorf("expected errors not to be cached")
+	}
+}
+
+func TestNoStorePolicy(t *testing.T) {
+	c := New(Config{Policies: map[string]Policy{"/items/{id}": {NoStore: true}}})
+	router, calls := testServer(c, http.StatusOK)
+
+	get(router, "/items/1")
+	rec := get(router, "/items/1")
+	if rec.Header().Get("Cache-Control") != "no-store" || rec.Header().Get("ETag") != "" || *calls != 2 {
+		t.Errorf("expected no-store response to bypass caching, got %v", rec.Header())
+	}
+}
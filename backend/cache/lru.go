@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded cache whose entries also expire after a TTL
+type LRU struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List // front is most recently used
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   *Entry
+	expires time.Time
+}
+
+// NewLRU creates a cache holding at most maxEntries entries
+func NewLRU(maxEntries int) *LRU {
+	return &LRU{maxEntries: maxEntries, now: time.Now, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the unexpired entry for key
+func (c *LRU) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Set stores value under key for ttl, evicting the least recently used entry if full
+func (c *LRU) Set(key string, value *Entry, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+	}
+}
+
+// RemoveIf removes every entry for which match returns true and reports how many were removed
+func (c *LRU) RemoveIf(match func(*Entry) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		if match(el.Value.(*lruEntry).value) {
+			c.remove(el)
+			removed++
+		}
+		el = next
+	}
+	return removed
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
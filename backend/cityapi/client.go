@@ -1,12 +1,24 @@
 package cityapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/metrics"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -17,6 +29,18 @@ const (
 type Client struct {
 	HTTPClient *http.Client
 	BaseURL    string
+
+	// MaxRetries is how many times a failed request is retried, waiting
+	// RetryBackoff before the first retry and doubling it each time
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// Limiter paces every request and retry. Copies made with WithContext
+	// share it, so concurrent workers together stay under its rate; nil
+	// means no limit.
+	Limiter *RateLimiter
+
+	ctx context.Context
 }
 
 // NewClient creates a new City API client
@@ -25,8 +49,26 @@ func NewClient() *Client {
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		BaseURL: BaseURL,
+		BaseURL:      BaseURL,
+		MaxRetries:   2,
+		RetryBackoff: 500 * time.Millisecond,
+		Limiter:      NewRateLimiter(DefaultRequestRate),
+	}
+}
+
+// WithContext returns a copy of the client whose requests are bound to ctx
+// and carry its request ID and log fields
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
 }
 
 // Matter represents legislation from the City Clerk API
@@ -197,6 +239,15 @@ func (c *Client) GetEventByID(eventID int) (*Event, error) {
 	return &event, err
 }
 
+// GetEventItems fetches the agenda items of an event
+func (c *Client) GetEventItems(eventID int) ([]EventItem, error) {
+	endpoint := fmt.Sprintf("%s/events/%d/eventitems", c.BaseURL, eventID)
+	
+	var items []EventItem
+	err := c.doRequest(endpoint, &items)
+	return items, err
+}
+
 // GetVotes fetches votes for a matter
 func (c *Client) GetVotes(matterID int) ([]Vote, error) {
 	endpoint := fmt.Sprintf("%s/matters/%d/votes", c.BaseURL, matterID)
@@ -273,35 +324,118 @@ func (c *Client) GetExportPersons(filter string, search string) ([]ExportPerson,
 	return persons, err
 }
 
-// doRequest performs the HTTP request and unmarshals the response
+// doRequest performs the HTTP request and unmarshals the response, waiting
+// for the Limiter before each attempt. Network errors, 429s and 5xx
+// responses are retried up to MaxRetries times with exponential backoff.
 func (c *Client) doRequest(endpoint string, result interface{}) error {
-	req, err := http.NewRequest("GET", endpoint, nil)
+	ctx := c.context()
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	req.Header.Set("Accept", "application/json")
+	if id := middleware.RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(middleware.RequestIDHeader, id)
+	}
+	label := c.endpointLabel(req.URL.Path)
 	
+	var body []byte
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			metrics.LegistarRetries.Inc(label)
+			select {
+			case <-ctx.Done():
+				metrics.LegistarErrors.Inc(label)
+				return fmt.Errorf("failed to execute request: %w", ctx.Err())
+			case <-time.After(c.RetryBackoff << (attempt - 1)):
+			}
+		}
+	
+		if err := c.Limiter.Wait(ctx); err != nil {
+			metrics.LegistarErrors.Inc(label)
+			return fmt.Errorf("failed to execute request: %w", err)
+		}
+		var status int
+		body, status, err = c.send(ctx, req, label, attempt)
+		retryable := err != nil || status == http.StatusTooManyRequests || status >= 500
+		if retryable && attempt < c.MaxRetries {
+			continue
+		}
+		if err != nil {
+			metrics.LegistarErrors.Inc(label)
+			return fmt.Errorf("failed to execute request: %w", err)
+		}
+		if status != http.StatusOK {
+			metrics.LegistarErrors.Inc(label)
+			return fmt.Errorf("API returned status %d: %s", status, string(body))
+		}
+		break
+	}
+	
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	
+	return nil
+}
+
+// send makes one attempt at req, in its own client span, and returns the
+// response body and status
+func (c *Client) send(ctx context.Context, req *http.Request, label string, attempt int) ([]byte, int, error) {
+	ctx, span := tracing.Start(ctx, "legistar GET "+label,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Host),
+			semconv.URLPath(req.URL.Path),
+			attribute.Int("legistar.attempt", attempt),
+		))
+	defer span.End()
+	req = req.Clone(ctx)
+	tracing.Inject(ctx, req.Header)
+
+	start := time.Now()
 	resp, err := c.HTTPClient.Do(req)
+	metrics.LegistarDuration.Observe(time.Since(start).Seconds(), label)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		metrics.LegistarRequests.Inc(label, "error")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.WarnContext(ctx, "legistar request failed", "path", req.URL.Path, "error", err)
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
-	
+	metrics.LegistarRequests.Inc(label, strconv.Itoa(resp.StatusCode))
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
 	}
+	slog.DebugContext(ctx, "legistar request", "path", req.URL.Path, "status", resp.StatusCode,
+		"duration_ms", float64(time.Since(start).Microseconds())/1000)
 	
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
 	}
-	
-	err = json.Unmarshal(body, result)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		slog.WarnContext(ctx, "legistar request failed", "path", req.URL.Path, "status", resp.StatusCode)
 	}
-	
-	return nil
+	return body, resp.StatusCode, nil
+}
+
+// endpointLabel turns a request path into a metrics label with numeric IDs
+// replaced, e.g. "matters/{id}/votes"
+func (c *Client) endpointLabel(path string) string {
+	if base, err := url.Parse(c.BaseURL); err == nil {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
 }
@@ -1,7 +1,14 @@
 package cityapi
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/metrics"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
 )
 
 func TestNewClient(t *testing.T) {
@@ -67,3 +74,113 @@ func TestGetMatters(t *testing.T) {
 		t.Logf("Successfully fetched matter: %s - %s", matters[0].MatterFile, matters[0].MatterTitle)
 	}
 }
+
+func TestRequestCarriesRequestID(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(middleware.RequestIDHeader)
+		w.Write([]byte(`[{"BodyId": 1, "BodyName": "City Council"}]`))
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.BaseURL = srv.URL
+	ctx := middleware.WithRequestID(context.Background(), "sync-run-1")
+
+	bodies, err := client.WithContext(ctx).GetBodies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 || bodies[0].BodyName != "City Council" {
+		t.Errorf("unexpected bodies: %+v", bodies)
+	}
+	if got != "sync-run-1" {
+		t.Errorf("expected request ID to be forwarded, got %q", got)
+	}
+	if client.ctx != nil {
+		t.Error("WithContext modified the original client")
+	}
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"VoteId": 7}]`))
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.BaseURL = srv.URL
+	client.RetryBackoff = time.Millisecond
+
+	retries := metrics.LegistarRetries.Value("matters/{id}/votes")
+	votes, err := client.GetVotes(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(votes) != 1 || calls != 3 {
+		t.Errorf("expected success on the third call, got %d calls and %+v", calls, votes)
+	}
+	if got := metrics.LegistarRetries.Value("matters/{id}/votes") - retries; got != 2 {
+		t.Errorf("expected 2 retries recorded, got %v", got)
+	}
+
+	// Client errors are not retried
+	calls = 0
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	if _, err := client.GetVotes(42); err == nil || calls != 1 {
+		t.Errorf("expected one failed call, got %d calls and err %v", calls, err)
+	}
+}
+
+func TestCopiesShareRateLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.BaseURL = srv.URL
+	client.Limiter = NewRateLimiter(50)
+
+	// Six requests from three workers take at least five intervals
+	start := time.Now()
+	done := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			worker := client.WithContext(context.Background())
+			for j := 0; j < 2; j++ {
+				if _, err := worker.GetVotes(42); err != nil {
+					done <- err
+					return
+				}
+			}
+			done <- nil
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected requests paced at 50 a second, took %s", elapsed)
+	}
+
+	// Waiting stops with the context
+	client.Limiter = NewRateLimiter(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.Limiter.Wait(ctx)
+	if _, err := client.WithContext(ctx).GetVotes(42); err == nil {
+		t.Error("expected a cancelled request to fail")
+	}
+}
@@ -0,0 +1,58 @@
+package cityapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRequestRate is how many requests a second NewClient allows
+const DefaultRequestRate = 10
+
+// RateLimiter spaces out requests so that no more than a fixed number start
+// each second, however many goroutines share it
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // when the next request may start
+}
+
+// NewRateLimiter returns a limiter allowing perSecond requests a second, or
+// nil, which never waits, if perSecond is not positive
+func NewRateLimiter(perSecond int) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait blocks until a request may start, or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	// Reserve the next free slot, then wait for it
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
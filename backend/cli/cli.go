@@ -0,0 +1,548 @@
+// Package cli implements the influencepower command, which serves the API and
+// runs the sync, maintenance and migration jobs with one shared configuration.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/app"
+	"github.com/Jsanchez767/InfluencePower/backend/config"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/jobs"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
+)
+
+// Exit codes
+const (
+	ExitOK      = 0 // everything succeeded
+	ExitFailure = 1 // a job, migration or the server failed
+	ExitUsage   = 2 // bad arguments or configuration
+	ExitPartial = 3 // jobs finished, but some records could not be written
+)
+
+const usage = `Usage: influencepower <command> [flags]
+
+Commands:
+  serve                       run the API server (and scheduled jobs when scheduler.enabled)
+  schedule                    run scheduled jobs without the API server
+  sync [entity...]            sync from Legistar: bodies, people, terms, matters, votes, events
+                              (all of them, in that order, when none are given)
+  metrics compute             compute person_metrics from synced matters and votes
+  headshots refresh           point officials' image_url at their Legistar profiles
+  deleted purge               remove people, terms and votes deleted longer than
+                              sync.purge_retention ago
+  people duplicates           list stored people who are likely the same person
+  people merge --into id id...
+                              merge duplicate people into person id, recording the merge
+  people unmerge merge-id     undo a merge recorded by people merge
+  db migrate                  apply pending schema migrations (needs DATABASE_URL)
+
+Flags shared by every command:
+  --config path               YAML config file (default $CONFIG_FILE)
+  --jurisdiction name         jurisdiction to sync (default sync.jurisdiction, Chicago)
+  --dry-run                   read and report, but write nothing; jobs print their plan
+
+Flags of sync, metrics compute, headshots refresh, deleted purge, people merge and
+people unmerge:
+  --plan path                 also write the plan as JSON to path
+  --format text|json          how a dry run prints the plan, or people duplicates
+                              the clusters (default text)
+  --apply path                execute a plan written with --plan instead of planning
+
+Run "influencepower <command> -h" for the flags of a command.
+
+Exit codes: 0 ok, 1 failure, 2 usage or configuration error, 3 some records failed.
+`
+
+// commands maps command names to their implementations
+var commands = map[string]func(ctx context.Context, cfg config.Config, opts *options) int{
+	"serve":             serve,
+	"schedule":          schedule,
+	"sync":              sync,
+	"metrics compute":   computeMetrics,
+	"headshots refresh": refreshHeadshots,
+	"deleted purge":     purgeDeleted,
+	"people duplicates": listDuplicates,
+	"people merge":      mergePeople,
+	"people unmerge":    unmergePeople,
+	"db migrate":        migrate,
+}
+
+// isHelp reports whether arg asks for usage
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// errUsage marks errors caused by bad arguments
+var errUsage = errors.New("usage error")
+
+// command is a parsed invocation
+type command struct {
+	name string
+	run  func(ctx context.Context, cfg config.Config, opts *options) int
+}
+
+// options are the flags shared by every command
+type options struct {
+	configPath   string
+	jurisdiction string
+	dryRun       bool
+
+	// jobs only
+	planPath  string
+	format    string
+	applyPath string
+
+	// sync only
+	source   string
+	limit    int
+	workers  int
+	entities []string
+
+	// people merge and unmerge only
+	into int   // survivor of a merge
+	ids  []int // people merged, or the merge undone
+
+	stdout io.Writer // where dry runs print the plan
+}
+
+// jobCommands are the commands that plan and apply jobs
+var jobCommands = map[string]bool{"sync": true, "metrics compute": true, "headshots refresh": true, "deleted purge": true, "people merge": true, "people unmerge": true}
+
+// Plan output formats
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// Main runs the command in args (without the program name) and returns the
+// process exit code. Dry runs of jobs print their plan to stdout.
+func Main(args []string, stdout, stderr io.Writer) int {
+	cmd, opts, err := parse(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n\n%s", err, usage)
+		return ExitUsage
+	}
+
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	if opts.jurisdiction == "" {
+		opts.jurisdiction = cfg.Sync.Jurisdiction
+	}
+	opts.stdout = stdout
+
+	// Log levels and format were checked by config validation
+	logging.Setup(logging.Config{Level: cfg.Log.Level, Format: cfg.Log.Format})
+
+	// Stop on SIGTERM (container platforms) or Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Tracing; spans are dropped unless an exporter is configured
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingConfig())
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		return ExitUsage
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Warn("failed to flush traces", "error", err)
+		}
+	}()
+
+	return cmd.run(ctx, cfg, opts)
+}
+
+// parse parses the command and its flags
+func parse(args []string, stderr io.Writer) (command, *options, error) {
+	if len(args) == 0 {
+		return command{}, nil, fmt.Errorf("%w: no command given", errUsage)
+	}
+
+	if isHelp(args[0]) {
+		fmt.Fprint(stderr, usage)
+		return command{}, nil, flag.ErrHelp
+	}
+
+	// Commands of two words, such as "db migrate"
+	name, rest := args[0], args[1:]
+	if _, ok := commands[name]; !ok && len(rest) > 0 {
+		name, rest = name+" "+rest[0], rest[1:]
+	}
+	run, ok := commands[name]
+	if !ok {
+		return command{}, nil, fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+	cmd := command{name: name, run: run}
+	opts := &options{}
+
+	fs := flag.NewFlagSet("influencepower "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.configPath, "config", os.Getenv(config.FileEnv), "YAML config file")
+	fs.StringVar(&opts.jurisdiction, "jurisdiction", "", "jurisdiction to sync (default sync.jurisdiction)")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "read and report, but write nothing")
+	if jobCommands[name] {
+		fs.StringVar(&opts.planPath, "plan", "", "also write the plan as JSON to `path`")
+		fs.StringVar(&opts.format, "format", formatText, "how a dry run prints the plan: text or json")
+		fs.StringVar(&opts.applyPath, "apply", "", "execute the plan in `path` instead of planning")
+	}
+	if name == "sync" {
+		fs.StringVar(&opts.source, "source", jobs.SourceLegistar, "source of people and terms: legistar or elms")
+		fs.IntVar(&opts.limit, "limit", 0, "most recent matters and events to sync (default 100 matters, 50 events)")
+		fs.IntVar(&opts.workers, "workers", 0, "votes and agenda items fetched at once (default sync.workers)")
+	}
+	if name == "people duplicates" {
+		fs.StringVar(&opts.format, "format", formatText, "how to print the clusters: text or json")
+	}
+	if name == "people merge" {
+		fs.IntVar(&opts.into, "into", 0, "`id` of the person to keep")
+	}
+	if err := fs.Parse(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return command{}, nil, err
+		}
+		return command{}, nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	if jobCommands[name] || name == "people duplicates" {
+		if opts.format != formatText && opts.format != formatJSON {
+			return command{}, nil, fmt.Errorf("%w: --format must be %s or %s", errUsage, formatText, formatJSON)
+		}
+		if opts.applyPath != "" && (opts.dryRun || opts.planPath != "" || fs.NArg() > 0) {
+			return command{}, nil, fmt.Errorf("%w: --apply executes a saved plan and takes no entities, --dry-run or --plan", errUsage)
+		}
+	}
+
+	if name == "schedule" && opts.dryRun {
+		return command{}, nil, fmt.Errorf("%w: schedule writes as it runs and takes no --dry-run", errUsage)
+	}
+
+	if name == "sync" {
+		opts.entities = fs.Args()
+		for _, entity := range opts.entities {
+			if _, ok := jobs.SyncJob(entity); !ok {
+				return command{}, nil, fmt.Errorf("%w: unknown entity %q", errUsage, entity)
+			}
+		}
+		if opts.source != jobs.SourceLegistar && opts.source != jobs.SourceELMS {
+			return command{}, nil, fmt.Errorf("%w: --source must be %s or %s", errUsage, jobs.SourceLegistar, jobs.SourceELMS)
+		}
+		if opts.limit < 0 {
+			return command{}, nil, fmt.Errorf("%w: --limit must not be negative", errUsage)
+		}
+		if opts.workers < 0 {
+			return command{}, nil, fmt.Errorf("%w: --workers must not be negative", errUsage)
+		}
+	} else if (name == "people merge" || name == "people unmerge") && opts.applyPath == "" {
+		if err := parseMerge(name, fs.Args(), opts); err != nil {
+			return command{}, nil, err
+		}
+	} else if fs.NArg() > 0 {
+		return command{}, nil, fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+	return cmd, opts, nil
+}
+
+// parseMerge parses the person ids of people merge, or the merge id of
+// people unmerge
+func parseMerge(name string, args []string, opts *options) error {
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id < 1 {
+			return fmt.Errorf("%w: %q is not an id", errUsage, arg)
+		}
+		opts.ids = append(opts.ids, id)
+	}
+	if name == "people unmerge" {
+		if len(opts.ids) != 1 {
+			return fmt.Errorf("%w: people unmerge takes one merge id", errUsage)
+		}
+		return nil
+	}
+	if opts.into < 1 {
+		return fmt.Errorf("%w: people merge needs --into, the id of the person to keep", errUsage)
+	}
+	if len(opts.ids) == 0 {
+		return fmt.Errorf("%w: people merge needs the ids of the people to merge", errUsage)
+	}
+	seen := map[int]bool{opts.into: true}
+	for _, id := range opts.ids {
+		if seen[id] {
+			return fmt.Errorf("%w: person %d is given twice", errUsage, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// serve runs the API server
+func serve(ctx context.Context, cfg config.Config, opts *options) int {
+	db.InitSupabase(cfg.Supabase.URL, cfg.Supabase.ServiceRoleKey)
+	if err := app.Serve(ctx, cfg); err != nil {
+		slog.Error("server failed", "error", err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// schedule runs jobs on their schedules until stopped, for deployments
+// that keep the scheduler out of the API servers
+func schedule(ctx context.Context, cfg config.Config, opts *options) int {
+	db.InitSupabase(cfg.Supabase.URL, cfg.Supabase.ServiceRoleKey)
+	app.NewScheduler(cfg, true, app.NewQueue(cfg.Queue)).Start(ctx)
+	return ExitOK
+}
+
+// sync runs the requested sync jobs, or all of them
+func sync(ctx context.Context, cfg config.Config, opts *options) int {
+	var selected []jobs.Job
+	if len(opts.entities) == 0 {
+		selected = jobs.SyncJobs()
+	}
+	for _, entity := range opts.entities {
+		job, _ := jobs.SyncJob(entity)
+		selected = append(selected, job)
+	}
+	return runJobs(ctx, cfg, opts, "sync", selected)
+}
+
+// computeMetrics runs the metrics job
+func computeMetrics(ctx context.Context, cfg config.Config, opts *options) int {
+	return runJobs(ctx, cfg, opts, "metrics", []jobs.Job{jobs.ComputeMetrics})
+}
+
+// refreshHeadshots runs the headshots job
+func refreshHeadshots(ctx context.Context, cfg config.Config, opts *options) int {
+	return runJobs(ctx, cfg, opts, "headshots", []jobs.Job{jobs.RefreshHeadshots})
+}
+
+// purgeDeleted runs the purge job
+func purgeDeleted(ctx context.Context, cfg config.Config, opts *options) int {
+	return runJobs(ctx, cfg, opts, "purge", []jobs.Job{jobs.PurgeDeleted})
+}
+
+// listDuplicates prints the clusters of people who are likely one person
+func listDuplicates(ctx context.Context, cfg config.Config, opts *options) int {
+	db.InitSupabase(cfg.Supabase.URL, cfg.Supabase.ServiceRoleKey)
+	env, err := jobs.NewEnv(ctx, opts.jurisdiction, app.JobOptions(cfg, queue.NewMemoryStore()))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load jurisdiction", "error", err)
+		return ExitUsage
+	}
+	clusters, err := jobs.FindDuplicates(ctx, env)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find duplicates", "error", err)
+		return ExitFailure
+	}
+
+	if opts.format == formatJSON {
+		enc := json.NewEncoder(opts.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(clusters); err != nil {
+			return ExitFailure
+		}
+		return ExitOK
+	}
+	fmt.Fprintf(opts.stdout, "%d clusters of likely duplicates\n", len(clusters))
+	for _, c := range clusters {
+		fmt.Fprintln(opts.stdout)
+		var others []string
+		for _, p := range c.People {
+			fmt.Fprintf(opts.stdout, "  %d %s", p.PersonID, p.Name)
+			if len(p.ExternalIDs) > 0 {
+				fmt.Fprintf(opts.stdout, " %v", p.ExternalIDs)
+			}
+			fmt.Fprintf(opts.stdout, ", %d terms\n", len(p.Terms))
+			if p.PersonID != c.Survivor {
+				others = append(others, strconv.Itoa(p.PersonID))
+			}
+		}
+		for _, p := range c.Pairs {
+			fmt.Fprintf(opts.stdout, "    %d ~ %d: %.2f (%s)\n", p.A, p.B, p.Score, strings.Join(p.Reasons, ", "))
+		}
+		fmt.Fprintf(opts.stdout, "    influencepower people merge --into %d %s\n", c.Survivor, strings.Join(others, " "))
+	}
+	return ExitOK
+}
+
+// mergePeople plans and applies the merge of people into --into
+func mergePeople(ctx context.Context, cfg config.Config, opts *options) int {
+	return runJobs(ctx, cfg, opts, "merge", []jobs.Job{jobs.MergePeople(opts.into, opts.ids, actor())})
+}
+
+// unmergePeople plans and applies the undoing of a merge
+func unmergePeople(ctx context.Context, cfg config.Config, opts *options) int {
+	var id int
+	if len(opts.ids) > 0 {
+		id = opts.ids[0]
+	}
+	return runJobs(ctx, cfg, opts, "unmerge", []jobs.Job{jobs.UndoMerge(id, actor())})
+}
+
+// actor names who runs a command in the records it writes, e.g. cli:jane
+func actor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "cli:" + user
+	}
+	return "cli"
+}
+
+// runJobs plans jobs in order under one request ID and trace, carrying on
+// after a job fails so independent entities still sync. The plan is printed
+// on a dry run and applied otherwise.
+func runJobs(ctx context.Context, cfg config.Config, opts *options, name string, selected []jobs.Job) int {
+	// Every log line, PostgREST query and Legistar request of this run
+	// carries the same request ID
+	ctx = middleware.WithRequestID(ctx, middleware.NewRequestID())
+	ctx, span := tracing.StartJob(ctx, name)
+	defer span.End()
+
+	db.InitSupabase(cfg.Supabase.URL, cfg.Supabase.ServiceRoleKey)
+
+	var saved *jobs.Plan
+	if opts.applyPath != "" {
+		var err error
+		if saved, err = jobs.LoadPlan(opts.applyPath); err != nil {
+			slog.ErrorContext(ctx, "failed to load plan", "error", err)
+			return ExitUsage
+		}
+		opts.jurisdiction = saved.Jurisdiction.Name
+	}
+
+	// Dry runs queue their units in memory so that they write nothing
+	units := app.NewQueue(cfg.Queue)
+	if opts.dryRun {
+		units = queue.NewMemoryStore()
+	}
+	jobOpts := app.JobOptions(cfg, units)
+	jobOpts.Limit = opts.limit
+	jobOpts.Source = opts.source
+	jobOpts.Actor = actor()
+	if opts.workers > 0 {
+		jobOpts.QueueConfig.Workers = opts.workers
+	}
+	env, err := jobs.NewEnv(ctx, opts.jurisdiction, jobOpts)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load jurisdiction", "error", err)
+		return ExitUsage
+	}
+	if saved != nil && saved.Jurisdiction.ID != env.Jurisdiction.ID {
+		slog.ErrorContext(ctx, "plan is for another jurisdiction", "plan_jurisdiction_id", saved.Jurisdiction.ID, "jurisdiction_id", env.Jurisdiction.ID)
+		return ExitUsage
+	}
+	ctx = logging.With(ctx, "jurisdiction_id", env.Jurisdiction.ID)
+
+	code := ExitOK
+	plan := saved
+	if plan == nil {
+		plan = jobs.NewPlan(env.Jurisdiction, opts.source)
+		for _, job := range selected {
+			if ctx.Err() != nil {
+				return ExitFailure
+			}
+			if err := jobs.Run(ctx, env, job, plan); err != nil {
+				code = ExitFailure
+			}
+		}
+		if err := writePlan(opts, plan); err != nil {
+			slog.ErrorContext(ctx, "failed to write plan", "error", err)
+			return ExitFailure
+		}
+	}
+	if ctx.Err() != nil {
+		return ExitFailure
+	}
+	if opts.dryRun {
+		return code
+	}
+
+	counts, err := jobs.Apply(ctx, env, plan)
+	switch {
+	case err != nil:
+		code = ExitFailure
+	case counts.Failed() > 0 && code == ExitOK:
+		code = ExitPartial
+	}
+	return code
+}
+
+// writePlan saves the plan to --plan and, on a dry run, prints it
+func writePlan(opts *options, plan *jobs.Plan) error {
+	if opts.planPath != "" {
+		f, err := os.Create(opts.planPath)
+		if err != nil {
+			return err
+		}
+		if err := plan.WriteJSON(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	if !opts.dryRun {
+		return nil
+	}
+	if opts.format == formatJSON {
+		return plan.WriteJSON(opts.stdout)
+	}
+	return plan.WriteText(opts.stdout)
+}
+
+// migrate applies pending schema migrations, or lists them on a dry run
+func migrate(ctx context.Context, cfg config.Config, opts *options) int {
+	if cfg.Database.URL == "" {
+		slog.Error("database.url (DATABASE_URL) is required for db migrate")
+		return ExitUsage
+	}
+
+	conn, err := db.OpenPostgres(ctx, cfg.Database.URL)
+	if err != nil {
+		slog.Error("failed to open database", "error", err)
+		return ExitFailure
+	}
+	defer conn.Close()
+
+	pending, err := db.PendingMigrations(ctx, conn)
+	if err != nil {
+		slog.Error("failed to list migrations", "error", err)
+		return ExitFailure
+	}
+	for _, m := range pending {
+		slog.Info("pending migration", "migration", m.Name)
+	}
+	if opts.dryRun || len(pending) == 0 {
+		slog.Info("migrations checked", "pending", len(pending), "dry_run", opts.dryRun)
+		return ExitOK
+	}
+
+	if err := db.ApplyMigrations(ctx, conn, pending); err != nil {
+		slog.Error("migration failed", "error", err)
+		return ExitFailure
+	}
+	return ExitOK
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"deploy"},
+		{"db"},
+		{"db", "rollback"},
+		{"sync", "officials"},
+		{"sync", "--source", "csv"},
+		{"sync", "--limit", "-1"},
+		{"sync", "--workers", "-2"},
+		{"serve", "--port", "80"},
+		{"metrics", "compute", "extra"},
+		{"sync", "--format", "yaml"},
+		{"sync", "--apply", "plan.json", "--dry-run"},
+		{"sync", "--apply", "plan.json", "votes"},
+		{"db", "migrate", "--apply", "plan.json"},
+		{"schedule", "--dry-run"},
+		{"schedule", "votes"},
+		{"people", "merge", "6"},
+		{"people", "merge", "--into", "5"},
+		{"people", "merge", "--into", "5", "5"},
+		{"people", "merge", "--into", "5", "six"},
+		{"people", "unmerge"},
+		{"people", "unmerge", "1", "2"},
+		{"people", "duplicates", "--format", "csv"},
+	} {
+		var stderr strings.Builder
+		if code := Main(args, io.Discard, &stderr); code != ExitUsage {
+			t.Errorf("%q: expected exit %d, got %d", args, ExitUsage, code)
+		}
+		if !strings.Contains(stderr.String(), "Usage: influencepower") {
+			t.Errorf("%q: expected usage, got %q", args, stderr.String())
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	var stderr strings.Builder
+	if code := Main([]string{"help"}, io.Discard, &stderr); code != ExitOK {
+		t.Errorf("expected exit 0, got %d", code)
+	}
+	if code := Main([]string{"sync", "-h"}, io.Discard, &stderr); code != ExitOK {
+		t.Errorf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "-dry-run") {
+		t.Errorf("expected sync flags in help, got %q", stderr.String())
+	}
+}
+
+func TestParseSync(t *testing.T) {
+	cmd, opts, err := parse([]string{"sync", "--dry-run", "--jurisdiction", "Evanston", "--limit", "10", "--workers", "8", "matters", "votes"}, &strings.Builder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.name != "sync" || !opts.dryRun || opts.jurisdiction != "Evanston" || opts.limit != 10 || opts.workers != 8 || opts.source != "legistar" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if len(opts.entities) != 2 || opts.entities[1] != "votes" {
+		t.Errorf("unexpected entities %v", opts.entities)
+	}
+
+	_, opts, err = parse([]string{"headshots", "refresh", "--dry-run", "--plan", "plan.json", "--format", "json"}, &strings.Builder{})
+	if err != nil || opts.planPath != "plan.json" || opts.format != "json" {
+		t.Errorf("unexpected options %+v, %v", opts, err)
+	}
+
+	cmd, opts, err = parse([]string{"people", "merge", "--dry-run", "--into", "5", "6", "7"}, &strings.Builder{})
+	if err != nil || cmd.name != "people merge" || opts.into != 5 || len(opts.ids) != 2 || opts.ids[1] != 7 {
+		t.Errorf("unexpected options %+v, %v", opts, err)
+	}
+
+	if cmd, _, err := parse([]string{"db", "migrate", "--dry-run"}, &strings.Builder{}); err != nil || cmd.name != "db migrate" {
+		t.Errorf("expected db migrate, got %q, %v", cmd.name, err)
+	}
+}
+
+func TestConfigErrorIsUsage(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "")
+	t.Setenv("SUPABASE_SERVICE_ROLE_KEY", "")
+	t.Setenv("CONFIG_FILE", "")
+
+	var stderr strings.Builder
+	if code := Main([]string{"sync", "--dry-run"}, io.Discard, &stderr); code != ExitUsage {
+		t.Errorf("expected exit %d, got %d", ExitUsage, code)
+	}
+	if !strings.Contains(stderr.String(), "SUPABASE_URL") {
+		t.Errorf("expected config error, got %q", stderr.String())
+	}
+}
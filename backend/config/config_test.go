@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setRequired(t *testing.T) {
+	t.Setenv("SUPABASE_URL", "https://example.supabase.co")
+	t.Setenv("SUPABASE_SERVICE_ROLE_KEY", "service-key")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setRequired(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || cfg.Server.WriteTimeout != 30*time.Second || !cfg.Auth.DBKeys || cfg.CORS.AllowedOrigins[0] != "*" {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadFileThenEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+port: 9000
+supabase:
+  url: https://file.supabase.co
+  service_role_key: file-key
+server:
+  write_timeout: 45s
+  shutdown_delay: 0s
+cors:
+  allowed_origins: [https://influencepower.org]
+cache:
+  max_entries: 50
+`), 0o600)
+
+	t.Setenv("PORT", "9100")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("AUTH_DB_KEYS", "false")
+	t.Setenv("CACHE_SYNC_POLL_INTERVAL", "1m")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9100 {
+		t.Errorf("env should override file: port %d", cfg.Port)
+	}
+	if cfg.Supabase.URL != "https://file.supabase.co" || cfg.Server.WriteTimeout != 45*time.Second || cfg.Cache.MaxEntries != 50 {
+		t.Errorf("file values not applied: %+v", cfg)
+	}
+	if cfg.Server.ShutdownDelay != 0 || cfg.Server.ReadTimeout != 15*time.Second {
+		t.Errorf("unexpected server config: %+v", cfg.Server)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 2 || cfg.CORS.AllowedOrigins[1] != "https://b.example" {
+		t.Errorf("unexpected origins: %q", cfg.CORS.AllowedOrigins)
+	}
+	if cfg.Auth.DBKeys || cfg.Cache.SyncPollInterval != time.Minute {
+		t.Errorf("env values not applied: %+v", cfg)
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	setRequired(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("server:\n  write_timout: 10s\n"), 0o600)
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "write_timout") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestLoadReportsBadEnv(t *testing.T) {
+	setRequired(t)
+	t.Setenv("SERVER_WRITE_TIMEOUT", "30")
+
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "SERVER_WRITE_TIMEOUT") {
+		t.Errorf("expected SERVER_WRITE_TIMEOUT error, got %v", err)
+	}
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Port = 0
+	cfg.Server.IdleTimeout = 0
+	cfg.CORS.AllowedOrigins = []string{"*", "https://a.example"}
+	cfg.RateLimit.Tiers = "reader=lots"
+	cfg.Search.Backend = "elastic"
+	cfg.Health.Freshness = "votes=2d"
+	cfg.Scheduler.Schedules = "officials=0 5 * * *"
+	cfg.Scheduler.Timezone = "Mars/Olympus_Mons"
+	cfg.Queue.Backend = "sqlite"
+	cfg.Sync.Workers = 0
+
+	err := cfg.Validate()
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	for _, want := range []string{"PORT", "SUPABASE_URL", "SUPABASE_SERVICE_ROLE_KEY", "SERVER_IDLE_TIMEOUT", "CORS_ALLOWED_ORIGINS", "RATE_LIMITS", "SEARCH_BACKEND", "HEALTH_FRESHNESS", "SCHEDULER_SCHEDULES", "SCHEDULER_TIMEZONE", "QUEUE_BACKEND", "SYNC_WORKERS"} {
+		if !strings.Contains(errs.Error(), want) {
+			t.Errorf("missing %s in:\n%v", want, errs)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log/slog"
+
+	_ "github.com/lib/pq" // Postgres driver for migrations
+)
+
+// migrationFiles are the schema files applied by "influencepower db migrate".
+// The base schema (migration_v2_multi_jurisdiction.sql, then
+// schema_city_api.sql) drops and recreates tables, so it is applied once by
+// hand when a database is created and is not listed here.
+//
+//go:embed schema_auth.sql schema_sync_state.sql schema_search.sql schema_scheduler.sql schema_queue.sql schema_review.sql schema_merges.sql schema_overrides.sql schema_provenance.sql schema_audit.sql schema_soft_delete.sql schema_positions.sql schema_officials.sql schema_cache_writes.sql schema_term_seats.sql
+var migrationFiles embed.FS
+
+// migrations lists migrationFiles in the order they are applied. New schema
+// files are appended; applied ones must not be edited.
+var migrations = []string{
+	"schema_auth.sql",
+	"schema_sync_state.sql",
+	"schema_search.sql",
+	"schema_scheduler.sql",
+	"schema_queue.sql",
+	"schema_review.sql",
+	"schema_merges.sql",
+	"schema_overrides.sql",
+	"schema_provenance.sql",
+	"schema_audit.sql",
+	"schema_soft_delete.sql",
+	"schema_positions.sql",
+	"schema_officials.sql",
+	"schema_cache_writes.sql",
+	"schema_term_seats.sql",
+}
+
+// Migration is a schema file that has not been applied yet
+type Migration struct {
+	Name string
+	SQL  string
+}
+
+// OpenPostgres connects to Postgres directly, for the DDL PostgREST cannot run
+func OpenPostgres(ctx context.Context, databaseURL string) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return conn, nil
+}
+
+// PendingMigrations returns the migrations not recorded in schema_migrations,
+// creating that table if needed
+func PendingMigrations(ctx context.Context, conn *sql.DB) ([]Migration, error) {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  name TEXT PRIMARY KEY,
+  applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, name := range migrations {
+		if applied[name] {
+			continue
+		}
+		data, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, Migration{Name: name, SQL: string(data)})
+	}
+	return pending, nil
+}
+
+// ApplyMigrations applies each migration in its own transaction and records
+// it in schema_migrations, stopping at the first failure
+func ApplyMigrations(ctx context.Context, conn *sql.DB, pending []Migration) error {
+	for _, m := range pending {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s failed: %w", m.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (name) VALUES ($1)", m.Name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.Name, err)
+		}
+		slog.InfoContext(ctx, "migration applied", "migration", m.Name)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+-- =====================================================
+-- AUDIT LOG
+-- =====================================================
+-- Every write to the data, through the API or by a sync job:
+-- who made it, the action, the row, the row or the fields
+-- changed before and after, and the request ID of the call
+-- or run. The log is append-only: updates and deletes are
+-- rejected, for the service role too.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor TEXT NOT NULL,                 -- e.g. 'key:admin', 'cli:jane', 'scheduler'
+  action TEXT NOT NULL,                -- 'create', 'update', 'delete', 'revoke', 'retry' or 'trigger'
+  entity TEXT NOT NULL,                -- the table written, e.g. 'people'
+  entity_id TEXT,                      -- the row's key
+  before JSONB,
+  after JSONB,
+  request_id TEXT,                     -- as in job_runs.request_id and X-Request-ID
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id DESC);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+  BEFORE UPDATE OR DELETE ON audit_log
+  FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- Only the service role may read or write the log
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,22 @@
+-- =====================================================
+-- API KEYS
+-- =====================================================
+-- Keys used to authenticate against the API. Only the SHA-256
+-- hash of each key is stored; the plaintext is shown once when
+-- the key is created via POST /api/v1/admin/api-keys.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name TEXT NOT NULL,
+  role TEXT NOT NULL CHECK (role IN ('reader', 'editor', 'admin')),
+  key_prefix TEXT NOT NULL,
+  key_hash TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_active ON api_keys(key_hash) WHERE revoked_at IS NULL;
+
+-- Only the service role may read or write keys
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
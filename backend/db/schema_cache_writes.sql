@@ -0,0 +1,12 @@
+-- =====================================================
+-- CACHE WRITES
+-- =====================================================
+-- API writes stamp written_at on the sync_state row of each
+-- entity they change, so every API instance, not only the one
+-- that took the write, drops its cached responses on its next
+-- poll. A row a write creates has not been synced, so
+-- last_synced_at is left null rather than defaulting to now.
+
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS written_at TIMESTAMPTZ;
+ALTER TABLE sync_state ALTER COLUMN last_synced_at DROP NOT NULL;
+ALTER TABLE sync_state ALTER COLUMN last_synced_at DROP DEFAULT;
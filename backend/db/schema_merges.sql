@@ -0,0 +1,28 @@
+-- =====================================================
+-- PERSON MERGES
+-- =====================================================
+-- One row per duplicate person merged into another by
+-- "influencepower people merge". The row keeps what undoing the
+-- merge needs: the merged person as it was, the rows moved to
+-- the survivor, the rows deleted because the survivor already
+-- had them, and the external IDs the survivor gained.
+
+CREATE TABLE IF NOT EXISTS person_merges (
+  id BIGSERIAL PRIMARY KEY,
+  survivor_id INTEGER NOT NULL,
+  merged_id INTEGER NOT NULL,                      -- no longer in people until the merge is undone
+  merged_person JSONB NOT NULL,                    -- the merged people row
+  linked_external_ids JSONB NOT NULL DEFAULT '{}', -- {"legistar_id": 162}: added to the survivor
+  repointed JSONB NOT NULL DEFAULT '{}',           -- {"terms": [12], "person_metrics": [5]}: keys of rows moved to the survivor
+  deleted JSONB NOT NULL DEFAULT '{}',             -- {"terms": [{...}]}: rows the survivor already had
+  merged_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  undone_by TEXT,
+  undone_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_merges_survivor ON person_merges(survivor_id);
+CREATE INDEX IF NOT EXISTS idx_person_merges_merged ON person_merges(merged_id);
+
+-- Only the service role may read or write merges
+ALTER TABLE person_merges ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,47 @@
+-- =====================================================
+-- OFFICIALS
+-- =====================================================
+-- The officials endpoints serve when each person was created
+-- and last changed, so current_officials carries the person's
+-- created_at and updated_at after its other columns. It is
+-- otherwise the view of schema_soft_delete.sql.
+
+CREATE OR REPLACE VIEW current_officials AS
+SELECT 
+  j.id as jurisdiction_id,
+  j.name as jurisdiction_name,
+  j.jurisdiction_type,
+  pos.id as position_id,
+  pos.position_type,
+  pos.district_number,
+  pos.district_name,
+  pos.title,
+  p.id as person_id,
+  p.full_name,
+  p.first_name,
+  p.last_name,
+  p.party_affiliation,
+  p.email,
+  p.phone,
+  p.website,
+  p.image_url,
+  t.start_date as term_start,
+  t.end_date as term_end,
+  t.term_number,
+  m.overall_score,
+  m.legislative_impact_score,
+  m.constituent_engagement_score,
+  m.transparency_score,
+  m.attendance_rate,
+  p.created_at,
+  p.updated_at
+FROM jurisdictions j
+JOIN positions pos ON pos.jurisdiction_id = j.id
+JOIN terms t ON t.position_id = pos.id
+JOIN people p ON p.id = t.person_id
+LEFT JOIN person_metrics m ON m.person_id = p.id
+WHERE (t.end_date IS NULL OR t.end_date > CURRENT_DATE)
+  AND j.is_active = true
+  AND p.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+ORDER BY j.name, pos.district_number NULLS FIRST, pos.position_type;
@@ -0,0 +1,23 @@
+-- =====================================================
+-- FIELD OVERRIDES
+-- =====================================================
+-- Field values of people, terms and matters pinned by editors
+-- because upstream data gets them wrong. Sync jobs write the
+-- pinned value instead of the upstream one, so a fix is not
+-- overwritten by the next sync. One override per field of a row.
+
+CREATE TABLE IF NOT EXISTS field_overrides (
+  id BIGSERIAL PRIMARY KEY,
+  entity TEXT NOT NULL CHECK (entity IN ('person', 'term', 'matter')),
+  entity_id TEXT NOT NULL,             -- people.id, terms.id or matters.matter_id
+  field TEXT NOT NULL,                 -- e.g. 'email'
+  value JSONB,                         -- the value the row keeps; null pins the field empty
+  reason TEXT NOT NULL,
+  created_by TEXT NOT NULL,            -- who last set the override
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (entity, entity_id, field)
+);
+
+-- Only the service role may read or write overrides
+ALTER TABLE field_overrides ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,14 @@
+-- =====================================================
+-- POSITION SEATS
+-- =====================================================
+-- How many people hold a position at once. Terms created or
+-- changed through the API may not overlap more terms of a
+-- position than it has seats, so a ward has one alderperson
+-- at a time. Syncs are not checked; Legistar is the record.
+
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS seats INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE positions DROP CONSTRAINT IF EXISTS positions_seats_check;
+ALTER TABLE positions ADD CONSTRAINT positions_seats_check CHECK (seats > 0);
+
+-- The overlap check reads a position's live terms
+CREATE INDEX IF NOT EXISTS idx_terms_position_dates ON terms(position_id, start_date, end_date) WHERE deleted_at IS NULL;
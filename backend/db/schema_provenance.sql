@@ -0,0 +1,24 @@
+-- =====================================================
+-- FIELD PROVENANCE
+-- =====================================================
+-- Where each field of people, terms and matters last came
+-- from: the source system and record, when it was fetched,
+-- and the sync run or API request that wrote it. Sync jobs
+-- record the fields they write when a plan is applied, and
+-- setting an override records the pinned field. One row per
+-- field of a row, replaced on each write.
+
+CREATE TABLE IF NOT EXISTS field_provenance (
+  entity TEXT NOT NULL CHECK (entity IN ('person', 'term', 'matter')),
+  entity_id TEXT NOT NULL,             -- people.id, terms.id or matters.matter_id
+  field TEXT NOT NULL,                 -- e.g. 'email'
+  system TEXT NOT NULL CHECK (system IN ('legistar', 'elms', 'override', 'manual')),
+  record_id TEXT,                      -- e.g. '/officerecords/300', 'ward=1', 'field_overrides/2'
+  fetched_at TIMESTAMPTZ NOT NULL,     -- when the value was read from the system, or entered
+  run_id TEXT,                         -- job_runs.request_id, or the request ID of an API write
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (entity, entity_id, field)
+);
+
+-- Only the service role may read or write provenance
+ALTER TABLE field_provenance ENABLE ROW LEVEL SECURITY;
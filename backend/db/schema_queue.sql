@@ -0,0 +1,33 @@
+-- =====================================================
+-- WORK QUEUE
+-- =====================================================
+-- Units of sync work, such as fetching one matter's votes. A
+-- job queues a batch of units, workers lease and run them with
+-- retries, and the job reads their results once the batch is
+-- drained. Units of a batch that was interrupted are resumed by
+-- the next run. Units that fail max_attempts times stay 'dead'
+-- until retried or discarded via /api/v1/admin/queue/tasks.
+
+CREATE TABLE IF NOT EXISTS queue_tasks (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,                    -- 'votes', 'event_items'
+  batch TEXT NOT NULL,                   -- '<jurisdiction id>/<start time>'
+  key TEXT NOT NULL UNIQUE,              -- idempotency key: kind, batch and subject
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'dead')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL,
+  run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_by TEXT,                        -- host:pid of the worker holding the lease
+  locked_until TIMESTAMPTZ,
+  last_error TEXT,
+  result JSONB,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_queue_tasks_batch ON queue_tasks(kind, batch, status, run_at);
+CREATE INDEX IF NOT EXISTS idx_queue_tasks_status ON queue_tasks(kind, status);
+
+-- Only the service role may read or write the queue
+ALTER TABLE queue_tasks ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,29 @@
+-- =====================================================
+-- REVIEW QUEUE
+-- =====================================================
+-- Sync decisions the jobs could not make on their own, such as
+-- an upstream person who may be one of several stored people.
+-- Items are keyed by their subject, so a sync that meets the
+-- same record again does not queue it twice, and a resolved or
+-- dismissed item is not reopened.
+
+CREATE TABLE IF NOT EXISTS review_items (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,                    -- 'person_match'
+  key TEXT NOT NULL UNIQUE,              -- kind, source and upstream record, e.g. 'person_match/legistar/legistar_id=162'
+  source TEXT NOT NULL,                  -- 'legistar', 'elms'
+  record JSONB NOT NULL,                 -- the upstream record as fetched
+  reason TEXT NOT NULL,
+  candidates JSONB NOT NULL DEFAULT '[]', -- [{"person_id": 1, "name": "...", "score": 0.6, "reasons": [...]}]
+  status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+  resolution JSONB,
+  resolved_by TEXT,
+  resolved_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_items_status ON review_items(status, kind, created_at);
+
+-- Only the service role may read or write the review queue
+ALTER TABLE review_items ENABLE ROW LEVEL SECURITY;
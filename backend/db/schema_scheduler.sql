@@ -0,0 +1,34 @@
+-- =====================================================
+-- SCHEDULED JOBS
+-- =====================================================
+-- Locks and run history of the built-in scheduler. An instance
+-- holds a job's lock while running it and renews it until the
+-- job finishes, so instances sharing a database run each job
+-- once; a lock past locked_until belongs to a dead instance and
+-- may be taken over.
+
+CREATE TABLE IF NOT EXISTS job_locks (
+  job TEXT PRIMARY KEY,
+  holder TEXT NOT NULL,                  -- host:pid of the instance running the job
+  locked_until TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+  id BIGSERIAL PRIMARY KEY,
+  job TEXT NOT NULL,
+  trigger TEXT NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+  instance TEXT NOT NULL,
+  status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'partial', 'failed')),
+  started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  finished_at TIMESTAMPTZ,
+  error TEXT,
+  request_id TEXT,
+  counts JSONB                           -- records fetched, upserted and failed per entity
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_job_runs_started ON job_runs(started_at DESC);
+
+-- Only the service role may read or write job state
+ALTER TABLE job_locks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE job_runs ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,48 @@
+-- =====================================================
+-- FULL-TEXT SEARCH
+-- =====================================================
+-- Adds generated tsvector columns and GIN indexes used by
+-- GET /api/v1/search. When these columns are missing the API
+-- falls back to its in-process index, so this migration is
+-- optional but recommended for large datasets.
+
+-- People: names use the 'simple' config so they are not stemmed
+ALTER TABLE people ADD COLUMN IF NOT EXISTS fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple',
+      coalesce(full_name, '') || ' ' ||
+      coalesce(first_name, '') || ' ' ||
+      coalesce(last_name, '') || ' ' ||
+      coalesce(email, ''))
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_people_fts ON people USING GIN(fts);
+
+-- Matters: titles weigh more than the full text
+ALTER TABLE matters ADD COLUMN IF NOT EXISTS fts tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(matter_file, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(matter_title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(matter_name, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(matter_type_name, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(matter_text, '')), 'D')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_matters_fts ON matters USING GIN(fts);
+CREATE INDEX IF NOT EXISTS idx_matters_file ON matters(matter_file);
+
+-- Bodies (committees)
+ALTER TABLE bodies ADD COLUMN IF NOT EXISTS fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple', coalesce(body_name, '') || ' ' || coalesce(body_type_name, ''))
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_bodies_fts ON bodies USING GIN(fts);
+
+-- Events (meetings)
+ALTER TABLE events ADD COLUMN IF NOT EXISTS fts tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('english', coalesce(event_body_name, '') || ' ' || coalesce(event_location, ''))
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_events_fts ON events USING GIN(fts);
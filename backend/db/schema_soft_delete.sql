@@ -0,0 +1,79 @@
+-- =====================================================
+-- SOFT DELETE
+-- =====================================================
+-- People, terms and votes deleted through the API are marked
+-- with when and by whom instead of being removed, so they can
+-- be restored. Deleting a person marks their terms and votes
+-- with the same deleted_at. Reads leave marked rows out; the
+-- purge job removes them once they are older than the
+-- retention period.
+
+ALTER TABLE people ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE people ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+ALTER TABLE terms ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE terms ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_people_deleted_at ON people(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_terms_deleted_at ON terms(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_votes_deleted_at ON votes(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- The views of migration_v2_multi_jurisdiction.sql, leaving out
+-- deleted people and terms
+CREATE OR REPLACE VIEW current_officials AS
+SELECT 
+  j.id as jurisdiction_id,
+  j.name as jurisdiction_name,
+  j.jurisdiction_type,
+  pos.id as position_id,
+  pos.position_type,
+  pos.district_number,
+  pos.district_name,
+  pos.title,
+  p.id as person_id,
+  p.full_name,
+  p.first_name,
+  p.last_name,
+  p.party_affiliation,
+  p.email,
+  p.phone,
+  p.website,
+  p.image_url,
+  t.start_date as term_start,
+  t.end_date as term_end,
+  t.term_number,
+  m.overall_score,
+  m.legislative_impact_score,
+  m.constituent_engagement_score,
+  m.transparency_score,
+  m.attendance_rate
+FROM jurisdictions j
+JOIN positions pos ON pos.jurisdiction_id = j.id
+JOIN terms t ON t.position_id = pos.id
+JOIN people p ON p.id = t.person_id
+LEFT JOIN person_metrics m ON m.person_id = p.id
+WHERE (t.end_date IS NULL OR t.end_date > CURRENT_DATE)
+  AND j.is_active = true
+  AND p.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+ORDER BY j.name, pos.district_number NULLS FIRST, pos.position_type;
+
+CREATE OR REPLACE VIEW term_history AS
+SELECT 
+  j.name as jurisdiction_name,
+  pos.district_number,
+  pos.title,
+  p.full_name,
+  p.party_affiliation,
+  t.start_date,
+  t.end_date,
+  EXTRACT(YEAR FROM AGE(COALESCE(t.end_date, CURRENT_DATE), t.start_date)) as years_served,
+  t.term_number
+FROM jurisdictions j
+JOIN positions pos ON pos.jurisdiction_id = j.id
+JOIN terms t ON t.position_id = pos.id
+JOIN people p ON p.id = t.person_id
+WHERE p.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+ORDER BY j.name, pos.district_number NULLS FIRST, t.start_date DESC;
@@ -0,0 +1,17 @@
+-- =====================================================
+-- SYNC STATE
+-- =====================================================
+-- One row per synced entity, updated by sync jobs when they
+-- finish writing. The API polls this table to invalidate its
+-- response cache after a sync, and exports the last run's
+-- record counts as Prometheus gauges.
+
+CREATE TABLE IF NOT EXISTS sync_state (
+  entity TEXT PRIMARY KEY,               -- 'people', 'terms', 'matters', 'votes', 'events', 'bodies', 'metrics'
+  last_synced_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Records handled by the last sync that reported counts
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS records_fetched INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS records_upserted INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS records_failed INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,58 @@
+-- =====================================================
+-- TERM SEATS
+-- =====================================================
+-- A term may not overlap more terms of a position than it has
+-- seats (schema_positions.sql), so a ward has one alderperson
+-- at a time. Terms from Legistar office records carry upstream
+-- IDs and are not checked; Legistar is the record for them.
+
+-- The API checks for a free seat before writing a term, but two
+-- writes can both pass that check. This trigger repeats it in the
+-- writing transaction, taking the position's row lock so that
+-- writes to one position's terms take turns. It checks a term
+-- without upstream IDs whenever one is added, restored, moved or
+-- made longer; shortening a term never takes a seat, and writes
+-- that leave its position, dates and deletion alone (a new term
+-- number, or a merge moving it to another person) are not
+-- checked. Violations raise exclusion_violation (23P01).
+CREATE OR REPLACE FUNCTION terms_check_seats() RETURNS trigger AS $$
+DECLARE
+  position_seats INTEGER;
+  held INTEGER;
+BEGIN
+  IF NEW.deleted_at IS NOT NULL OR NEW.external_id IS NOT NULL OR NEW.external_guid IS NOT NULL THEN
+    RETURN NEW;
+  END IF;
+  IF TG_OP = 'UPDATE'
+     AND NEW.position_id IS NOT DISTINCT FROM OLD.position_id
+     AND NEW.start_date IS NOT DISTINCT FROM OLD.start_date
+     AND NEW.end_date IS NOT DISTINCT FROM OLD.end_date
+     AND NEW.deleted_at IS NOT DISTINCT FROM OLD.deleted_at THEN
+    RETURN NEW;
+  END IF;
+  IF TG_OP = 'UPDATE' AND OLD.deleted_at IS NULL
+     AND NEW.position_id = OLD.position_id
+     AND NEW.start_date >= OLD.start_date
+     AND NEW.end_date IS NOT NULL AND (OLD.end_date IS NULL OR NEW.end_date <= OLD.end_date) THEN
+    RETURN NEW;
+  END IF;
+
+  SELECT seats INTO position_seats FROM positions WHERE id = NEW.position_id FOR UPDATE;
+  SELECT count(*) INTO held FROM terms
+  WHERE position_id = NEW.position_id
+    AND id <> NEW.id
+    AND deleted_at IS NULL
+    AND (end_date IS NULL OR NEW.start_date < end_date)
+    AND (NEW.end_date IS NULL OR start_date < NEW.end_date);
+  IF held >= GREATEST(COALESCE(position_seats, 1), 1) THEN
+    RAISE EXCEPTION 'position % has no seat free from % to %', NEW.position_id, NEW.start_date, COALESCE(NEW.end_date::text, 'now')
+      USING ERRCODE = 'exclusion_violation';
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS terms_check_seats ON terms;
+CREATE TRIGGER terms_check_seats
+  BEFORE INSERT OR UPDATE OF position_id, start_date, end_date, deleted_at ON terms
+  FOR EACH ROW EXECUTE FUNCTION terms_check_seats();
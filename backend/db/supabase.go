@@ -1,23 +1,108 @@
 package db
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/Jsanchez767/InfluencePower/backend/metrics"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
 	postgrest "github.com/supabase-community/postgrest-go"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var Client *postgrest.Client
 
+// connection settings, kept to build per-request clients
+var (
+	restURL string
+	headers map[string]string
+)
+
 // InitSupabase initializes the Supabase client
 func InitSupabase(url, key string) {
-	Client = postgrest.NewClient(url+"/rest/v1", "", map[string]string{
+	restURL = url + "/rest/v1"
+	headers = map[string]string{
 		"apikey":        key,
 		"Authorization": "Bearer " + key,
-	})
-	
-	if Client == nil {
-		log.Fatal("Failed to initialize Supabase client")
 	}
-	
-	log.Println("Supabase client initialized successfully")
+	Client = newClient(context.Background())
+
+	if Client.ClientError != nil {
+		slog.Error("failed to initialize Supabase client", "error", Client.ClientError)
+		os.Exit(1)
+	}
+
+	slog.Info("Supabase client initialized")
+}
+
+// WithContext returns a client whose queries send ctx's request ID to
+// PostgREST and are logged at debug level with their latency. It returns
+// Client unchanged before InitSupabase has been called.
+func WithContext(ctx context.Context) *postgrest.Client {
+	if restURL == "" {
+		return Client
+	}
+	return newClient(ctx)
+}
+
+func newClient(ctx context.Context) *postgrest.Client {
+	h := headers
+	if id := middleware.RequestIDFromContext(ctx); id != "" {
+		h = make(map[string]string, len(headers)+1)
+		for k, v := range headers {
+			h[k] = v
+		}
+		h[middleware.RequestIDHeader] = id
+	}
+
+	client := postgrest.NewClient(restURL, "", h)
+	if client.ClientError == nil {
+		client.Transport.Parent = queryLogger{ctx: ctx}
+	}
+	return client
+}
+
+// queryLogger logs and traces each PostgREST round trip and records its
+// latency
+type queryLogger struct {
+	ctx context.Context
+}
+
+func (l queryLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	table := strings.TrimPrefix(req.URL.Path, "/rest/v1/")
+	ctx, span := tracing.Start(l.ctx, "db "+req.Method+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(req.Method), semconv.DBSQLTable(table)))
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	tracing.Inject(ctx, req.Header)
+
+	start := time.Now()
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	metrics.DBDuration.Observe(elapsed.Seconds(), req.Method, table)
+	attrs := []any{"method", req.Method, "table", table, "duration_ms", float64(elapsed.Microseconds()) / 1000}
+	if err != nil {
+		metrics.DBErrors.Inc(req.Method, table)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.WarnContext(l.ctx, "db query failed", append(attrs, "error", err)...)
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		metrics.DBErrors.Inc(req.Method, table)
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	slog.DebugContext(l.ctx, "db query", append(attrs, "status", resp.StatusCode)...)
+	return resp, nil
 }
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Entities recorded in the sync_state table (schema_sync_state.sql)
+const (
+	EntityPeople  = "people"
+	EntityTerms   = "terms"
+	EntityMatters = "matters"
+	EntityVotes   = "votes"
+	EntityEvents  = "events"
+	EntityBodies  = "bodies"
+	EntityMetrics = "metrics"
+)
+
+// SyncCounts are the records a sync job handled for one entity
+type SyncCounts struct {
+	Fetched  int `json:"records_fetched"`
+	Upserted int `json:"records_upserted"`
+	Failed   int `json:"records_failed"`
+}
+
+// SyncState is the time an entity was last synced and what that sync did.
+// LastSyncedAt is zero if only API writes have recorded the entity.
+type SyncState struct {
+	Entity       string    `json:"entity"`
+	LastSyncedAt time.Time `json:"last_synced_at"`
+	WrittenAt    time.Time `json:"written_at"` // last API write, zero if none
+	SyncCounts
+}
+
+// syncStamp is a sync_state row that leaves the counts untouched
+type syncStamp struct {
+	Entity       string    `json:"entity"`
+	LastSyncedAt time.Time `json:"last_synced_at"`
+}
+
+// RecordSync marks entities as synced now. Sync jobs call it after they
+// finish writing so that API caches are invalidated.
+func RecordSync(client *postgrest.Client, entities ...string) error {
+	now := time.Now().UTC()
+	rows := make([]syncStamp, len(entities))
+	for i, entity := range entities {
+		rows[i] = syncStamp{Entity: entity, LastSyncedAt: now}
+	}
+	return upsertSyncState(client, rows, entities)
+}
+
+// RecordSyncCounts marks entities as synced now, like RecordSync, and stores
+// how many records each sync fetched, upserted and failed to write
+func RecordSyncCounts(client *postgrest.Client, counts map[string]SyncCounts) error {
+	now := time.Now().UTC()
+	entities := make([]string, 0, len(counts))
+	rows := make([]SyncState, 0, len(counts))
+	for entity, c := range counts {
+		entities = append(entities, entity)
+		rows = append(rows, SyncState{Entity: entity, LastSyncedAt: now, SyncCounts: c})
+	}
+	sort.Strings(entities)
+	return upsertSyncState(client, rows, entities)
+}
+
+// writeStamp is a sync_state row that records an API write and leaves the
+// last sync untouched
+type writeStamp struct {
+	Entity    string    `json:"entity"`
+	WrittenAt time.Time `json:"written_at"`
+}
+
+// RecordWrite marks entities as written by the API now, so that every API
+// instance invalidates its caches, without counting as a sync
+func RecordWrite(client *postgrest.Client, entities ...string) error {
+	now := time.Now().UTC()
+	rows := make([]writeStamp, len(entities))
+	for i, entity := range entities {
+		rows[i] = writeStamp{Entity: entity, WrittenAt: now}
+	}
+	return upsertSyncState(client, rows, entities)
+}
+
+func upsertSyncState(client *postgrest.Client, rows interface{}, entities []string) error {
+	_, _, err := client.From("sync_state").
+		Upsert(rows, "entity", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record sync of %v: %w", entities, err)
+	}
+	return nil
+}
+
+// SyncStates returns the last sync time, last write and counts of every
+// entity
+func SyncStates(client *postgrest.Client) ([]SyncState, error) {
+	var states []SyncState
+	_, err := client.From("sync_state").
+		Select("entity, last_synced_at, written_at, records_fetched, records_upserted, records_failed", "", false).
+		ExecuteTo(&states)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync state: %w", err)
+	}
+	return states, nil
+}
+
+// Ping checks that the database answers a trivial query
+func Ping(client *postgrest.Client) error {
+	_, _, err := client.From("people").
+		Select("id", "", false).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+	return nil
+}
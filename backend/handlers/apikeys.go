@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/gorilla/mux"
+)
+
+// CreateAPIKeyRequest is the body of CreateAPIKey
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Role      string     `json:"role" validate:"required,oneof=reader|editor|admin"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse returns the plaintext key, which is only shown once
+type CreateAPIKeyResponse struct {
+	*auth.APIKey
+	Key string `json:"key"`
+}
+
+// keyStore returns the database key store or writes an error if it isn't configured
+func keyStore(w http.ResponseWriter, r *http.Request) *auth.PostgrestKeyStore {
+	if auth.Keys == nil {
+		apierror.Write(w, r, apierror.New(http.StatusNotImplemented, apierror.CodeInternal, "API key storage is not configured"))
+	}
+	return auth.Keys
+}
+
+// ListAPIKeys returns all API keys without their secrets
+func ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	store := keyStore(w, r)
+	if store == nil {
+		return
+	}
+
+	keys, err := store.List(r.Context())
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// CreateAPIKey issues a new API key
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	store := keyStore(w, r)
+	if store == nil {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	role, _ := auth.ParseRole(req.Role)
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		apierror.Write(w, r, validationError(fieldError("expires_at", "future", "must be in the future")))
+		return
+	}
+
+	key, plaintext, err := store.Create(r.Context(), req.Name, role, req.ExpiresAt)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionCreate, "api_keys", key.ID, nil, key))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateAPIKeyResponse{APIKey: key, Key: plaintext})
+}
+
+// RevokeAPIKey revokes an API key
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	store := keyStore(w, r)
+	if store == nil {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	revoked, err := store.Revoke(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if !revoked {
+		apierror.Write(w, r, apierror.NotFound("API key not found"))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionRevoke, "api_keys", id, nil, nil))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+// Limits of ListAuditLog
+const (
+	defaultAuditEntries = 100
+	maxAuditEntries     = 1000
+)
+
+// recordAudit appends entries for writes made by a request, naming its
+// principal and request ID. The writes are done, so failing to record them
+// is logged rather than returned.
+func recordAudit(r *http.Request, entries ...audit.Entry) {
+	requestID := middleware.RequestIDFromContext(r.Context())
+	for i := range entries {
+		entries[i].Actor = principalName(r)
+		entries[i].RequestID = requestID
+	}
+	if err := audit.Append(db.WithContext(r.Context()), entries); err != nil {
+		slog.ErrorContext(r.Context(), "failed to record audit log", "entries", len(entries), "error", err)
+	}
+}
+
+// auditEntry returns an entry for one write of a request; recordAudit fills
+// in the actor and request ID
+func auditEntry(action, entity, entityID string, before, after interface{}) audit.Entry {
+	return audit.New("", action, entity, entityID, before, after, "")
+}
+
+// loadRows returns the rows of table whose column equals value, for the
+// before side of an audit entry
+func loadRows(r *http.Request, table, column, value string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	_, err := db.WithContext(r.Context()).From(table).
+		Select("*", "", false).
+		Eq(column, value).
+		ExecuteTo(&rows)
+	return rows, err
+}
+
+// rowID returns the id of a row from loadRows
+func rowID(row map[string]interface{}) string {
+	if id, ok := row["id"].(float64); ok {
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return fmt.Sprint(row["id"])
+}
+
+// ListAuditLog returns audit log entries, newest first, optionally of one
+// entity, row or actor
+func ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Entity:   query.Get("entity"),
+		EntityID: query.Get("entity_id"),
+		Actor:    query.Get("actor"),
+		Limit:    defaultAuditEntries,
+	}
+	if filter.EntityID != "" && filter.Entity == "" {
+		apierror.Write(w, r, apierror.BadRequest("entity_id needs entity"))
+		return
+	}
+	if v := query.Get("before_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid before_id"))
+			return
+		}
+		filter.BeforeID = id
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid limit"))
+			return
+		}
+		if n > maxAuditEntries {
+			n = maxAuditEntries
+		}
+		filter.Limit = n
+	}
+
+	entries, err := audit.List(db.WithContext(r.Context()), filter)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/metrics"
+)
+
+// PurgeCacheRequest is the body of PurgeCache
+type PurgeCacheRequest struct {
+	Entities []string `json:"entities,omitempty"` // e.g. "votes"; empty purges everything
+}
+
+// PurgeCacheResponse reports how many cached responses were dropped
+type PurgeCacheResponse struct {
+	Removed int `json:"removed"`
+}
+
+// PurgeCache drops cached responses, optionally only those built from the
+// given entities. Sync jobs normally trigger this through sync_state instead.
+func PurgeCache(w http.ResponseWriter, r *http.Request) {
+	var req PurgeCacheRequest
+	if r.ContentLength != 0 {
+		if err := decodeAndValidate(w, r, &req); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PurgeCacheResponse{Removed: invalidateCaches(req.Entities)})
+}
+
+// invalidateCaches drops cached responses and the in-process search index
+func invalidateCaches(entities []string) int {
+	removed := cache.Default.Invalidate(entities...)
+	ResetSearchIndex()
+	return removed
+}
+
+// invalidateWritten drops the cached responses built from entities after an
+// API write, and records the write in sync_state so that the other API
+// instances drop theirs on their next poll
+func invalidateWritten(r *http.Request, entities []string) {
+	invalidateCaches(entities)
+	if err := db.RecordWrite(db.WithContext(r.Context()), entities...); err != nil {
+		slog.ErrorContext(r.Context(), "failed to record write for cache invalidation", "entities", entities, "error", err)
+	}
+}
+
+// WatchSyncs polls sync_state every interval, invalidates cached responses
+// for entities synced or written by an API instance since the previous poll
+// and updates the sync gauges served at /metrics. It returns when ctx is
+// done.
+func WatchSyncs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var seen map[string]time.Time
+	for {
+		states, err := db.SyncStates(db.WithContext(ctx))
+		if err != nil {
+			slog.WarnContext(ctx, "cache invalidation poll failed", "error", err)
+		} else {
+			current := make(map[string]time.Time, len(states))
+			var changed []string
+			for _, s := range states {
+				recordSyncMetrics(s)
+				changedAt := s.LastSyncedAt
+				if s.WrittenAt.After(changedAt) {
+					changedAt = s.WrittenAt
+				}
+				current[s.Entity] = changedAt
+				// The first poll only records a baseline
+				if prev, ok := seen[s.Entity]; seen != nil && (!ok || changedAt.After(prev)) {
+					changed = append(changed, s.Entity)
+				}
+			}
+			seen = current
+
+			if len(changed) > 0 {
+				removed := invalidateCaches(changed)
+				slog.InfoContext(ctx, "cache invalidated", "entities", changed, "removed", removed)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordSyncMetrics exports the last sync of an entity as gauges. Entities
+// only written by the API have no sync to export.
+func recordSyncMetrics(s db.SyncState) {
+	if s.LastSyncedAt.IsZero() {
+		return
+	}
+	metrics.SyncLastSuccess.Set(float64(s.LastSyncedAt.Unix()), s.Entity)
+	metrics.SyncRecords.Set(float64(s.Fetched), s.Entity, "fetched")
+	metrics.SyncRecords.Set(float64(s.Upserted), s.Entity, "upserted")
+	metrics.SyncRecords.Set(float64(s.Failed), s.Entity, "failed")
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/softdelete"
+	"github.com/gorilla/mux"
+)
+
+// Limits of ListDeleted
+const (
+	defaultDeletedRows = 50
+	maxDeletedRows     = 500
+)
+
+// deletedMarks are the fields a soft delete sets, for the after side of its
+// audit entries
+func deletedMarks(row softdelete.Row) map[string]interface{} {
+	return map[string]interface{}{"deleted_at": row.DeletedAt, "deleted_by": row.DeletedBy}
+}
+
+// restoredMarks are the fields a restore sets
+var restoredMarks = map[string]interface{}{"deleted_at": nil, "deleted_by": nil}
+
+// syncEntities returns the cache tags of the tables rows are in
+func syncEntities(rows []softdelete.Row) []string {
+	var entities []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if e, ok := softdelete.Lookup(row.Entity); ok && !seen[e.Sync] {
+			seen[e.Sync] = true
+			entities = append(entities, e.Sync)
+		}
+	}
+	return entities
+}
+
+// softDelete marks the row of table with id deleted by the request's
+// principal, with its dependents, and writes 204
+func softDelete(w http.ResponseWriter, r *http.Request, table, id, notFound string) {
+	if _, err := strconv.Atoi(id); err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid ID"))
+		return
+	}
+	entity, _ := softdelete.Lookup(table)
+
+	rows, err := softdelete.Delete(db.WithContext(r.Context()), entity, id, principalName(r))
+	if len(rows) > 0 {
+		entries := make([]audit.Entry, len(rows))
+		for i, row := range rows {
+			entries[i] = auditEntry(audit.ActionDelete, row.Entity, row.ID, row.Row, deletedMarks(row))
+		}
+		recordAudit(r, entries...)
+		invalidateWritten(r, syncEntities(rows))
+	}
+	switch {
+	case errors.Is(err, softdelete.ErrNotFound), errors.Is(err, softdelete.ErrDeleted):
+		apierror.Write(w, r, apierror.NotFound(notFound))
+	case err != nil:
+		apierror.Write(w, r, apierror.FromUpstream(err))
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// personExists reports whether person id exists and is not deleted
+func personExists(r *http.Request, id string) (bool, error) {
+	rows, err := loadRows(r, "people", "id", id)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) > 0 && rows[0]["deleted_at"] == nil, nil
+}
+
+// DeleteTerm marks a term deleted
+func DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	softDelete(w, r, "terms", mux.Vars(r)["id"], "Term not found")
+}
+
+// DeleteVotingRecord marks a vote deleted
+func DeleteVotingRecord(w http.ResponseWriter, r *http.Request) {
+	softDelete(w, r, "votes", mux.Vars(r)["id"], "Voting record not found")
+}
+
+// deletedEntity returns the soft-deleted table named by {entity}, or writes
+// an error
+func deletedEntity(w http.ResponseWriter, r *http.Request) (softdelete.Entity, bool) {
+	entity, ok := softdelete.Lookup(mux.Vars(r)["entity"])
+	if !ok {
+		apierror.Write(w, r, apierror.NotFound("Entity not found; deleted rows are kept for people, terms and votes"))
+	}
+	return entity, ok
+}
+
+// ListDeleted returns the deleted rows of people, terms or votes, most
+// recently deleted first
+func ListDeleted(w http.ResponseWriter, r *http.Request) {
+	entity, ok := deletedEntity(w, r)
+	if !ok {
+		return
+	}
+	limit := defaultDeletedRows
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid limit"))
+			return
+		}
+		if n > maxDeletedRows {
+			n = maxDeletedRows
+		}
+		limit = n
+	}
+
+	rows, err := softdelete.List(db.WithContext(r.Context()), entity, limit)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
+
+// RestoreDeleted restores a deleted row and the rows deleted with it,
+// returning them as they were deleted
+func RestoreDeleted(w http.ResponseWriter, r *http.Request) {
+	entity, ok := deletedEntity(w, r)
+	if !ok {
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if _, err := strconv.Atoi(id); err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid ID"))
+		return
+	}
+
+	rows, err := softdelete.Restore(db.WithContext(r.Context()), entity, id)
+	if len(rows) > 0 {
+		entries := make([]audit.Entry, len(rows))
+		for i, row := range rows {
+			entries[i] = auditEntry(audit.ActionRestore, row.Entity, row.ID, deletedMarks(row), restoredMarks)
+		}
+		recordAudit(r, entries...)
+		invalidateWritten(r, syncEntities(rows))
+	}
+	switch {
+	case errors.Is(err, softdelete.ErrNotFound):
+		apierror.Write(w, r, apierror.NotFound("Row not found"))
+	case errors.Is(err, softdelete.ErrNotDeleted):
+		apierror.Write(w, r, apierror.Conflict("Row is not deleted"))
+	case errors.Is(err, softdelete.ErrPersonDeleted):
+		apierror.Write(w, r, apierror.Conflict("The row's person is deleted; restore the person first"))
+	case err != nil:
+		apierror.Write(w, r, seatError(err))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rows)
+	}
+}
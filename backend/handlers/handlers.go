@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
 	"github.com/Jsanchez767/InfluencePower/backend/db"
 	"github.com/Jsanchez767/InfluencePower/backend/models"
 	"github.com/gorilla/mux"
@@ -12,15 +15,11 @@ import (
 
 // GetOfficials returns all officials
 func GetOfficials(w http.ResponseWriter, r *http.Request) {
-	var officials []models.Official
-	
 	// Query all current officials from the new current_officials view
-	_, err := db.Client.From("current_officials").
-		Select("*", "exact", false).
-		ExecuteTo(&officials)
+	officials, err := selectOfficials(r, "", "")
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
@@ -33,21 +32,16 @@ func GetOfficialByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var officials []models.Official
-	
 	// Query from current_officials view using person_id
-	_, err := db.Client.From("current_officials").
-		Select("*", "exact", false).
-		Eq("person_id", id).
-		ExecuteTo(&officials)
+	officials, err := selectOfficials(r, "person_id", id)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
 	if len(officials) == 0 {
-		http.Error(w, "Official not found", http.StatusNotFound)
+		apierror.Write(w, r, apierror.NotFound("Official not found"))
 		return
 	}
 
@@ -55,84 +49,10 @@ func GetOfficialByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(officials[0])
 }
 
-// CreateOfficial creates a new official (creates person and term)
-func CreateOfficial(w http.ResponseWriter, r *http.Request) {
-	var official models.Official
-	
-	if err := json.NewDecoder(r.Body).Decode(&official); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Insert into people table
-	var result []models.Official
-	_, err := db.Client.From("people").
-		Insert(official, false, "", "", "").
-		ExecuteTo(&result)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if len(result) > 0 {
-		json.NewEncoder(w).Encode(result[0])
-	} else {
-		json.NewEncoder(w).Encode(official)
-	}
-}
-
-// UpdateOfficial updates an existing official (updates person record)
-func UpdateOfficial(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	var official models.Official
-	if err := json.NewDecoder(r.Body).Decode(&official); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Update people table
-	var result []models.Official
-	_, err := db.Client.From("people").
-		Update(official, "", "").
-		Eq("id", id).
-		ExecuteTo(&result)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if len(result) > 0 {
-		json.NewEncoder(w).Encode(result[0])
-	} else {
-		json.NewEncoder(w).Encode(official)
-	}
-}
-
-// DeleteOfficial deletes an official (deletes person record)
+// DeleteOfficial marks a person deleted, with their terms and votes, so
+// they drop out of every read until restored
 func DeleteOfficial(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	// Delete from people table (cascade will handle terms)
-	var result []models.Official
-	_, err := db.Client.From("people").
-		Delete("", "").
-		Eq("id", id).
-		ExecuteTo(&result)
-	
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
+	softDelete(w, r, "people", mux.Vars(r)["id"], "Official not found")
 }
 
 // GetOfficialsByParty returns officials filtered by party
@@ -140,16 +60,11 @@ func GetOfficialsByParty(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	party := vars["party"]
 
-	var officials []models.Official
-	
 	// Query from current_officials view using party_affiliation
-	_, err := db.Client.From("current_officials").
-		Select("*", "exact", false).
-		Eq("party_affiliation", party).
-		ExecuteTo(&officials)
+	officials, err := selectOfficials(r, "party_affiliation", party)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
@@ -162,16 +77,11 @@ func GetOfficialsByWard(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	ward := vars["ward"]
 
-	var officials []models.Official
-	
 	// Query from current_officials view using district_number (was ward)
-	_, err := db.Client.From("current_officials").
-		Select("*", "exact", false).
-		Eq("district_number", ward).
-		ExecuteTo(&officials)
+	officials, err := selectOfficials(r, "district_number", ward)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
@@ -184,51 +94,90 @@ func GetVotingRecords(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	officialID := vars["id"]
 
-	var records []models.VotingRecord
+	var rows []voteRow
 	
 	// Query from votes table using person_id
-	_, err := db.Client.From("votes").
-		Select("*", "exact", false).
+	_, err := db.WithContext(r.Context()).From("votes").
+		Select(voteColumns, "exact", false).
 		Eq("person_id", officialID).
+		Is("deleted_at", "null").
 		Order("vote_date", nil).
-		ExecuteTo(&records)
+		ExecuteTo(&rows)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
+	records := make([]models.VotingRecord, len(rows))
+	for i, row := range rows {
+		records[i] = row.votingRecord()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(records)
 }
 
-// CreateVotingRecord creates a new voting record
+// CreateVotingRecord creates a new voting record. The bill is a stored
+// matter, by ID or name; the description is the matter's title and is not
+// stored with the vote.
 func CreateVotingRecord(w http.ResponseWriter, r *http.Request) {
 	var record models.VotingRecord
 	
-	if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeAndValidate(w, r, &record); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	exists, err := personExists(r, strconv.Itoa(record.OfficialID))
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if !exists {
+		apierror.Write(w, r, validationError(fieldError("official_id", "exists", "is not an official")))
+		return
+	}
+	matter, err := findMatter(r, record.BillTitle)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if matter == nil {
+		apierror.Write(w, r, validationError(fieldError("bill_title", "exists", "is not a stored matter")))
 		return
 	}
 
 	// Insert into votes table
-	var result []models.VotingRecord
-	_, err := db.Client.From("votes").
-		Insert(record, false, "", "", "").
-		ExecuteTo(&result)
+	var rows []map[string]interface{}
+	_, err = db.WithContext(r.Context()).From("votes").
+		Insert(newVoteRow{
+			PersonID:  record.OfficialID,
+			MatterID:  matter.MatterID,
+			VoteValue: record.Vote,
+			VoteDate:  record.VoteDate.UTC(),
+		}, false, "", "", "").
+		ExecuteTo(&rows)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
+	if len(rows) == 0 {
+		apierror.Write(w, r, apierror.Internal(errors.New("insert into votes returned no row")))
+		return
+	}
+	var vote voteRow
+	if err := decodeRow(rows[0], &vote); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	vote.Matter = &matter.voteMatter
+	recordAudit(r, auditEntry(audit.ActionCreate, "votes", rowID(rows[0]), nil, rows[0]))
+	invalidateWritten(r, []string{db.EntityVotes})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	if len(result) > 0 {
-		json.NewEncoder(w).Encode(result[0])
-	} else {
-		json.NewEncoder(w).Encode(record)
-	}
+	json.NewEncoder(w).Encode(vote.votingRecord())
 }
 
 // GetWardStatistics returns statistics for a specific ward
@@ -237,25 +186,25 @@ func GetWardStatistics(w http.ResponseWriter, r *http.Request) {
 	wardStr := vars["ward"]
 	ward, err := strconv.Atoi(wardStr)
 	if err != nil {
-		http.Error(w, "Invalid ward number", http.StatusBadRequest)
+		apierror.Write(w, r, apierror.BadRequest("Invalid ward number"))
 		return
 	}
 
 	var stats []models.WardStatistic
 	
-	// Query from current_officials view with district_number
-	_, err = db.Client.From("current_officials").
+	// Query from the ward_statistics table the model mirrors
+	_, err = db.WithContext(r.Context()).From("ward_statistics").
 		Select("*", "exact", false).
-		Eq("district_number", strconv.Itoa(ward)).
+		Eq("ward", strconv.Itoa(ward)).
 		ExecuteTo(&stats)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
 	if len(stats) == 0 {
-		http.Error(w, "Ward statistics not found", http.StatusNotFound)
+		apierror.Write(w, r, apierror.NotFound("Ward statistics not found"))
 		return
 	}
 
@@ -267,12 +216,12 @@ func GetWardStatistics(w http.ResponseWriter, r *http.Request) {
 func GetCommittees(w http.ResponseWriter, r *http.Request) {
 	var committees []models.Committee
 	
-	_, err := db.Client.From("committees").
+	_, err := db.WithContext(r.Context()).From("committees").
 		Select("*", "exact", false).
 		ExecuteTo(&committees)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
@@ -287,13 +236,13 @@ func GetOfficialCommittees(w http.ResponseWriter, r *http.Request) {
 
 	var committees []models.OfficialCommittee
 	
-	_, err := db.Client.From("official_committees").
+	_, err := db.WithContext(r.Context()).From("official_committees").
 		Select("*, committees(*)", "exact", false).
 		Eq("official_id", officialID).
 		ExecuteTo(&committees)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
@@ -306,21 +255,32 @@ func GetOfficialMetrics(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	officialID := vars["id"]
 
-	var metrics []map[string]interface{}
+	// Metrics of deleted people are not served
+	exists, err := personExists(r, officialID)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if !exists {
+		apierror.Write(w, r, apierror.NotFound("Metrics not found"))
+		return
+	}
+
+	var metrics []models.PersonMetrics
 	
 	// Query from person_metrics table using person_id
-	_, err := db.Client.From("person_metrics").
+	_, err = db.WithContext(r.Context()).From("person_metrics").
 		Select("*", "exact", false).
 		Eq("person_id", officialID).
 		ExecuteTo(&metrics)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
 	if len(metrics) == 0 {
-		http.Error(w, "Metrics not found", http.StatusNotFound)
+		apierror.Write(w, r, apierror.NotFound("Metrics not found"))
 		return
 	}
 
@@ -336,18 +296,18 @@ func GetWardMetrics(w http.ResponseWriter, r *http.Request) {
 	var metrics []map[string]interface{}
 	
 	// Query person_metrics by joining through current_officials view
-	_, err := db.Client.From("current_officials").
+	_, err := db.WithContext(r.Context()).From("current_officials").
 		Select("*, person_metrics(*)", "exact", false).
 		Eq("district_number", ward).
 		ExecuteTo(&metrics)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
 	if len(metrics) == 0 {
-		http.Error(w, "Ward metrics not found", http.StatusNotFound)
+		apierror.Write(w, r, apierror.NotFound("Ward metrics not found"))
 		return
 	}
 
@@ -362,48 +322,37 @@ func GetVotingAllies(w http.ResponseWriter, r *http.Request) {
 
 	// Query votes from the official
 	var officialVotes []map[string]interface{}
-	_, err := db.Client.From("votes").
-		Select("matter_id, vote_result", "exact", false).
+	_, err := db.WithContext(r.Context()).From("votes").
+		Select("matter_id, vote_value", "exact", false).
 		Eq("person_id", officialID).
+		Is("deleted_at", "null").
 		ExecuteTo(&officialVotes)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
-	// Create a map of matter_id -> vote_result for this official
+	// Create a map of matter_id -> vote_value for this official
 	officialVoteMap := make(map[string]string)
 	for _, vote := range officialVotes {
 		if matterID, ok := vote["matter_id"].(string); ok {
-			if voteResult, ok := vote["vote_result"].(string); ok {
-				officialVoteMap[matterID] = voteResult
+			if voteValue, ok := vote["vote_value"].(string); ok {
+				officialVoteMap[matterID] = voteValue
 			}
 		}
 	}
 
 	// Get all other officials from current_officials view
-	var officials []models.Official
-	_, err = db.Client.From("current_officials").
-		Select("person_id, full_name, district_number, party_affiliation", "exact", false).
-		ExecuteTo(&officials)
+	officials, err := selectOfficials(r, "", "")
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
 	// Calculate alignment with each official
-	type AllyData struct {
-		OfficialID int     `json:"official_id"`
-		Name       string  `json:"name"`
-		Ward       *int    `json:"ward"`
-		Party      string  `json:"party"`
-		Alignment  float64 `json:"alignment"`
-		Bloc       string  `json:"bloc"`
-	}
-
-	var allies []AllyData
+	allies := []models.VotingAlly{}
 
 	for _, other := range officials {
 		if strconv.Itoa(other.ID) == officialID {
@@ -412,9 +361,10 @@ func GetVotingAllies(w http.ResponseWriter, r *http.Request) {
 
 		// Get votes for this official
 		var otherVotes []map[string]interface{}
-		_, err := db.Client.From("votes").
-			Select("matter_id, vote_result", "exact", false).
+		_, err := db.WithContext(r.Context()).From("votes").
+			Select("matter_id, vote_value", "exact", false).
 			Eq("person_id", strconv.Itoa(other.ID)).
+			Is("deleted_at", "null").
 			ExecuteTo(&otherVotes)
 		
 		if err != nil {
@@ -427,7 +377,7 @@ func GetVotingAllies(w http.ResponseWriter, r *http.Request) {
 		for _, vote := range otherVotes {
 			if matterID, ok := vote["matter_id"].(string); ok {
 				if officialVote, exists := officialVoteMap[matterID]; exists {
-					if otherVote, ok := vote["vote_result"].(string); ok {
+					if otherVote, ok := vote["vote_value"].(string); ok {
 						total++
 						if officialVote == otherVote {
 							matches++
@@ -444,7 +394,7 @@ func GetVotingAllies(w http.ResponseWriter, r *http.Request) {
 				bloc = "Progressive Caucus"
 			}
 
-			allies = append(allies, AllyData{
+			allies = append(allies, models.VotingAlly{
 				OfficialID: other.ID,
 				Name:       other.Name,
 				Ward:       other.Ward,
@@ -478,17 +428,18 @@ func GetRecentVotes(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	officialID := vars["id"]
 
-	var votes []map[string]interface{}
+	var votes []models.RecentVote
 	
-	_, err := db.Client.From("votes").
-		Select("*, matters(matter_name, matter_type)", "exact", false).
+	_, err := db.WithContext(r.Context()).From("votes").
+		Select("*, matters(matter_name, matter_type_name)", "exact", false).
 		Eq("person_id", officialID).
+		Is("deleted_at", "null").
 		Order("created_at", nil).
 		Limit(10, "").
 		ExecuteTo(&votes)
 	
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.FromUpstream(err))
 		return
 	}
 
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/scheduler"
+	"github.com/gorilla/mux"
+)
+
+// maxJobRuns caps the runs ListJobRuns returns
+const maxJobRuns = 500
+
+// jobScheduler returns the scheduler or writes an error if it isn't running
+func jobScheduler(w http.ResponseWriter, r *http.Request) *scheduler.Scheduler {
+	if scheduler.Default == nil {
+		apierror.Write(w, r, apierror.New(http.StatusNotImplemented, apierror.CodeInternal, "job scheduler is not configured"))
+	}
+	return scheduler.Default
+}
+
+// ListJobs returns every job with its schedule, next and last run
+func ListJobs(w http.ResponseWriter, r *http.Request) {
+	s := jobScheduler(w, r)
+	if s == nil {
+		return
+	}
+
+	jobs, err := s.Jobs(r.Context())
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// ListJobRuns returns the most recent job runs, newest first
+func ListJobRuns(w http.ResponseWriter, r *http.Request) {
+	s := jobScheduler(w, r)
+	if s == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	limit := scheduler.DefaultHistory
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid limit"))
+			return
+		}
+		if n > maxJobRuns {
+			n = maxJobRuns
+		}
+		limit = n
+	}
+
+	runs, err := s.Runs(r.Context(), query.Get("job"), limit)
+	if errors.Is(err, scheduler.ErrUnknownJob) {
+		apierror.Write(w, r, apierror.BadRequest("Unknown job"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// TriggerJob starts a job now and returns its run without waiting for it to
+// finish
+func TriggerJob(w http.ResponseWriter, r *http.Request) {
+	s := jobScheduler(w, r)
+	if s == nil {
+		return
+	}
+
+	run, err := s.Trigger(r.Context(), mux.Vars(r)["name"])
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		apierror.Write(w, r, apierror.NotFound("Job not found"))
+		return
+	case errors.Is(err, scheduler.ErrRunning):
+		apierror.Write(w, r, apierror.Conflict("Job is already running"))
+		return
+	case err != nil:
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	// The run's writes are audited under the same request ID; this entry
+	// names who started it
+	recordAudit(r, auditEntry(audit.ActionTrigger, "job_runs", strconv.FormatInt(run.ID, 10), nil, map[string]string{"job": run.Job, "trigger": run.Trigger}))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(run)
+}
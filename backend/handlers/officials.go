@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/models"
+)
+
+// officialColumns are the columns of current_officials an Official is read from
+const officialColumns = "person_id, full_name, district_number, party_affiliation, title, phone, email, image_url, created_at, updated_at"
+
+// officialRow is a row of the current_officials view
+type officialRow struct {
+	PersonID         int       `json:"person_id"`
+	FullName         string    `json:"full_name"`
+	DistrictNumber   *int      `json:"district_number"`
+	PartyAffiliation *string   `json:"party_affiliation"`
+	Title            string    `json:"title"`
+	Phone            *string   `json:"phone"`
+	Email            *string   `json:"email"`
+	ImageURL         *string   `json:"image_url"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// official returns the Official the API serves for row: the person, with
+// the ward and title of the position they hold
+func (row officialRow) official() models.Official {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return models.Official{
+		ID:        row.PersonID,
+		Name:      row.FullName,
+		Ward:      row.DistrictNumber,
+		Party:     deref(row.PartyAffiliation),
+		Role:      row.Title,
+		Contact:   deref(row.Phone),
+		Email:     deref(row.Email),
+		ImageURL:  deref(row.ImageURL),
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+// selectOfficials returns the current officials whose column equals value,
+// or every current official if column is empty
+func selectOfficials(r *http.Request, column, value string) ([]models.Official, error) {
+	q := db.WithContext(r.Context()).From("current_officials").
+		Select(officialColumns, "exact", false)
+	if column != "" {
+		q = q.Eq(column, value)
+	}
+	var rows []officialRow
+	if _, err := q.ExecuteTo(&rows); err != nil {
+		return nil, err
+	}
+	officials := make([]models.Official, len(rows))
+	for i, row := range rows {
+		officials[i] = row.official()
+	}
+	return officials, nil
+}
+
+// CreateOfficial answers 410: officials are created as a person with
+// POST /people and given a position with POST /positions/{id}/terms
+func CreateOfficial(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, apierror.Gone("POST /officials was removed; create the person with POST /people and give them a position with POST /positions/{id}/terms").
+		WithDetails(map[string]interface{}{"replacements": []string{"POST /api/v1/people", "POST /api/v1/positions/{id}/terms"}}))
+}
+
+// UpdateOfficial answers 410: officials are changed with PATCH /people/{id}
+func UpdateOfficial(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, apierror.Gone("PUT /officials/{id} was removed; change the person with PATCH /people/{id}").
+		WithDetails(map[string]interface{}{"replacements": []string{"PATCH /api/v1/people/{id}"}}))
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/overrides"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+	"github.com/gorilla/mux"
+)
+
+// SetOverrideRequest is the body of SetOverride
+type SetOverrideRequest struct {
+	Entity   string          `json:"entity" validate:"required,oneof=person|term|matter"`
+	EntityID string          `json:"entity_id" validate:"required,max=100"`
+	Field    string          `json:"field" validate:"required"`
+	Value    json.RawMessage `json:"value" validate:"required"` // null pins the field empty
+	Reason   string          `json:"reason" validate:"required,max=1000"`
+}
+
+// overrideStore returns the override store or writes an error if it isn't
+// configured
+func overrideStore(w http.ResponseWriter, r *http.Request) overrides.Store {
+	if overrides.Default == nil {
+		apierror.Write(w, r, apierror.New(http.StatusNotImplemented, apierror.CodeInternal, "overrides are not configured"))
+	}
+	return overrides.Default
+}
+
+// ListOverrides returns the pinned fields, optionally of one entity or row
+func ListOverrides(w http.ResponseWriter, r *http.Request) {
+	store := overrideStore(w, r)
+	if store == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := overrides.Filter{Entity: query.Get("entity"), EntityID: query.Get("entity_id")}
+	if _, ok := overrides.Lookup(filter.Entity); filter.Entity != "" && !ok {
+		apierror.Write(w, r, apierror.BadRequest("Invalid entity"))
+		return
+	}
+
+	list, err := store.List(r.Context(), filter)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// SetOverride pins a field of a person, term or matter to a value, writing
+// it to the row now and on every later sync
+func SetOverride(w http.ResponseWriter, r *http.Request) {
+	store := overrideStore(w, r)
+	if store == nil {
+		return
+	}
+
+	var req SetOverrideRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	entity, _ := overrides.Lookup(req.Entity)
+	if !entity.Pinnable(req.Field) {
+		apierror.Write(w, r, validationError(fieldError("field", "pinnable", "is not a field of "+req.Entity+" that syncs write")))
+		return
+	}
+
+	// What the override and the row were, for the audit log
+	pinned, err := store.List(r.Context(), overrides.Filter{Entity: req.Entity, EntityID: req.EntityID})
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	var previous *overrides.Override
+	for i := range pinned {
+		if pinned[i].Field == req.Field {
+			previous = &pinned[i]
+		}
+	}
+	rows, err := loadRows(r, entity.Table, entity.Key, req.EntityID)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	o, err := store.Set(r.Context(), overrides.Override{
+		Entity:    req.Entity,
+		EntityID:  req.EntityID,
+		Field:     req.Field,
+		Value:     req.Value,
+		Reason:    req.Reason,
+		CreatedBy: principalName(r),
+	})
+	if errors.Is(err, overrides.ErrEntityNotFound) {
+		apierror.Write(w, r, apierror.NotFound("Overridden "+req.Entity+" not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	action, before := audit.ActionCreate, interface{}(nil)
+	if previous != nil {
+		action, before = audit.ActionUpdate, previous
+	}
+	entries := []audit.Entry{auditEntry(action, "field_overrides", strconv.FormatInt(o.ID, 10), before, o)}
+	if len(rows) > 0 {
+		entries = append(entries, auditEntry(audit.ActionUpdate, entity.Table, req.EntityID,
+			map[string]interface{}{req.Field: rows[0][req.Field]}, map[string]json.RawMessage{req.Field: req.Value}))
+	}
+	recordAudit(r, entries...)
+	source := provenance.Source{
+		System:    provenance.SystemOverride,
+		RecordID:  fmt.Sprintf("field_overrides/%d", o.ID),
+		FetchedAt: o.UpdatedAt,
+		RunID:     middleware.RequestIDFromContext(r.Context()),
+	}
+	// The value is written; a missing provenance row does not undo that
+	if err := provenance.Record(db.WithContext(r.Context()), provenance.Fields(o.Entity, o.EntityID, []string{o.Field}, source)); err != nil {
+		slog.WarnContext(r.Context(), "failed to record provenance of override", "override_id", o.ID, "error", err)
+	}
+	invalidateWritten(r, []string{entity.Sync})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(o)
+}
+
+// DeleteOverride unpins a field. The row keeps the pinned value until the
+// next sync writes the upstream one.
+func DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	store := overrideStore(w, r)
+	if store == nil {
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid override ID"))
+		return
+	}
+
+	o, err := store.Delete(r.Context(), id)
+	if errors.Is(err, overrides.ErrNotFound) {
+		apierror.Write(w, r, apierror.NotFound("Override not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionDelete, "field_overrides", strconv.FormatInt(id, 10), o, nil))
+
+	w.WriteHeader(http.StatusNoContent)
+}
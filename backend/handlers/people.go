@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// PersonRequest is the body of CreatePerson, and a person as UpdatePerson
+// validates it after applying the patch
+type PersonRequest struct {
+	FirstName        string  `json:"first_name" validate:"required,max=100"`
+	LastName         string  `json:"last_name" validate:"required,max=100"`
+	FullName         string  `json:"full_name,omitempty" validate:"max=200"` // first and last name if empty
+	MiddleName       *string `json:"middle_name,omitempty" validate:"omitempty,max=100"`
+	Suffix           *string `json:"suffix,omitempty" validate:"omitempty,max=20"`
+	Email            *string `json:"email,omitempty" validate:"omitempty,email"`
+	Phone            *string `json:"phone,omitempty" validate:"omitempty,max=50"`
+	Website          *string `json:"website,omitempty" validate:"omitempty,url"`
+	TwitterHandle    *string `json:"twitter_handle,omitempty" validate:"omitempty,max=100"`
+	FacebookURL      *string `json:"facebook_url,omitempty" validate:"omitempty,url"`
+	InstagramHandle  *string `json:"instagram_handle,omitempty" validate:"omitempty,max=100"`
+	ImageURL         *string `json:"image_url,omitempty" validate:"omitempty,url"`
+	DateOfBirth      *string `json:"date_of_birth,omitempty" validate:"omitempty,date,notfuture"`
+	PartyAffiliation *string `json:"party_affiliation,omitempty" validate:"omitempty,max=100"`
+}
+
+func (p *PersonRequest) normalize() {
+	if strings.TrimSpace(p.FullName) == "" {
+		p.FullName = strings.TrimSpace(p.FirstName + " " + p.LastName)
+	}
+}
+
+// PersonPatch is the body of UpdatePerson, a JSON Merge Patch: the fields
+// to change, null to clear one. A null full_name is recomputed from the
+// first and last name.
+type PersonPatch struct {
+	FirstName        *string `json:"first_name,omitempty"`
+	LastName         *string `json:"last_name,omitempty"`
+	FullName         *string `json:"full_name,omitempty"`
+	MiddleName       *string `json:"middle_name,omitempty"`
+	Suffix           *string `json:"suffix,omitempty"`
+	Email            *string `json:"email,omitempty"`
+	Phone            *string `json:"phone,omitempty"`
+	Website          *string `json:"website,omitempty"`
+	TwitterHandle    *string `json:"twitter_handle,omitempty"`
+	FacebookURL      *string `json:"facebook_url,omitempty"`
+	InstagramHandle  *string `json:"instagram_handle,omitempty"`
+	ImageURL         *string `json:"image_url,omitempty"`
+	DateOfBirth      *string `json:"date_of_birth,omitempty"`
+	PartyAffiliation *string `json:"party_affiliation,omitempty"`
+}
+
+// GetPerson returns a person
+func GetPerson(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	var person models.Person
+	row, err := loadLive(r, "people", id, &person)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if row == nil {
+		apierror.Write(w, r, apierror.NotFound("Person not found"))
+		return
+	}
+	writeEntity(w, http.StatusOK, person)
+}
+
+// CreatePerson adds a person. Positions they hold are added as terms.
+func CreatePerson(w http.ResponseWriter, r *http.Request) {
+	var req PersonRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var rows []map[string]interface{}
+	_, err := db.WithContext(r.Context()).From("people").
+		Insert(req, false, "", "", "").
+		ExecuteTo(&rows)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if len(rows) == 0 {
+		apierror.Write(w, r, apierror.Internal(errors.New("insert into people returned no row")))
+		return
+	}
+	var person models.Person
+	if err := decodeRow(rows[0], &person); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	id := rowID(rows[0])
+	recordAudit(r, auditEntry(audit.ActionCreate, "people", id, nil, rows[0]))
+	recordManualProvenance(r, "person", id, fieldNames(req))
+	invalidateWritten(r, []string{db.EntityPeople})
+
+	writeEntity(w, http.StatusCreated, person)
+}
+
+// UpdatePerson applies a JSON Merge Patch to a person. Fields the syncs
+// write are overwritten by the next sync unless they are pinned with an
+// override.
+func UpdatePerson(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	var person models.Person
+	row, err := loadLive(r, "people", id, &person)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if row == nil {
+		apierror.Write(w, r, apierror.NotFound("Person not found"))
+		return
+	}
+	if err := checkIfMatch(r, person); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var req PersonRequest
+	changes, err := decodePatch(w, r, row, &PersonPatch{}, &req)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if len(changes) == 0 {
+		writeEntity(w, http.StatusOK, person)
+		return
+	}
+
+	updated, err := updateVersioned(r, "people", id, row["updated_at"], changes)
+	if errors.Is(err, errChanged) {
+		apierror.Write(w, r, changedError(r))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionUpdate, "people", id, changedFields(row, changes), changedFields(updated, changes)))
+	recordManualProvenance(r, "person", id, sortedFields(changes))
+	invalidateWritten(r, []string{db.EntityPeople})
+
+	person = models.Person{}
+	if err := decodeRow(updated, &person); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	writeEntity(w, http.StatusOK, person)
+}
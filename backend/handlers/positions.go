@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// PositionRequest is the body of CreatePosition, and a position as
+// UpdatePosition validates it after applying the patch
+type PositionRequest struct {
+	JurisdictionID int     `json:"jurisdiction_id" validate:"required,min=1"`
+	PositionType   string  `json:"position_type" validate:"required,max=50"` // e.g. "alderman" or "mayor"
+	DistrictNumber *int    `json:"district_number,omitempty" validate:"omitempty,min=1"`
+	DistrictName   *string `json:"district_name,omitempty" validate:"omitempty,max=100"`
+	Title          string  `json:"title" validate:"required,max=200"`
+	BodyName       *string `json:"body_name,omitempty" validate:"omitempty,max=200"`
+	BodyID         *int    `json:"body_id,omitempty" validate:"omitempty,min=1"`
+	Seats          int     `json:"seats,omitempty" validate:"min=1,max=100"` // 1 if omitted
+}
+
+func (p *PositionRequest) normalize() {
+	if p.Seats == 0 {
+		p.Seats = 1
+	}
+}
+
+// PositionPatch is the body of UpdatePosition, a JSON Merge Patch. The
+// jurisdiction, type and district identify a position to the syncs, so
+// they cannot be changed.
+type PositionPatch struct {
+	DistrictName *string `json:"district_name,omitempty"`
+	Title        *string `json:"title,omitempty"`
+	BodyName     *string `json:"body_name,omitempty"`
+	BodyID       *int    `json:"body_id,omitempty"`
+	Seats        *int    `json:"seats,omitempty"` // null resets it to 1
+}
+
+// loadPosition returns the position with id, or nil if there is none
+func loadPosition(r *http.Request, id string) (*models.Position, map[string]interface{}, error) {
+	var position models.Position
+	row, err := loadLive(r, "positions", id, &position)
+	if err != nil || row == nil {
+		return nil, nil, err
+	}
+	return &position, row, nil
+}
+
+// GetPosition returns a position
+func GetPosition(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	position, _, err := loadPosition(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if position == nil {
+		apierror.Write(w, r, apierror.NotFound("Position not found"))
+		return
+	}
+	writeEntity(w, http.StatusOK, position)
+}
+
+// CreatePosition adds a position
+func CreatePosition(w http.ResponseWriter, r *http.Request) {
+	var req PositionRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var rows []map[string]interface{}
+	_, err := db.WithContext(r.Context()).From("positions").
+		Insert(req, false, "", "", "").
+		ExecuteTo(&rows)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if len(rows) == 0 {
+		apierror.Write(w, r, apierror.Internal(errors.New("insert into positions returned no row")))
+		return
+	}
+	var position models.Position
+	if err := decodeRow(rows[0], &position); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionCreate, "positions", rowID(rows[0]), nil, rows[0]))
+	invalidateWritten(r, []string{db.EntityTerms})
+
+	writeEntity(w, http.StatusCreated, position)
+}
+
+// UpdatePosition applies a JSON Merge Patch to a position. Its seats cannot
+// drop below the number of people holding it today.
+func UpdatePosition(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	position, row, err := loadPosition(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if position == nil {
+		apierror.Write(w, r, apierror.NotFound("Position not found"))
+		return
+	}
+	if err := checkIfMatch(r, position); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var req PositionRequest
+	changes, err := decodePatch(w, r, row, &PositionPatch{}, &req)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if len(changes) == 0 {
+		writeEntity(w, http.StatusOK, position)
+		return
+	}
+	if _, ok := changes["seats"]; ok && req.Seats < position.Seats {
+		holders, err := currentTerms(r, position.ID)
+		if err != nil {
+			apierror.Write(w, r, apierror.FromUpstream(err))
+			return
+		}
+		if len(holders) > req.Seats {
+			apierror.Write(w, r, apierror.Conflict(fmt.Sprintf("%d people hold the position; close their terms before removing seats", len(holders))).
+				WithDetails(map[string]interface{}{"terms": termIDs(holders)}))
+			return
+		}
+	}
+
+	updated, err := updateVersioned(r, "positions", id, row["updated_at"], changes)
+	if errors.Is(err, errChanged) {
+		apierror.Write(w, r, changedError(r))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionUpdate, "positions", id, changedFields(row, changes), changedFields(updated, changes)))
+	invalidateWritten(r, []string{db.EntityTerms})
+
+	var result models.Position
+	if err := decodeRow(updated, &result); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	writeEntity(w, http.StatusOK, result)
+}
+
+// ListPositionTerms returns the terms of a position, latest first
+func ListPositionTerms(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	position, _, err := loadPosition(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if position == nil {
+		apierror.Write(w, r, apierror.NotFound("Position not found"))
+		return
+	}
+	terms, err := positionTerms(r, position.ID)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(terms)
+}
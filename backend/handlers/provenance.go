@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+	"github.com/gorilla/mux"
+)
+
+// GetPersonProvenance returns where each recorded field of a person came
+// from, by field. Fields written before provenance was recorded are absent.
+func GetPersonProvenance(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := strconv.Atoi(id); err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid person ID"))
+		return
+	}
+
+	exists, err := personExists(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if !exists {
+		apierror.Write(w, r, apierror.NotFound("Person not found"))
+		return
+	}
+
+	fields, err := provenance.List(db.WithContext(r.Context()), "person", id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fields)
+}
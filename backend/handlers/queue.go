@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/jobs"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+	"github.com/gorilla/mux"
+)
+
+// Limits of ListQueueTasks
+const (
+	defaultQueueTasks = 50
+	maxQueueTasks     = 500
+)
+
+// QueueCount is the number of units of a kind with a status
+type QueueCount struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// queueStore returns the work queue or writes an error if it isn't configured
+func queueStore(w http.ResponseWriter, r *http.Request) queue.Store {
+	if queue.Default == nil {
+		apierror.Write(w, r, apierror.New(http.StatusNotImplemented, apierror.CodeInternal, "work queue is not configured"))
+	}
+	return queue.Default
+}
+
+// GetQueueStats counts the queued units of each kind by status
+func GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	store := queueStore(w, r)
+	if store == nil {
+		return
+	}
+
+	counts := []QueueCount{}
+	for _, kind := range jobs.UnitKinds {
+		for _, status := range queue.Statuses {
+			n, err := store.Count(r.Context(), kind, status)
+			if err != nil {
+				apierror.Write(w, r, apierror.FromUpstream(err))
+				return
+			}
+			counts = append(counts, QueueCount{Kind: kind, Status: status, Count: n})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
+
+// ListQueueTasks returns queued units, newest first, e.g. the dead ones
+func ListQueueTasks(w http.ResponseWriter, r *http.Request) {
+	store := queueStore(w, r)
+	if store == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := queue.Filter{Kind: query.Get("kind"), Status: query.Get("status"), Limit: defaultQueueTasks}
+	if filter.Status != "" && !slices.Contains(queue.Statuses, filter.Status) {
+		apierror.Write(w, r, apierror.BadRequest("Invalid status"))
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid limit"))
+			return
+		}
+		if n > maxQueueTasks {
+			n = maxQueueTasks
+		}
+		filter.Limit = n
+	}
+
+	tasks, err := store.List(r.Context(), filter)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
+
+// taskID parses the {id} of a unit
+func taskID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid task ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// RetryQueueTask makes a dead unit pending again, so the next run of its job
+// resumes its batch
+func RetryQueueTask(w http.ResponseWriter, r *http.Request) {
+	store := queueStore(w, r)
+	if store == nil {
+		return
+	}
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := store.Retry(r.Context(), id)
+	if errors.Is(err, queue.ErrNotFound) {
+		apierror.Write(w, r, apierror.NotFound("Dead task not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionRetry, "queue_tasks", strconv.FormatInt(id, 10), nil, task))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// DiscardQueueTask deletes a dead unit
+func DiscardQueueTask(w http.ResponseWriter, r *http.Request) {
+	store := queueStore(w, r)
+	if store == nil {
+		return
+	}
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	err := store.Discard(r.Context(), id)
+	if errors.Is(err, queue.ErrNotFound) {
+		apierror.Write(w, r, apierror.NotFound("Dead task not found"))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionDelete, "queue_tasks", strconv.FormatInt(id, 10), nil, nil))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/Jsanchez767/InfluencePower/backend/validate"
+)
+
+// maxBodyBytes limits the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+// decodeAndValidate decodes the JSON request body into dst, fills in its
+// defaults if it is a normalizer, and checks its `validate` tags. The
+// returned error is always an *apierror.Error.
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if dec.More() {
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "Request body must contain a single JSON object")
+	}
+	if n, ok := dst.(normalizer); ok {
+		n.normalize()
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		return validationError(err)
+	}
+	return nil
+}
+
+// validationError converts a validate error into an API error with field details
+func validationError(err error) error {
+	var fieldErrs validate.Errors
+	if errors.As(err, &fieldErrs) {
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeValidationFailed, "Request body failed validation").
+			WithDetails(fieldErrs)
+	}
+	return apierror.Internal(err)
+}
+
+// fieldError builds a validation error for a single field
+func fieldError(field, rule, message string) validate.Errors {
+	return validate.Errors{{Field: field, Rule: rule, Message: message}}
+}
+
+// decodeError maps JSON decoding failures to client errors
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	var sizeErr *http.MaxBytesError
+
+	invalid := func(field, rule, message string) error {
+		return validationError(fieldError(field, rule, message))
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "Request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "Request body is not valid JSON")
+	case errors.As(err, &sizeErr):
+		return apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+	case errors.As(err, &typeErr):
+		return invalid(typeErr.Field, "type", "must be a "+typeErr.Type.String())
+	case errors.As(err, &timeErr):
+		return invalid("", "date", "dates must be in RFC 3339 format")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return invalid(field, "unknown", "is not a recognized field")
+	}
+	return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "Request body could not be decoded")
+}
+
+// principalName names the principal making a request, for the records of
+// who changed what
+func principalName(r *http.Request) string {
+	if p := auth.PrincipalFromContext(r.Context()); p != nil {
+		return p.Subject
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/review"
+	"github.com/gorilla/mux"
+)
+
+// Limits of ListReviewItems
+const (
+	defaultReviewItems = 50
+	maxReviewItems     = 500
+)
+
+// DismissReviewItemRequest is the body of DismissReviewItem
+type DismissReviewItemRequest struct {
+	Note string `json:"note,omitempty" validate:"max=1000"`
+}
+
+// reviewStore returns the review queue or writes an error if it isn't
+// configured
+func reviewStore(w http.ResponseWriter, r *http.Request) review.Store {
+	if review.Default == nil {
+		apierror.Write(w, r, apierror.New(http.StatusNotImplemented, apierror.CodeInternal, "review queue is not configured"))
+	}
+	return review.Default
+}
+
+// ListReviewItems returns review items, oldest first, open ones unless
+// another status is asked for
+func ListReviewItems(w http.ResponseWriter, r *http.Request) {
+	store := reviewStore(w, r)
+	if store == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := review.Filter{Kind: query.Get("kind"), Status: query.Get("status"), Limit: defaultReviewItems}
+	if filter.Status == "" {
+		filter.Status = review.StatusOpen
+	}
+	if !slices.Contains(review.Statuses, filter.Status) {
+		apierror.Write(w, r, apierror.BadRequest("Invalid status"))
+		return
+	}
+	if filter.Kind != "" && !slices.Contains(review.Kinds, filter.Kind) {
+		apierror.Write(w, r, apierror.BadRequest("Invalid kind"))
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid limit"))
+			return
+		}
+		if n > maxReviewItems {
+			n = maxReviewItems
+		}
+		filter.Limit = n
+	}
+
+	items, err := store.List(r.Context(), filter)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// reviewItemID parses the {id} of a review item
+func reviewItemID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid review item ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// writeReviewItem writes item, or the error deciding it
+func writeReviewItem(w http.ResponseWriter, r *http.Request, item review.Item, err error) {
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		apierror.Write(w, r, apierror.NotFound("Review item not found"))
+	case errors.Is(err, review.ErrNotOpen):
+		apierror.Write(w, r, apierror.Conflict("Review item is already resolved or dismissed"))
+	case err != nil:
+		apierror.Write(w, r, apierror.FromUpstream(err))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(item)
+	}
+}
+
+// GetReviewItem returns a review item with its upstream record and
+// candidates
+func GetReviewItem(w http.ResponseWriter, r *http.Request) {
+	store := reviewStore(w, r)
+	if store == nil {
+		return
+	}
+	id, ok := reviewItemID(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := store.Get(r.Context(), id)
+	writeReviewItem(w, r, item, err)
+}
+
+// ResolveReviewItem records the decision on an open review item. The next
+// sync applies it to the item's record.
+func ResolveReviewItem(w http.ResponseWriter, r *http.Request) {
+	store := reviewStore(w, r)
+	if store == nil {
+		return
+	}
+	id, ok := reviewItemID(w, r)
+	if !ok {
+		return
+	}
+
+	var req review.Resolution
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	item, err := store.Get(r.Context(), id)
+	if err != nil {
+		writeReviewItem(w, r, item, err)
+		return
+	}
+	if err := req.Validate(item.Kind); err != nil {
+		apierror.Write(w, r, validationError(err))
+		return
+	}
+
+	resolved, err := store.Resolve(r.Context(), id, req, principalName(r))
+	if err == nil {
+		recordAudit(r, auditEntry(audit.ActionUpdate, "review_items", strconv.FormatInt(id, 10), item, resolved))
+	}
+	writeReviewItem(w, r, resolved, err)
+}
+
+// DismissReviewItem closes an open review item without a decision, so the
+// sync skips its record
+func DismissReviewItem(w http.ResponseWriter, r *http.Request) {
+	store := reviewStore(w, r)
+	if store == nil {
+		return
+	}
+	id, ok := reviewItemID(w, r)
+	if !ok {
+		return
+	}
+
+	var req DismissReviewItemRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	item, err := store.Get(r.Context(), id)
+	if err != nil {
+		writeReviewItem(w, r, item, err)
+		return
+	}
+
+	dismissed, err := store.Dismiss(r.Context(), id, req.Note, principalName(r))
+	if err == nil {
+		recordAudit(r, auditEntry(audit.ActionUpdate, "review_items", strconv.FormatInt(id, 10), item, dismissed))
+	}
+	writeReviewItem(w, r, dismissed, err)
+}
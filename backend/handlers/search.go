@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/search"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// ftsCandidateLimit caps the rows fetched per table by Postgres full-text search
+	ftsCandidateLimit = 100
+
+	// searchIndexTTL is how long the in-process index is reused before reloading
+	searchIndexTTL = 10 * time.Minute
+
+	// searchPageSize bounds the rows read per request, below PostgREST's
+	// max-rows, which would otherwise cut a whole-table read short
+	searchPageSize = 500
+)
+
+// SearchBackend selects "postgres" full-text search, falling back to the
+// in-process index on failure, or "memory" to always use the in-process index
+var SearchBackend = "postgres"
+
+// searchTypes are the document types accepted by the type filter
+var searchTypes = map[string]bool{
+	search.TypePerson:    true,
+	search.TypeMatter:    true,
+	search.TypeCommittee: true,
+	search.TypeMeeting:   true,
+}
+
+// SearchResponse is the JSON body returned by Search
+type SearchResponse struct {
+	search.Results
+	Backend string `json:"backend"` // "postgres" or "memory"
+}
+
+// narrowFunc restricts a table query to search candidates. A nil narrowFunc
+// loads the whole table.
+type narrowFunc func(table string, q *postgrest.FilterBuilder) *postgrest.FilterBuilder
+
+// memoryIndex caches the in-process index used when Postgres full-text search
+// is unavailable
+var memoryIndex struct {
+	sync.Mutex
+	index    *search.Index
+	builtAt  time.Time
+	building chan struct{} // closed when the running build finishes
+}
+
+// Search performs a ranked search across people, legislation, committees and meetings
+func Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	text := strings.TrimSpace(query.Get("q"))
+	if text == "" {
+		apierror.Write(w, r, apierror.BadRequest("Missing search query parameter 'q'"))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid limit"))
+			return
+		}
+		if n > maxSearchLimit {
+			n = maxSearchLimit
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			apierror.Write(w, r, apierror.BadRequest("Invalid offset"))
+			return
+		}
+		offset = n
+	}
+
+	var types []string
+	if v := query.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !searchTypes[t] {
+				apierror.Write(w, r, apierror.BadRequest(fmt.Sprintf("Invalid type %q", t)))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	q := search.Query{Text: text, Types: types, Limit: limit, Offset: offset}
+
+	var index *search.Index
+	backend := "memory"
+	if SearchBackend != "memory" {
+		docs, err := fullTextCandidates(r.Context(), text)
+		if err == nil {
+			index = search.NewIndex(docs)
+			backend = "postgres"
+		} else {
+			slog.WarnContext(r.Context(), "full-text search unavailable, using in-process index", "error", err)
+		}
+	}
+
+	if index == nil {
+		var err error
+		index, err = inProcessIndex(r.Context())
+		if err != nil {
+			apierror.Write(w, r, apierror.FromUpstream(err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SearchResponse{
+		Results: index.Search(q),
+		Backend: backend,
+	})
+}
+
+// fullTextCandidates uses the fts columns from db/schema_search.sql to fetch
+// matching rows, which are then ranked and highlighted in-process
+func fullTextCandidates(ctx context.Context, text string) ([]search.Document, error) {
+	narrow := func(table string, q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+		config := "english"
+		if table == "people" || table == "bodies" {
+			config = "simple"
+		}
+		return q.TextSearch("fts", text, config, "websearch").Limit(ftsCandidateLimit, "")
+	}
+
+	docs, err := loadSearchDocuments(ctx, narrow)
+	if err != nil {
+		return nil, err
+	}
+
+	// File numbers are tokenized unpredictably by to_tsvector, so look them up directly
+	if fileNumber, ok := search.NormalizeFileNumber(text); ok {
+		exact := func(table string, q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+			return q.Eq("matter_file", fileNumber)
+		}
+		matters, err := loadMatterDocuments(ctx, exact)
+		if err != nil {
+			return nil, err
+		}
+		docs = dedupeDocuments(append(docs, matters...))
+	}
+
+	return docs, nil
+}
+
+// inProcessIndex returns the cached index over all searchable rows, rebuilding it when stale.
+// One request builds the index at a time, without holding the lock; others
+// use the stale index meanwhile, or wait for the first one to be built.
+func inProcessIndex(ctx context.Context) (*search.Index, error) {
+	memoryIndex.Lock()
+	if memoryIndex.index != nil && time.Since(memoryIndex.builtAt) < searchIndexTTL {
+		defer memoryIndex.Unlock()
+		return memoryIndex.index, nil
+	}
+	if building := memoryIndex.building; building != nil {
+		stale := memoryIndex.index
+		memoryIndex.Unlock()
+		if stale != nil {
+			return stale, nil
+		}
+		select {
+		case <-building:
+			return inProcessIndex(ctx)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	memoryIndex.building = done
+	memoryIndex.Unlock()
+
+	var index *search.Index
+	docs, err := loadSearchDocuments(ctx, nil)
+	if err == nil {
+		index = search.NewIndex(docs)
+	}
+
+	memoryIndex.Lock()
+	defer memoryIndex.Unlock()
+	memoryIndex.building = nil
+	close(done)
+	if err != nil {
+		return nil, err
+	}
+	memoryIndex.index = index
+	memoryIndex.builtAt = time.Now()
+	slog.InfoContext(ctx, "search index built", "documents", index.Len())
+	return index, nil
+}
+
+// ResetSearchIndex discards the in-process index so the next search rebuilds it
+func ResetSearchIndex() {
+	memoryIndex.Lock()
+	memoryIndex.index = nil
+	memoryIndex.Unlock()
+}
+
+// loadSearchDocuments loads people, matters, committees and meetings as search documents
+func loadSearchDocuments(ctx context.Context, narrow narrowFunc) ([]search.Document, error) {
+	var docs []search.Document
+	for _, load := range []func(context.Context, narrowFunc) ([]search.Document, error){
+		loadPersonDocuments,
+		loadMatterDocuments,
+		loadCommitteeDocuments,
+		loadMeetingDocuments,
+	} {
+		batch, err := load(ctx, narrow)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, batch...)
+	}
+	return docs, nil
+}
+
+// dedupeDocuments drops documents returned by more than one lookup
+func dedupeDocuments(docs []search.Document) []search.Document {
+	seen := make(map[string]bool, len(docs))
+	unique := docs[:0]
+	for _, d := range docs {
+		key := d.Type + ":" + d.ID
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, d)
+		}
+	}
+	return unique
+}
+
+// selectFrom runs a select on table, narrowed to search candidates when narrow
+// is set, and decodes the rows into to. Rows are read a page at a time in
+// order of key, one of columns; a narrow that sets its own limit reads one
+// page of at most that many rows. A narrow must not filter on key, as the
+// filter would replace the page cursor.
+func selectFrom(ctx context.Context, table, columns, key string, narrow narrowFunc, to interface{}) error {
+	var all []map[string]json.RawMessage
+	var after string
+	for {
+		q := db.WithContext(ctx).From(table).
+			Select(columns, "", false).
+			Order(key, &postgrest.OrderOpts{Ascending: true}).
+			Limit(searchPageSize, "")
+		if after != "" {
+			q = q.Gt(key, after)
+		}
+		if narrow != nil {
+			q = narrow(table, q)
+		}
+		var rows []map[string]json.RawMessage
+		if _, err := q.ExecuteTo(&rows); err != nil {
+			return fmt.Errorf("failed to search %s: %w", table, err)
+		}
+		all = append(all, rows...)
+		if len(rows) < searchPageSize {
+			break
+		}
+		last := rows[len(rows)-1][key]
+		if err := json.Unmarshal(last, &after); err != nil {
+			// Numeric keys are compared as their JSON text
+			after = string(last)
+		}
+	}
+
+	raw, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, to)
+}
+
+func loadPersonDocuments(ctx context.Context, narrow narrowFunc) ([]search.Document, error) {
+	var people []struct {
+		ID        int    `json:"id"`
+		FullName  string `json:"full_name"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Email     string `json:"email"`
+	}
+	// Deleted people are not found
+	live := func(table string, q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+		q = q.Is("deleted_at", "null")
+		if narrow != nil {
+			q = narrow(table, q)
+		}
+		return q
+	}
+	if err := selectFrom(ctx, "people", "id, full_name, first_name, last_name, email", "id", live, &people); err != nil {
+		return nil, err
+	}
+
+	// Describe current officeholders by their position
+	positions := make(map[int]string)
+	for start := 0; start < len(people); start += searchPageSize {
+		end := start + searchPageSize
+		if end > len(people) {
+			end = len(people)
+		}
+		ids := make([]string, 0, end-start)
+		for _, p := range people[start:end] {
+			ids = append(ids, strconv.Itoa(p.ID))
+		}
+		// The chunk already filters on person_id, and a person may hold
+		// several offices, so its rows are paged by offset
+		for from := 0; ; from += searchPageSize {
+			var offices []struct {
+				PersonID     int    `json:"person_id"`
+				Title        string `json:"title"`
+				DistrictName string `json:"district_name"`
+			}
+			_, err := db.WithContext(ctx).From("current_officials").
+				Select("person_id, title, district_name", "", false).
+				In("person_id", ids).
+				Order("person_id", &postgrest.OrderOpts{Ascending: true}).
+				Order("position_id", &postgrest.OrderOpts{Ascending: true}).
+				Range(from, from+searchPageSize-1, "").
+				ExecuteTo(&offices)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search current_officials: %w", err)
+			}
+			for _, o := range offices {
+				positions[o.PersonID] = strings.TrimSpace(o.Title + ", " + o.DistrictName)
+			}
+			if len(offices) < searchPageSize {
+				break
+			}
+		}
+	}
+
+	docs := make([]search.Document, 0, len(people))
+	for _, p := range people {
+		name := search.ReverseName(p.FullName)
+		aliases := []string{p.LastName + ", " + p.FirstName}
+		if name != p.FullName {
+			aliases = append(aliases, p.FullName)
+		}
+		docs = append(docs, search.Document{
+			Type:    search.TypePerson,
+			ID:      strconv.Itoa(p.ID),
+			Title:   name,
+			Aliases: aliases,
+			Body:    strings.TrimSpace(positions[p.ID] + " " + p.Email),
+		})
+	}
+	return docs, nil
+}
+
+func loadMatterDocuments(ctx context.Context, narrow narrowFunc) ([]search.Document, error) {
+	var matters []struct {
+		MatterID   string `json:"matter_id"`
+		File       string `json:"matter_file"`
+		Name       string `json:"matter_name"`
+		Title      string `json:"matter_title"`
+		TypeName   string `json:"matter_type_name"`
+		StatusName string `json:"matter_status_name"`
+		IntroDate  string `json:"matter_intro_date"`
+		Text       string `json:"matter_text"`
+	}
+	columns := "matter_id, matter_file, matter_name, matter_title, matter_type_name, matter_status_name, matter_intro_date, matter_text"
+	if err := selectFrom(ctx, "matters", columns, "matter_id", narrow, &matters); err != nil {
+		return nil, err
+	}
+
+	docs := make([]search.Document, 0, len(matters))
+	for _, m := range matters {
+		title := m.Title
+		if title == "" {
+			title = m.Name
+		}
+		body := strings.Join(nonEmpty(m.Name, m.TypeName, m.StatusName, m.Text), " · ")
+		docs = append(docs, search.Document{
+			Type:    search.TypeMatter,
+			ID:      m.MatterID,
+			Title:   title,
+			Aliases: nonEmpty(m.File),
+			Keys:    nonEmpty(m.File),
+			Body:    body,
+			Date:    dateOnly(m.IntroDate),
+		})
+	}
+	return docs, nil
+}
+
+func loadCommitteeDocuments(ctx context.Context, narrow narrowFunc) ([]search.Document, error) {
+	var bodies []struct {
+		BodyID   int    `json:"body_id"`
+		Name     string `json:"body_name"`
+		TypeName string `json:"body_type_name"`
+	}
+	if err := selectFrom(ctx, "bodies", "body_id, body_name, body_type_name", "body_id", narrow, &bodies); err != nil {
+		return nil, err
+	}
+
+	docs := make([]search.Document, 0, len(bodies))
+	for _, b := range bodies {
+		docs = append(docs, search.Document{
+			Type:  search.TypeCommittee,
+			ID:    strconv.Itoa(b.BodyID),
+			Title: b.Name,
+			Body:  b.TypeName,
+		})
+	}
+	return docs, nil
+}
+
+func loadMeetingDocuments(ctx context.Context, narrow narrowFunc) ([]search.Document, error) {
+	var events []struct {
+		EventID  string `json:"event_id"`
+		BodyName string `json:"event_body_name"`
+		Date     string `json:"event_date"`
+		Time     string `json:"event_time"`
+		Location string `json:"event_location"`
+	}
+	if err := selectFrom(ctx, "events", "event_id, event_body_name, event_date, event_time, event_location", "event_id", narrow, &events); err != nil {
+		return nil, err
+	}
+
+	docs := make([]search.Document, 0, len(events))
+	for _, e := range events {
+		docs = append(docs, search.Document{
+			Type:  search.TypeMeeting,
+			ID:    e.EventID,
+			Title: e.BodyName,
+			Body:  strings.Join(nonEmpty(dateOnly(e.Date), e.Time, e.Location), " · "),
+			Date:  dateOnly(e.Date),
+		})
+	}
+	return docs, nil
+}
+
+// nonEmpty returns the non-blank values of vals
+func nonEmpty(vals ...string) []string {
+	var out []string
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// dateOnly trims a timestamp to its YYYY-MM-DD date
+func dateOnly(ts string) string {
+	if len(ts) >= 10 {
+		return ts[:10]
+	}
+	return ts
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/models"
+	"github.com/Jsanchez767/InfluencePower/backend/validate"
+	"github.com/gorilla/mux"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// TermRequest is the body of CreateTerm, and a term as UpdateTerm
+// validates it after applying the patch
+type TermRequest struct {
+	PersonID     int     `json:"person_id" validate:"required,min=1"`
+	StartDate    string  `json:"start_date" validate:"required,date"`
+	EndDate      *string `json:"end_date,omitempty" validate:"omitempty,date"` // omitted while the term is current
+	TermNumber   *int    `json:"term_number,omitempty" validate:"omitempty,min=1"`
+	ElectionType *string `json:"election_type,omitempty" validate:"omitempty,oneof=general|special|appointment"`
+}
+
+// TermPatch is the body of UpdateTerm, a JSON Merge Patch. A term stays
+// with its person and position; close it and create another to move it.
+type TermPatch struct {
+	StartDate    *string `json:"start_date,omitempty"`
+	EndDate      *string `json:"end_date,omitempty"` // null reopens the term
+	TermNumber   *int    `json:"term_number,omitempty"`
+	ElectionType *string `json:"election_type,omitempty"`
+}
+
+// CloseTermRequest is the body of CloseTerm
+type CloseTermRequest struct {
+	EndDate string `json:"end_date" validate:"required,date"`
+}
+
+// termRow is a new row of terms
+type termRow struct {
+	TermRequest
+	PositionID int `json:"position_id"`
+}
+
+// checkTermDates returns a validation error if a term ends before it starts
+func checkTermDates(start string, end *string) error {
+	if end != nil && *end < start {
+		return validationError(fieldError("end_date", "min", "must not be before start_date"))
+	}
+	return nil
+}
+
+// overlaps reports whether terms from startA to endA and from startB to endB
+// overlap; a nil end is still current. A term ending on the day another
+// starts does not overlap it, so one holder can hand over to the next on
+// inauguration day.
+func overlaps(startA string, endA *string, startB string, endB *string) bool {
+	return (endB == nil || startA < *endB) && (endA == nil || startB < *endA)
+}
+
+// selectTerms returns the live terms of position matched by filter, latest
+// first
+func selectTerms(r *http.Request, positionID int, filter func(*postgrest.FilterBuilder) *postgrest.FilterBuilder) ([]models.Term, error) {
+	q := db.WithContext(r.Context()).From("terms").
+		Select("*", "", false).
+		Eq("position_id", strconv.Itoa(positionID)).
+		Is("deleted_at", "null")
+	if filter != nil {
+		q = filter(q)
+	}
+	terms := []models.Term{}
+	_, err := q.Order("start_date", &postgrest.OrderOpts{Ascending: false}).ExecuteTo(&terms)
+	return terms, err
+}
+
+// positionTerms returns the live terms of a position, latest first
+func positionTerms(r *http.Request, positionID int) ([]models.Term, error) {
+	return selectTerms(r, positionID, nil)
+}
+
+// currentTerms returns the terms of a position that are current today
+func currentTerms(r *http.Request, positionID int) ([]models.Term, error) {
+	today := time.Now().Format(validate.DateLayout)
+	terms, err := selectTerms(r, positionID, func(q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+		return q.Lte("start_date", today)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var current []models.Term
+	for _, t := range terms {
+		if t.EndDate == nil || *t.EndDate > today {
+			current = append(current, t)
+		}
+	}
+	return current, nil
+}
+
+// termIDs returns the IDs of terms
+func termIDs(terms []models.Term) []int {
+	ids := make([]int, len(terms))
+	for i, t := range terms {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// checkSeats returns a 409 error if a term of position from start to end
+// would overlap as many of its other terms as it has seats, so that a
+// single-seat position has one holder at a time. except is the term being
+// changed, which is not counted.
+func checkSeats(r *http.Request, position models.Position, start string, end *string, except int) error {
+	terms, err := positionTerms(r, position.ID)
+	if err != nil {
+		return apierror.FromUpstream(err)
+	}
+	var overlapping []models.Term
+	for _, t := range terms {
+		if t.ID != except && overlaps(start, end, t.StartDate, t.EndDate) {
+			overlapping = append(overlapping, t)
+		}
+	}
+	seats := position.Seats
+	if seats < 1 {
+		seats = 1
+	}
+	if len(overlapping) < seats {
+		return nil
+	}
+	message := "The position is held by another term over these dates"
+	if seats > 1 {
+		message = fmt.Sprintf("All %d seats of the position are held by other terms over these dates", seats)
+	}
+	return apierror.Conflict(message).WithDetails(map[string]interface{}{"terms": termIDs(overlapping)})
+}
+
+// seatError maps err to an API error. The terms trigger in
+// db/schema_positions.sql raises exclusion_violation when another write took
+// the last free seat after checkSeats passed, which is a 409 like the check.
+func seatError(err error) *apierror.Error {
+	if apierror.UpstreamCode(err) == "23P01" {
+		return apierror.Conflict("The position has no seat free over these dates").Wrap(err)
+	}
+	return apierror.FromUpstream(err)
+}
+
+// loadTerm returns the live term with id, or nil if there is none
+func loadTerm(r *http.Request, id string) (*models.Term, map[string]interface{}, error) {
+	var term models.Term
+	row, err := loadLive(r, "terms", id, &term)
+	if err != nil || row == nil {
+		return nil, nil, err
+	}
+	return &term, row, nil
+}
+
+// GetTerm returns a term
+func GetTerm(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	term, _, err := loadTerm(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if term == nil {
+		apierror.Write(w, r, apierror.NotFound("Term not found"))
+		return
+	}
+	writeEntity(w, http.StatusOK, term)
+}
+
+// CreateTerm adds a term of a person on a position, as long as the
+// position has a seat free over its dates
+func CreateTerm(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	position, _, err := loadPosition(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if position == nil {
+		apierror.Write(w, r, apierror.NotFound("Position not found"))
+		return
+	}
+
+	var req TermRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := checkTermDates(req.StartDate, req.EndDate); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	exists, err := personExists(r, strconv.Itoa(req.PersonID))
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if !exists {
+		apierror.Write(w, r, validationError(fieldError("person_id", "exists", "is not a person")))
+		return
+	}
+	if err := checkSeats(r, *position, req.StartDate, req.EndDate, 0); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var rows []map[string]interface{}
+	_, err = db.WithContext(r.Context()).From("terms").
+		Insert(termRow{TermRequest: req, PositionID: position.ID}, false, "", "", "").
+		ExecuteTo(&rows)
+	if err != nil {
+		apierror.Write(w, r, seatError(err))
+		return
+	}
+	if len(rows) == 0 {
+		apierror.Write(w, r, apierror.Internal(errors.New("insert into terms returned no row")))
+		return
+	}
+	var term models.Term
+	if err := decodeRow(rows[0], &term); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	termID := rowID(rows[0])
+	recordAudit(r, auditEntry(audit.ActionCreate, "terms", termID, nil, rows[0]))
+	recordManualProvenance(r, "term", termID, fieldNames(req))
+	invalidateWritten(r, []string{db.EntityTerms})
+
+	writeEntity(w, http.StatusCreated, term)
+}
+
+// UpdateTerm applies a JSON Merge Patch to a term. Changed dates must leave
+// the position a seat free, as for a new term.
+func UpdateTerm(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	term, row, err := loadTerm(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if term == nil {
+		apierror.Write(w, r, apierror.NotFound("Term not found"))
+		return
+	}
+	if err := checkIfMatch(r, term); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var req TermRequest
+	changes, err := decodePatch(w, r, row, &TermPatch{}, &req)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if len(changes) == 0 {
+		writeEntity(w, http.StatusOK, term)
+		return
+	}
+	if err := checkTermDates(req.StartDate, req.EndDate); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	_, startChanged := changes["start_date"]
+	_, endChanged := changes["end_date"]
+	if startChanged || endChanged {
+		if err := checkTermSeats(r, *term, req.StartDate, req.EndDate); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+
+	updateTerm(w, r, id, row, changes)
+}
+
+// CloseTerm ends a current term on end_date
+func CloseTerm(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, mux.Vars(r))
+	if !ok {
+		return
+	}
+	var req CloseTermRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	term, row, err := loadTerm(r, id)
+	if err != nil {
+		apierror.Write(w, r, apierror.FromUpstream(err))
+		return
+	}
+	if term == nil {
+		apierror.Write(w, r, apierror.NotFound("Term not found"))
+		return
+	}
+	if err := checkIfMatch(r, term); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if term.EndDate != nil {
+		apierror.Write(w, r, apierror.Conflict("Term has already ended on "+*term.EndDate))
+		return
+	}
+	if err := checkTermDates(term.StartDate, &req.EndDate); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	end, _ := json.Marshal(req.EndDate)
+	updateTerm(w, r, id, row, map[string]json.RawMessage{"end_date": end})
+}
+
+// checkTermSeats checks that term, moved to the dates from start to end,
+// leaves its position a seat free
+func checkTermSeats(r *http.Request, term models.Term, start string, end *string) error {
+	position, _, err := loadPosition(r, strconv.Itoa(term.PositionID))
+	if err != nil {
+		return apierror.FromUpstream(err)
+	}
+	if position == nil {
+		return apierror.NotFound("Position not found")
+	}
+	return checkSeats(r, *position, start, end, term.ID)
+}
+
+// updateTerm writes changes to the term with id, read as row, and writes
+// the updated term
+func updateTerm(w http.ResponseWriter, r *http.Request, id string, row map[string]interface{}, changes map[string]json.RawMessage) {
+	updated, err := updateVersioned(r, "terms", id, row["updated_at"], changes)
+	if errors.Is(err, errChanged) {
+		apierror.Write(w, r, changedError(r))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, seatError(err))
+		return
+	}
+	recordAudit(r, auditEntry(audit.ActionUpdate, "terms", id, changedFields(row, changes), changedFields(updated, changes)))
+	recordManualProvenance(r, "term", id, sortedFields(changes))
+	invalidateWritten(r, []string{db.EntityTerms})
+
+	var term models.Term
+	if err := decodeRow(updated, &term); err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+	writeEntity(w, http.StatusOK, term)
+}
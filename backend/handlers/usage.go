@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Jsanchez767/InfluencePower/backend/ratelimit"
+)
+
+// GetUsage returns per-client request counts since the server started,
+// optionally filtered by a client prefix such as "ip:" or "key:"
+func GetUsage(w http.ResponseWriter, r *http.Request) {
+	usage := ratelimit.Default.Usage()
+
+	if prefix := r.URL.Query().Get("client"); prefix != "" {
+		filtered := usage[:0]
+		for _, u := range usage {
+			if strings.HasPrefix(u.Client, prefix) {
+				filtered = append(filtered, u)
+			}
+		}
+		usage = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/models"
+)
+
+// voteColumns are the columns of votes, with its matter, a VotingRecord is
+// read from
+const voteColumns = "id, person_id, matter_id, vote_value, vote_date, created_at, matters(matter_name, matter_title)"
+
+// voteRow is a row of votes. Its dates are TIMESTAMP columns, which
+// PostgREST renders without an offset.
+type voteRow struct {
+	ID        int         `json:"id"`
+	PersonID  int         `json:"person_id"`
+	MatterID  *string     `json:"matter_id"`
+	VoteValue string      `json:"vote_value"`
+	VoteDate  *string     `json:"vote_date"`
+	CreatedAt *string     `json:"created_at"`
+	Matter    *voteMatter `json:"matters"`
+}
+
+// voteMatter is the matter a vote is on, embedded in a voteRow
+type voteMatter struct {
+	MatterName  *string `json:"matter_name"`
+	MatterTitle *string `json:"matter_title"`
+}
+
+// votingRecord returns the VotingRecord the API serves for row. The bill is
+// the vote's matter, by name, or by ID if it has none.
+func (row voteRow) votingRecord() models.VotingRecord {
+	record := models.VotingRecord{
+		ID:         row.ID,
+		OfficialID: row.PersonID,
+		Vote:       row.VoteValue,
+		VoteDate:   parseTimestamp(row.VoteDate),
+		CreatedAt:  parseTimestamp(row.CreatedAt),
+	}
+	if row.MatterID != nil {
+		record.BillTitle = *row.MatterID
+	}
+	if m := row.Matter; m != nil {
+		if m.MatterName != nil && *m.MatterName != "" {
+			record.BillTitle = *m.MatterName
+		}
+		if m.MatterTitle != nil {
+			record.Description = *m.MatterTitle
+		}
+	}
+	return record
+}
+
+// newVoteRow is the votes row a VotingRecord is written as
+type newVoteRow struct {
+	PersonID  int       `json:"person_id"`
+	MatterID  string    `json:"matter_id"`
+	VoteValue string    `json:"vote_value"`
+	VoteDate  time.Time `json:"vote_date"`
+}
+
+// storedMatter is the matter a new vote is cast on
+type storedMatter struct {
+	MatterID string `json:"matter_id"`
+	voteMatter
+}
+
+// findMatter returns the stored matter whose ID or else name is bill, or nil
+// if there is none
+func findMatter(r *http.Request, bill string) (*storedMatter, error) {
+	for _, column := range []string{"matter_id", "matter_name"} {
+		rows, err := loadRows(r, "matters", column, bill)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) > 0 {
+			var m storedMatter
+			if err := decodeRow(rows[0], &m); err != nil {
+				return nil, err
+			}
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+// timestampLayout is how PostgREST renders a TIMESTAMP column
+const timestampLayout = "2006-01-02T15:04:05.999999"
+
+// parseTimestamp parses a TIMESTAMP or TIMESTAMPTZ column, reading one
+// without an offset as UTC. It returns the zero time if s is null or
+// malformed.
+func parseTimestamp(s *string) time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, *s); err == nil {
+		return t
+	}
+	t, _ := time.Parse(timestampLayout, *s)
+	return t
+}
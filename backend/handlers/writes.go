@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/cache"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/mergepatch"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+	"github.com/Jsanchez767/InfluencePower/backend/softdelete"
+	"github.com/Jsanchez767/InfluencePower/backend/validate"
+)
+
+// People, positions and terms are created with POST and changed with PATCH
+// and a JSON Merge Patch. Their responses carry the ETag GET sends; a write
+// with If-Match only applies if the row still has that representation, and
+// every update is guarded by the updated_at it was computed from.
+
+// normalizer is implemented by request bodies that fill in defaults.
+// decodeAndValidate and decodePatch call normalize after decoding a body and
+// before validating it.
+type normalizer interface {
+	normalize()
+}
+
+// errChanged is returned by updateVersioned when the row was changed since
+// it was read
+var errChanged = errors.New("row changed since it was read")
+
+// parseID returns the {id} path variable, or writes 400 if it is not a number
+func parseID(w http.ResponseWriter, r *http.Request, vars map[string]string) (string, bool) {
+	id := vars["id"]
+	if _, err := strconv.Atoi(id); err != nil {
+		apierror.Write(w, r, apierror.BadRequest("Invalid ID"))
+		return "", false
+	}
+	return id, true
+}
+
+// encodeJSON encodes v as handlers write response bodies
+func encodeJSON(v interface{}) []byte {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(v)
+	return buf.Bytes()
+}
+
+// writeEntity writes v with status and the ETag the cache gives the same
+// body on GET, which clients send back in If-Match
+func writeEntity(w http.ResponseWriter, status int, v interface{}) {
+	body := encodeJSON(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", cache.ETag(body))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// checkIfMatch returns a 412 error unless the If-Match header is absent, is
+// * or lists the ETag of current, the representation GET returns now
+func checkIfMatch(r *http.Request, current interface{}) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	etag := cache.ETag(encodeJSON(current))
+	for _, candidate := range strings.Split(header, ",") {
+		// If-Match uses strong comparison, so weak tags never match
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return nil
+		}
+	}
+	return apierror.PreconditionFailed("The resource changed since it was read; fetch it again and reapply the change")
+}
+
+// changedError is the error for an update that lost a race with another
+// write: 412 if the client named the version it read, 409 otherwise
+func changedError(r *http.Request) error {
+	if r.Header.Get("If-Match") != "" {
+		return apierror.PreconditionFailed("The resource changed since it was read; fetch it again and reapply the change")
+	}
+	return apierror.Conflict("The resource changed while it was being updated; try again")
+}
+
+// decodeRow decodes a row read from PostgREST into v
+func decodeRow(row map[string]interface{}, v interface{}) error {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// loadLive decodes the row of table with id into v and returns it, or nil
+// if there is no such row or it is deleted
+func loadLive(r *http.Request, table, id string, v interface{}) (map[string]interface{}, error) {
+	rows, err := loadRows(r, table, "id", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || rows[0]["deleted_at"] != nil {
+		return nil, nil
+	}
+	return rows[0], decodeRow(rows[0], v)
+}
+
+// decodePatch reads a JSON Merge Patch of the stored row current from the
+// request body. patch is a struct of the fields that may be patched, which
+// rejects others; the patched row is decoded into dst, a request body type,
+// and validated. It returns the fields whose value changed, as dst encodes
+// them, with null for a field that was cleared.
+func decodePatch(w http.ResponseWriter, r *http.Request, current map[string]interface{}, patch, dst interface{}) (map[string]json.RawMessage, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, decodeError(io.EOF)
+	}
+	if !json.Valid(body) {
+		return nil, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "Request body is not valid JSON")
+	}
+	if _, err := mergepatch.Fields(body); err != nil {
+		return nil, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "Request body must be a JSON object")
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(patch); err != nil {
+		return nil, decodeError(err)
+	}
+
+	// The patch applies to the fields of dst, as they are stored
+	if err := decodeRow(current, dst); err != nil {
+		return nil, apierror.Internal(err)
+	}
+	stored := encodeJSON(dst)
+	merged, err := mergepatch.Apply(stored, body)
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+	v := reflect.ValueOf(dst).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := json.Unmarshal(merged, dst); err != nil {
+		return nil, decodeError(err)
+	}
+	if n, ok := dst.(normalizer); ok {
+		n.normalize()
+	}
+
+	var before, after map[string]json.RawMessage
+	json.Unmarshal(stored, &before)
+	json.Unmarshal(encodeJSON(dst), &after)
+	changes := make(map[string]json.RawMessage)
+	for name, value := range after {
+		if !bytes.Equal(before[name], value) {
+			changes[name] = value
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes[name] = json.RawMessage("null")
+		}
+	}
+
+	// Stored values, such as synced ones, may not meet the rules of request
+	// bodies; only the fields the patch changes have to
+	if err := validate.Struct(dst); err != nil {
+		var fieldErrs validate.Errors
+		if !errors.As(err, &fieldErrs) {
+			return nil, apierror.Internal(err)
+		}
+		var changed validate.Errors
+		for _, fe := range fieldErrs {
+			if _, ok := changes[fe.Field]; ok {
+				changed = append(changed, fe)
+			}
+		}
+		if len(changed) > 0 {
+			return nil, validationError(changed)
+		}
+	}
+	return changes, nil
+}
+
+// updateVersioned writes changes to the row of table with id, provided it
+// still has the updated_at it was read with, and returns the updated row.
+// It returns errChanged if the row was changed or deleted in between.
+func updateVersioned(r *http.Request, table, id string, version interface{}, changes map[string]json.RawMessage) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(changes)+1)
+	for name, value := range changes {
+		values[name] = value
+	}
+	values["updated_at"] = time.Now().UTC()
+
+	q := db.WithContext(r.Context()).From(table).
+		Update(values, "", "").
+		Eq("id", id)
+	if v, ok := version.(string); ok {
+		q = q.Eq("updated_at", v)
+	} else {
+		q = q.Is("updated_at", "null")
+	}
+	if _, ok := softdelete.Lookup(table); ok {
+		q = q.Is("deleted_at", "null")
+	}
+	var rows []map[string]interface{}
+	if _, err := q.ExecuteTo(&rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errChanged
+	}
+	return rows[0], nil
+}
+
+// changedFields returns the values of the changed fields in row, for the
+// before and after sides of an audit entry
+func changedFields(row map[string]interface{}, changes map[string]json.RawMessage) map[string]interface{} {
+	values := make(map[string]interface{}, len(changes))
+	for name := range changes {
+		values[name] = row[name]
+	}
+	return values
+}
+
+// fieldNames returns the names of the fields v encodes, sorted
+func fieldNames(v interface{}) []string {
+	var fields map[string]json.RawMessage
+	json.Unmarshal(encodeJSON(v), &fields)
+	return sortedFields(fields)
+}
+
+// sortedFields returns the keys of fields, sorted
+func sortedFields(fields map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recordManualProvenance records fields of a person or term as written
+// through the API by the request. The row is written; a missing provenance
+// row does not undo that.
+func recordManualProvenance(r *http.Request, entity, id string, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	source := provenance.Source{
+		System:    provenance.SystemManual,
+		FetchedAt: time.Now().UTC(),
+		RunID:     middleware.RequestIDFromContext(r.Context()),
+	}
+	if err := provenance.Record(db.WithContext(r.Context()), provenance.Fields(entity, id, fields, source)); err != nil {
+		slog.WarnContext(r.Context(), "failed to record provenance of API write", "entity", entity, "id", id, "error", err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package health checks the database and the freshness of synced data for
+// the readiness endpoint.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+)
+
+// Status is the state of a component or of the whole service
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded" // serving, but data is stale or partly unavailable
+	StatusDown     Status = "down"     // cannot serve requests
+	StatusDraining Status = "draining" // shutting down
+)
+
+// DefaultFreshness is the maximum age of each entity's last sync before the
+// service reports itself degraded. Legislation and votes are synced daily,
+// people and committees weekly.
+var DefaultFreshness = map[string]time.Duration{
+	db.EntityVotes:   48 * time.Hour,
+	db.EntityMatters: 48 * time.Hour,
+	db.EntityEvents:  48 * time.Hour,
+	db.EntityMetrics: 48 * time.Hour,
+	db.EntityPeople:  7 * 24 * time.Hour,
+	db.EntityTerms:   7 * 24 * time.Hour,
+	db.EntityBodies:  7 * 24 * time.Hour,
+}
+
+// DefaultTimeout bounds each dependency check
+const DefaultTimeout = 3 * time.Second
+
+// Component is the result of one check
+type Component struct {
+	Name         string     `json:"name"`
+	Status       Status     `json:"status"`
+	Message      string     `json:"message,omitempty"`
+	LatencyMS    *int64     `json:"latency_ms,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	MaxAge       string     `json:"max_age,omitempty"`
+}
+
+// Report is the readiness response
+type Report struct {
+	Status     Status      `json:"status"`
+	CheckedAt  time.Time   `json:"checked_at"`
+	Components []Component `json:"components"`
+}
+
+// ParseFreshness parses thresholds like "votes=48h,people=168h" over
+// DefaultFreshness. A threshold of 0 stops checking that entity.
+func ParseFreshness(spec string) (map[string]time.Duration, error) {
+	freshness := make(map[string]time.Duration, len(DefaultFreshness))
+	for entity, maxAge := range DefaultFreshness {
+		freshness[entity] = maxAge
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		entity, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid freshness %q: expected entity=duration", part)
+		}
+		entity = strings.TrimSpace(entity)
+		if _, known := DefaultFreshness[entity]; !known {
+			return nil, fmt.Errorf("invalid freshness %q: unknown entity %q", part, entity)
+		}
+		maxAge, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || maxAge < 0 {
+			return nil, fmt.Errorf("invalid freshness %q: expected a duration such as 48h", part)
+		}
+		freshness[entity] = maxAge
+	}
+	return freshness, nil
+}
+
+// Config configures a Checker
+type Config struct {
+	Freshness map[string]time.Duration // DefaultFreshness if nil
+	Timeout   time.Duration            // DefaultTimeout if zero
+}
+
+// Checker runs the readiness checks
+type Checker struct {
+	freshness  map[string]time.Duration
+	timeout    time.Duration
+	ping       func() error
+	syncStates func() ([]db.SyncState, error)
+	now        func() time.Time
+}
+
+// New creates a checker using ping to reach the database and syncStates to
+// read the last sync time of each entity
+func New(cfg Config, ping func() error, syncStates func() ([]db.SyncState, error)) *Checker {
+	c := &Checker{
+		freshness:  cfg.Freshness,
+		timeout:    cfg.Timeout,
+		ping:       ping,
+		syncStates: syncStates,
+		now:        time.Now,
+	}
+	if c.freshness == nil {
+		c.freshness = DefaultFreshness
+	}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	return c
+}
+
+// Default checks the database behind db.Client
+var Default = newDefault(Config{})
+
+func newDefault(cfg Config) *Checker {
+	return New(cfg,
+		func() error { return db.Ping(db.Client) },
+		func() ([]db.SyncState, error) { return db.SyncStates(db.Client) })
+}
+
+// Init replaces the default checker
+func Init(cfg Config) {
+	Default = newDefault(cfg)
+}
+
+// Check runs every check. The service is down when the database is
+// unreachable and degraded when any entity is older than its threshold.
+func (c *Checker) Check() Report {
+	report := Report{Status: StatusOK, CheckedAt: c.now().UTC()}
+
+	database := Component{Name: "database", Status: StatusOK}
+	start := c.now()
+	err := c.withTimeout(c.ping)
+	latency := c.now().Sub(start).Milliseconds()
+	database.LatencyMS = &latency
+	if err != nil {
+		database.Status = StatusDown
+		database.Message = err.Error()
+	}
+	report.Components = append(report.Components, database)
+	if err != nil {
+		report.Status = StatusDown
+		return report
+	}
+
+	var states []db.SyncState
+	err = c.withTimeout(func() (err error) {
+		states, err = c.syncStates()
+		return err
+	})
+	if err != nil {
+		report.Components = append(report.Components, Component{Name: "sync_state", Status: StatusDegraded, Message: err.Error()})
+		report.Status = StatusDegraded
+		return report
+	}
+
+	synced := make(map[string]time.Time, len(states))
+	for _, s := range states {
+		// A row recorded only by API writes is not a sync
+		if !s.LastSyncedAt.IsZero() {
+			synced[s.Entity] = s.LastSyncedAt
+		}
+	}
+
+	entities := make([]string, 0, len(c.freshness))
+	for entity, maxAge := range c.freshness {
+		if maxAge > 0 {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Strings(entities)
+
+	for _, entity := range entities {
+		maxAge := c.freshness[entity]
+		comp := Component{Name: "sync:" + entity, Status: StatusOK, MaxAge: maxAge.String()}
+		if at, ok := synced[entity]; !ok {
+			comp.Status = StatusDegraded
+			comp.Message = "never synced"
+		} else {
+			at := at
+			comp.LastSyncedAt = &at
+			if age := c.now().Sub(at); age > maxAge {
+				comp.Status = StatusDegraded
+				comp.Message = fmt.Sprintf("last synced %s ago", age.Truncate(time.Minute))
+			}
+		}
+		if comp.Status == StatusDegraded {
+			report.Status = StatusDegraded
+		}
+		report.Components = append(report.Components, comp)
+	}
+	return report
+}
+
+// withTimeout runs fn, giving up after the configured timeout. The PostgREST
+// client has no context support, so a timed-out call keeps running in the
+// background.
+func (c *Checker) withTimeout(fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(c.timeout):
+		return errors.New("timed out after " + c.timeout.String())
+	}
+}
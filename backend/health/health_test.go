@@ -0,0 +1,117 @@
+package health
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+)
+
+var now = time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+func newChecker(pingErr error, states []db.SyncState) *Checker {
+	c := New(Config{Freshness: map[string]time.Duration{
+		db.EntityVotes:  48 * time.Hour,
+		db.EntityPeople: 7 * 24 * time.Hour,
+		db.EntityTerms:  0,
+	}},
+		func() error { return pingErr },
+		func() ([]db.SyncState, error) { return states, nil })
+	c.now = func() time.Time { return now }
+	return c
+}
+
+func component(r Report, name string) Component {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c
+		}
+	}
+	return Component{}
+}
+
+func TestCheckOK(t *testing.T) {
+	report := newChecker(nil, []db.SyncState{
+		{Entity: db.EntityVotes, LastSyncedAt: now.Add(-6 * time.Hour)},
+		{Entity: db.EntityPeople, LastSyncedAt: now.Add(-72 * time.Hour)},
+	}).Check()
+
+	if report.Status != StatusOK {
+		t.Errorf("expected ok, got %+v", report)
+	}
+	if len(report.Components) != 3 {
+		t.Errorf("expected database and two entities (terms disabled), got %+v", report.Components)
+	}
+	votes := component(report, "sync:votes")
+	if votes.LastSyncedAt == nil || !votes.LastSyncedAt.Equal(now.Add(-6*time.Hour)) || votes.MaxAge != "48h0m0s" {
+		t.Errorf("unexpected votes component: %+v", votes)
+	}
+}
+
+func TestCheckDegradedWhenStale(t *testing.T) {
+	report := newChecker(nil, []db.SyncState{
+		{Entity: db.EntityVotes, LastSyncedAt: now.Add(-50 * time.Hour)},
+	}).Check()
+
+	if report.Status != StatusDegraded {
+		t.Fatalf("expected degraded, got %s", report.Status)
+	}
+	if votes := component(report, "sync:votes"); votes.Status != StatusDegraded || !strings.Contains(votes.Message, "50h") {
+		t.Errorf("unexpected votes component: %+v", votes)
+	}
+	if people := component(report, "sync:people"); people.Status != StatusDegraded || people.Message != "never synced" {
+		t.Errorf("unexpected people component: %+v", people)
+	}
+	if database := component(report, "database"); database.Status != StatusOK {
+		t.Errorf("unexpected database component: %+v", database)
+	}
+}
+
+func TestCheckWrittenIsNotSynced(t *testing.T) {
+	report := newChecker(nil, []db.SyncState{
+		{Entity: db.EntityVotes, LastSyncedAt: now.Add(-6 * time.Hour)},
+		{Entity: db.EntityPeople, WrittenAt: now.Add(-time.Minute)},
+	}).Check()
+
+	if people := component(report, "sync:people"); people.Status != StatusDegraded || people.Message != "never synced" {
+		t.Errorf("expected people written by the API but never synced, got %+v", people)
+	}
+}
+
+func TestCheckDownWhenDatabaseUnreachable(t *testing.T) {
+	report := newChecker(errors.New("connection refused"), nil).Check()
+	if report.Status != StatusDown || component(report, "database").Status != StatusDown {
+		t.Errorf("expected down, got %+v", report)
+	}
+}
+
+func TestCheckTimesOut(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	c := New(Config{Timeout: 10 * time.Millisecond},
+		func() error { <-block; return nil },
+		func() ([]db.SyncState, error) { return nil, nil })
+
+	report := c.Check()
+	if report.Status != StatusDown || !strings.Contains(report.Components[0].Message, "timed out") {
+		t.Errorf("expected timeout, got %+v", report)
+	}
+}
+
+func TestParseFreshness(t *testing.T) {
+	freshness, err := ParseFreshness("votes=24h, people=0s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freshness[db.EntityVotes] != 24*time.Hour || freshness[db.EntityPeople] != 0 || freshness[db.EntityMatters] != 48*time.Hour {
+		t.Errorf("unexpected freshness: %v", freshness)
+	}
+
+	for _, bad := range []string{"votes", "votes=soon", "ballots=24h", "votes=-1h"} {
+		if _, err := ParseFreshness(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
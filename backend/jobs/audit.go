@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+)
+
+// defaultActor names who applies a plan in the audit log when
+// Options.Actor is not set
+const defaultActor = "sync"
+
+// auditEntry returns the audit log entry of a change written by w: the
+// fields it changed before and after, with refs resolved to the ids they
+// were given
+func auditEntry(w *writer, c Change, actor, runID string) audit.Entry {
+	var before, after map[string]interface{}
+	if c.Op != OpCreate {
+		before = make(map[string]interface{}, len(c.Fields))
+	}
+	if c.Op != OpDelete {
+		after = make(map[string]interface{}, len(c.Fields)+len(c.Refs))
+	}
+	for _, f := range c.Fields {
+		if before != nil {
+			before[f.Field] = f.Old
+		}
+		if after != nil {
+			after[f.Field] = f.New
+		}
+	}
+	for field, ref := range c.Refs {
+		if id, ok := w.created[ref]; ok && after != nil {
+			after[field] = id
+		}
+	}
+	// A nil map encodes as null, which New leaves out
+	return audit.New(actor, c.Op, c.Table, w.rowKey(c), before, after, runID)
+}
@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+)
+
+// headshotPerson is a person whose headshot is refreshed
+type headshotPerson struct {
+	ID                  int                    `json:"id"`
+	FullName            string                 `json:"full_name"`
+	ImageURL            *string                `json:"image_url"`
+	HeadshotLastUpdated *string                `json:"headshot_last_updated"`
+	ExternalIDs         map[string]interface{} `json:"external_ids"`
+}
+
+// refreshHeadshots points the image_url of each current official at their
+// Legistar profile, matching people on legistar_id or else on name
+func planHeadshots(ctx context.Context, env *Env, plan *Plan) error {
+	officials, err := loadOfficials(env)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(officials))
+	for i, o := range officials {
+		ids[i] = strconv.Itoa(o.PersonID)
+	}
+
+	var people []headshotPerson
+	if len(ids) > 0 {
+		_, err = env.DB.From("people").
+			Select("id, full_name, image_url, headshot_last_updated, external_ids", "", false).
+			In("id", ids).
+			ExecuteTo(&people)
+		if err != nil {
+			return fmt.Errorf("failed to load people: %w", err)
+		}
+	}
+
+	persons, err := env.Legistar.GetPersons()
+	if err != nil {
+		return fmt.Errorf("failed to fetch persons: %w", err)
+	}
+	slog.InfoContext(ctx, "fetched persons", "count", len(persons), "officials", len(people))
+	fetchedAt := time.Now().UTC()
+
+	plan.fetched(db.EntityPeople, len(people))
+
+	for _, p := range people {
+		match, ok := matchPerson(p, persons)
+		if !ok {
+			slog.WarnContext(logging.With(ctx, "person_id", p.ID, "full_name", p.FullName), "no match found in Legistar")
+			continue
+		}
+
+		// Other external IDs are kept
+		externalIDs := map[string]interface{}{}
+		for k, v := range p.ExternalIDs {
+			externalIDs[k] = v
+		}
+		externalIDs["legistar_id"] = match.PersonID
+		externalIDs["legistar_guid"] = match.PersonGUID
+
+		current := map[string]interface{}{
+			"image_url":             p.ImageURL,
+			"headshot_last_updated": p.HeadshotLastUpdated,
+			"external_ids":          p.ExternalIDs,
+		}
+		update := map[string]interface{}{
+			"image_url":             legistarImageURL(match.PersonID),
+			"headshot_last_updated": time.Now().Format(time.RFC3339),
+			"external_ids":          externalIDs,
+		}
+		plan.diff(target{
+			entity:   db.EntityPeople,
+			table:    "people",
+			key:      "id",
+			value:    strconv.Itoa(p.ID),
+			label:    p.FullName,
+			volatile: []string{"headshot_last_updated"},
+			source:   legistarSource(fmt.Sprintf("/persons/%d", match.PersonID), fetchedAt),
+		}, current, update)
+	}
+	return nil
+}
+
+// matchPerson finds p among Legistar persons by legistar_id, then by full
+// name as "First Last" or "Last, First"
+func matchPerson(p headshotPerson, persons []cityapi.Person) (cityapi.Person, bool) {
+	if id, ok := p.ExternalIDs["legistar_id"].(float64); ok {
+		for _, person := range persons {
+			if person.PersonID == int(id) {
+				return person, true
+			}
+		}
+	}
+
+	names := []string{p.FullName}
+	if parts := strings.Fields(p.FullName); len(parts) >= 2 {
+		last := parts[len(parts)-1]
+		names = append(names, last+", "+strings.Join(parts[:len(parts)-1], " "))
+	}
+	for _, name := range names {
+		for _, person := range persons {
+			if strings.EqualFold(person.PersonFullName, name) {
+				return person, true
+			}
+		}
+	}
+	return cityapi.Person{}, false
+}
@@ -0,0 +1,301 @@
+// Package jobs implements the sync and maintenance jobs run by the
+// influencepower CLI.
+//
+// Each job reads from Legistar and the database and adds the writes it would
+// make to a Plan, without writing anything. Apply executes a plan, either
+// right away or after it was reviewed on a dry run, and records the records
+// each entity handled in sync_state so API servers drop stale caches and
+// export the counts.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Sources of people and terms
+const (
+	SourceLegistar = "legistar" // office records from the Legistar web API
+	SourceELMS     = "elms"     // the City Clerk's ELMS person export (CSV)
+)
+
+// Jurisdiction is the jurisdiction a job syncs, from the jurisdictions table
+type Jurisdiction struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	APIBaseURL string `json:"api_base_url"`
+}
+
+// Slug returns the jurisdiction's Legistar client name, e.g. "chicago"
+func (j Jurisdiction) Slug() string {
+	if u, err := url.Parse(j.APIBaseURL); err == nil && j.APIBaseURL != "" {
+		return path.Base(u.Path)
+	}
+	return strings.ToLower(strings.ReplaceAll(j.Name, " ", ""))
+}
+
+// LoadJurisdiction looks up a city jurisdiction by name, ignoring case
+func LoadJurisdiction(client *postgrest.Client, name string) (Jurisdiction, error) {
+	var rows []Jurisdiction
+	_, err := client.From("jurisdictions").
+		Select("id, name, api_base_url", "", false).
+		Ilike("name", name).
+		Eq("jurisdiction_type", "city").
+		ExecuteTo(&rows)
+	if err != nil {
+		return Jurisdiction{}, fmt.Errorf("failed to load jurisdiction %q: %w", name, err)
+	}
+	if len(rows) == 0 {
+		return Jurisdiction{}, fmt.Errorf("jurisdiction %q not found; run the schema migration first", name)
+	}
+	return rows[0], nil
+}
+
+// Options control how a job plans
+type Options struct {
+	Limit  int    // most recent matters, events or matters' votes to sync; 0 uses the job's default
+	Source string // where people and terms come from: legistar (default) or elms
+
+	// Queue holds the units of work jobs split their fetching into; an
+	// in-memory queue is used if nil. QueueConfig.Workers units are fetched
+	// at once.
+	Queue       queue.Store
+	QueueConfig queue.Config
+
+	// RequestRate caps Legistar requests per second across all workers;
+	// 0 leaves the client's default
+	RequestRate int
+
+	// BatchSize is the most rows Apply upserts in one request;
+	// DefaultBatchSize if zero
+	BatchSize int
+
+	// Actor names who applies plans in the audit log, e.g. cli:jane;
+	// "sync" if empty
+	Actor string
+
+	// Retention is how long the purge job keeps soft-deleted rows;
+	// DefaultRetention if zero
+	Retention time.Duration
+}
+
+// Env is what a planning job reads from
+type Env struct {
+	DB           *postgrest.Client
+	Legistar     *cityapi.Client
+	Jurisdiction Jurisdiction
+	Options
+}
+
+// NewEnv loads the jurisdiction named name and builds the clients a job
+// uses, with the Legistar client pointed at the jurisdiction's api_base_url
+func NewEnv(ctx context.Context, name string, opts Options) (*Env, error) {
+	jurisdiction, err := LoadJurisdiction(db.WithContext(ctx), name)
+	if err != nil {
+		return nil, err
+	}
+
+	legistar := cityapi.NewClient()
+	if jurisdiction.APIBaseURL != "" {
+		legistar.BaseURL = jurisdiction.APIBaseURL
+	}
+	if opts.RequestRate > 0 {
+		legistar.Limiter = cityapi.NewRateLimiter(opts.RequestRate)
+	}
+	return &Env{
+		DB:           db.WithContext(ctx),
+		Legistar:     legistar,
+		Jurisdiction: jurisdiction,
+		Options:      opts,
+	}, nil
+}
+
+// withContext returns a copy of env whose clients are bound to ctx
+func (e *Env) withContext(ctx context.Context) *Env {
+	e2 := *e
+	e2.DB = db.WithContext(ctx)
+	e2.Legistar = e.Legistar.WithContext(ctx)
+	return &e2
+}
+
+// limit returns the configured limit or def
+func (e *Env) limit(def int) int {
+	if e.Limit > 0 {
+		return e.Limit
+	}
+	return def
+}
+
+// Counts are the records a job handled, per sync_state entity
+type Counts map[string]*db.SyncCounts
+
+// entity returns the counts for entity, creating them
+func (c Counts) entity(entity string) *db.SyncCounts {
+	if c[entity] == nil {
+		c[entity] = &db.SyncCounts{}
+	}
+	return c[entity]
+}
+
+// Values returns the counts by value, as db.RecordSyncCounts takes them
+func (c Counts) Values() map[string]db.SyncCounts {
+	values := make(map[string]db.SyncCounts, len(c))
+	for entity, counts := range c {
+		values[entity] = *counts
+	}
+	return values
+}
+
+// Failed returns the number of records that could not be written
+func (c Counts) Failed() int {
+	failed := 0
+	for _, counts := range c {
+		failed += counts.Failed
+	}
+	return failed
+}
+
+// Job is a named unit of work. Plan adds the writes the job would make to
+// plan; earlier jobs' changes are already in it.
+type Job struct {
+	Name string
+	Plan func(ctx context.Context, env *Env, plan *Plan) error
+}
+
+// syncJobs are run by "influencepower sync", in dependency order
+var syncJobs = []Job{
+	{Name: "bodies", Plan: planBodies},
+	{Name: "people", Plan: planPeople},
+	{Name: "terms", Plan: planTerms},
+	{Name: "matters", Plan: planMatters},
+	{Name: "votes", Plan: planVotes},
+	{Name: "events", Plan: planEvents},
+}
+
+// SyncJobs returns the sync jobs in the order they should run
+func SyncJobs() []Job {
+	return append([]Job(nil), syncJobs...)
+}
+
+// SyncJob returns the sync job with the given name
+func SyncJob(name string) (Job, bool) {
+	for _, job := range syncJobs {
+		if job.Name == name {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+// Maintenance jobs
+var (
+	ComputeMetrics   = Job{Name: "metrics", Plan: planMetrics}
+	RefreshHeadshots = Job{Name: "headshots", Plan: planHeadshots}
+	PurgeDeleted     = Job{Name: "purge", Plan: planPurge}
+)
+
+// Named returns the jobs run under name: "sync" for every sync job, a sync
+// job's entity, "metrics", "headshots" or "purge"
+func Named(name string) ([]Job, bool) {
+	switch name {
+	case "sync":
+		return SyncJobs(), true
+	case ComputeMetrics.Name:
+		return []Job{ComputeMetrics}, true
+	case RefreshHeadshots.Name:
+		return []Job{RefreshHeadshots}, true
+	case PurgeDeleted.Name:
+		return []Job{PurgeDeleted}, true
+	}
+	if job, ok := SyncJob(name); ok {
+		return []Job{job}, true
+	}
+	return nil, false
+}
+
+// Known reports whether Named knows name
+func Known(name string) bool {
+	_, ok := Named(name)
+	return ok
+}
+
+// Execute plans the selected jobs and applies the plan, carrying on after a
+// job fails so independent entities still sync. It returns the first error.
+func Execute(ctx context.Context, env *Env, selected []Job) (Counts, error) {
+	plan := NewPlan(env.Jurisdiction, env.Source)
+	var firstErr error
+	for _, job := range selected {
+		if err := ctx.Err(); err != nil {
+			return Counts{}, err
+		}
+		if err := Run(ctx, env, job, plan); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	counts, err := Apply(ctx, env, plan)
+	if firstErr == nil {
+		firstErr = err
+	}
+	return counts, firstErr
+}
+
+// Run adds job's changes to plan in its own span. A job that fails leaves
+// the plan as it was.
+func Run(ctx context.Context, env *Env, job Job, plan *Plan) error {
+	ctx = logging.With(ctx, "job", job.Name)
+	ctx, span := tracing.Start(ctx, "job "+job.Name)
+	env = env.withContext(ctx)
+
+	slog.InfoContext(ctx, "job started")
+	if plan.overrides == nil {
+		pins, err := loadOverrides(env)
+		if err != nil {
+			tracing.End(span, err)
+			slog.ErrorContext(ctx, "job failed", "error", err)
+			return err
+		}
+		plan.overrides = pins
+	}
+	mark := plan.mark()
+	err := job.Plan(ctx, env, plan)
+	tracing.End(span, err)
+
+	if err != nil {
+		plan.rollback(mark)
+		slog.ErrorContext(ctx, "job failed", "error", err)
+		return err
+	}
+	plan.Jobs = append(plan.Jobs, job.Name)
+	creates, updates, deletes := plan.since(mark).Summary()
+	slog.InfoContext(ctx, "job planned", "creates", creates, "updates", updates, "deletes", deletes)
+	return nil
+}
+
+// summary returns log attributes for counts, one group per entity
+func summary(counts Counts) []any {
+	entities := make([]string, 0, len(counts))
+	for entity := range counts {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+
+	attrs := make([]any, 0, len(entities))
+	for _, entity := range entities {
+		c := counts[entity]
+		attrs = append(attrs, slog.Group(entity, "fetched", c.Fetched, "upserted", c.Upserted, "failed", c.Failed))
+	}
+	return attrs
+}
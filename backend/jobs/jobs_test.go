@@ -0,0 +1,665 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+// legistarFixtures are served for Legistar paths under /legistar
+var legistarFixtures = map[string]string{
+	"bodies":     `[{"BodyId": 1, "BodyName": "City Council"}, {"BodyId": 2, "BodyName": "Committee on Finance"}]`,
+	"matters":    `[{"MatterId": 10, "MatterTitle": "An ordinance"}]`,
+	"votes":      `[{"VoteId": 100, "VoteMatterId": 10, "VotePersonName": "Jane Doe", "VoteValue": "Yea"}]`,
+	"events":     `[{"EventId": 20}]`,
+	"eventitems": `[{"EventItemId": 200, "EventItemMatterId": 10}]`,
+	"persons":    `[{"PersonId": 162, "PersonGuid": "abc", "PersonFullName": "Jane Doe"}]`,
+	"officerecords": `[{"OfficeRecordId": 300, "OfficeRecordPersonId": 162, "OfficeRecordFullName": "Jane Doe",
+		"OfficeRecordTitle": "Alderperson", "OfficeRecordBodyName": "City Council",
+		"OfficeRecordEmail": "Ward01@cityofchicago.org", "OfficeRecordStartDate": "2023-05-15T00:00:00"}]`,
+}
+
+// dbFixtures are served for PostgREST reads by table
+var dbFixtures = map[string]string{
+	"matters":   `[{"matter_id": "10"}]`,
+	"positions": `[{"id": 7}]`,
+}
+
+// fakeBackends serves Legistar and PostgREST and records every write, and
+// its query string, by table. Reads return rows by table, dbFixtures unless
+// a test changes them. Writes to tables in failing return 409.
+type fakeBackends struct {
+	mu      sync.Mutex
+	rows    map[string]string
+	writes  map[string][]string
+	queries map[string][]string
+	failing map[string]bool
+}
+
+func newFakeBackends(t *testing.T) (*fakeBackends, *Env) {
+	f := &fakeBackends{rows: map[string]string{}, writes: map[string][]string{}, queries: map[string][]string{}, failing: map[string]bool{}}
+	for table, rows := range dbFixtures {
+		f.rows[table] = rows
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		name := path.Base(r.URL.Path)
+
+		if strings.HasPrefix(r.URL.Path, "/legistar/") {
+			w.Write([]byte(legistarFixtures[name]))
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			body, _ := io.ReadAll(r.Body)
+			f.mu.Lock()
+			f.writes[name] = append(f.writes[name], string(body))
+			f.queries[name] = append(f.queries[name], r.URL.RawQuery)
+			f.mu.Unlock()
+			if f.failing[name] {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"code": "23505", "message": "duplicate key"}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`[{"id": 1}]`))
+			return
+		}
+
+		f.mu.Lock()
+		rows, ok := f.rows[name]
+		f.mu.Unlock()
+		if !ok {
+			rows = "[]"
+		}
+		w.Write([]byte(filterRows(rows, r.URL.Query())))
+	}))
+	t.Cleanup(srv.Close)
+
+	db.InitSupabase(srv.URL, "test")
+	legistar := cityapi.NewClient()
+	legistar.BaseURL = srv.URL + "/legistar"
+	return f, &Env{
+		DB:           db.Client,
+		Legistar:     legistar,
+		Jurisdiction: Jurisdiction{ID: 1, Name: "Chicago", APIBaseURL: legistar.BaseURL},
+	}
+}
+
+// filterRows keeps the rows matching eq. and in. filters of query, so that
+// tests can serve the rows of several people in one table
+func filterRows(rows string, query url.Values) string {
+	var all []map[string]interface{}
+	if json.Unmarshal([]byte(rows), &all) != nil {
+		return rows
+	}
+	kept := []map[string]interface{}{}
+	for _, row := range all {
+		match := true
+		for column, filters := range query {
+			for _, filter := range filters {
+				var values []string
+				switch {
+				case strings.HasPrefix(filter, "eq."):
+					values = []string{strings.TrimPrefix(filter, "eq.")}
+				case strings.HasPrefix(filter, "in.("):
+					values = strings.Split(strings.TrimSuffix(strings.TrimPrefix(filter, "in.("), ")"), ",")
+				default:
+					continue
+				}
+				if v, ok := row[column]; ok && !contains(values, keyString(v)) {
+					match = false
+				}
+			}
+		}
+		if match {
+			kept = append(kept, row)
+		}
+	}
+	b, _ := json.Marshal(kept)
+	return string(b)
+}
+
+// planJobs plans the named sync jobs into a new plan
+func planJobs(t *testing.T, env *Env, names ...string) *Plan {
+	t.Helper()
+	plan := NewPlan(env.Jurisdiction, SourceLegistar)
+	for _, name := range names {
+		job, _ := SyncJob(name)
+		if err := Run(context.Background(), env, job, plan); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	return plan
+}
+
+func TestPlanWritesNothing(t *testing.T) {
+	f, env := newFakeBackends(t)
+
+	plan := planJobs(t, env, "bodies", "people", "terms", "matters", "votes", "events")
+	if len(f.writes) != 0 {
+		t.Errorf("planning wrote to %v", f.writes)
+	}
+
+	// Matter 10 is stored, with only its matter_id
+	creates, updates, deletes := plan.Summary()
+	if creates != 7 || updates != 1 || deletes != 0 {
+		t.Errorf("expected 7 creates and 1 update, got %d creates, %d updates, %d deletes", creates, updates, deletes)
+	}
+	for _, entity := range []string{db.EntityBodies, db.EntityPeople, db.EntityTerms, db.EntityMatters, db.EntityVotes, db.EntityEvents} {
+		if plan.Fetched[entity] == 0 {
+			t.Errorf("expected %s fetched, got %v", entity, plan.Fetched)
+		}
+	}
+}
+
+func TestPlanDiffsStoredRows(t *testing.T) {
+	f, env := newFakeBackends(t)
+	f.rows["bodies"] = `[{"body_id": 1, "body_name": "City Council", "body_type_id": 0, "body_type_name": "", "body_meet_flag": 0},
+		{"body_id": 2, "body_name": "Finance", "body_type_id": 0, "body_type_name": "", "body_meet_flag": 0}]`
+	f.rows["event_items"] = `[{"event_item_id": "200", "event_id": "20", "matter_id": "10", "item_agenda_sequence": 0,
+		"item_agenda_number": "", "item_action": "", "item_action_text": ""},
+		{"event_item_id": "201", "event_id": "20", "matter_id": "10"}]`
+
+	plan := planJobs(t, env, "bodies", "events")
+	var ids []string
+	for _, c := range plan.Changes {
+		ids = append(ids, c.Op+" "+c.ID)
+	}
+	want := []string{"update bodies/body_id=2", "create events/event_id=20", "delete event_items/event_item_id=201"}
+	if strings.Join(ids, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("expected changes %v, got %v", want, ids)
+	}
+	if fields := plan.Changes[0].Fields; len(fields) != 1 || fields[0].Field != "body_name" || fields[0].Old != "Finance" {
+		t.Errorf("unexpected fields %+v", fields)
+	}
+
+	var text strings.Builder
+	plan.WriteText(&text)
+	if !strings.Contains(text.String(), `body_name: "Finance" -> "Committee on Finance"`) {
+		t.Errorf("expected field diff in text plan, got %q", text.String())
+	}
+}
+
+func TestApplyResolvesRefs(t *testing.T) {
+	f, env := newFakeBackends(t)
+
+	plan := planJobs(t, env, "people", "terms")
+	if len(plan.Changes) != 2 || plan.Changes[1].Refs["person_id"] != "people/legistar_id=162" {
+		t.Fatalf("expected the term to refer to the new person, got %+v", plan.Changes)
+	}
+
+	counts, err := Apply(context.Background(), env, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Failed() != 0 || counts[db.EntityTerms].Upserted != 1 {
+		t.Errorf("unexpected counts %+v", *counts[db.EntityTerms])
+	}
+	if w := f.writes["terms"]; len(w) != 1 || !strings.Contains(w[0], `"person_id":1`) {
+		t.Errorf("expected the term written with the new person's id, got %v", w)
+	}
+}
+
+func TestApplyAppendsAuditLog(t *testing.T) {
+	f, env := newFakeBackends(t)
+	env.Actor = "cli:jane"
+
+	plan := planJobs(t, env, "people", "terms")
+	ctx := middleware.WithRequestID(context.Background(), "run-2")
+	if _, err := Apply(ctx, env, plan); err != nil {
+		t.Fatal(err)
+	}
+	w := f.writes["audit_log"]
+	if len(w) != 1 {
+		t.Fatalf("expected one audit log write, got %v", w)
+	}
+	var entries []struct {
+		Actor     string                 `json:"actor"`
+		Action    string                 `json:"action"`
+		Entity    string                 `json:"entity"`
+		EntityID  string                 `json:"entity_id"`
+		Before    map[string]interface{} `json:"before"`
+		After     map[string]interface{} `json:"after"`
+		RequestID string                 `json:"request_id"`
+	}
+	if err := json.Unmarshal([]byte(w[0]), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the person and term audited, got %+v", entries)
+	}
+	person, term := entries[0], entries[1]
+	if person.Actor != "cli:jane" || person.Action != "create" || person.Entity != "people" || person.EntityID != "1" ||
+		person.RequestID != "run-2" || person.Before != nil || person.After["full_name"] == nil {
+		t.Errorf("unexpected person entry %+v", person)
+	}
+	if term.Entity != "terms" || keyString(term.After["person_id"]) != "1" {
+		t.Errorf("expected the term audited with the new person's id, got %+v", term)
+	}
+}
+
+func TestPeopleAreResolved(t *testing.T) {
+	f, env := newFakeBackends(t)
+
+	// Jane Doe entered by hand is linked to her Legistar IDs
+	f.rows["people"] = `[{"id": 5, "full_name": "Doe, Jane", "email": "ward01@cityofchicago.org", "external_ids": {"elms": "x"},
+		"terms": [{"start_date": "2023-05-15", "positions": {"district_number": 1, "jurisdiction_id": 1}}]}]`
+	plan := planJobs(t, env, "people")
+	if len(plan.Changes) != 1 || plan.Changes[0].Op != OpUpdate || plan.Changes[0].Value != "5" {
+		t.Fatalf("expected an update of person 5, got %+v", plan.Changes)
+	}
+	if row := plan.Changes[0].row(); keyString(row["external_ids"].(map[string]interface{})["legistar_id"]) != "162" || row["external_ids"].(map[string]interface{})["elms"] != "x" {
+		t.Errorf("expected the Legistar ID linked, got %+v", row["external_ids"])
+	}
+
+	// Two people named Jane Doe in no known ward are left for review
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe"}, {"id": 6, "full_name": "Jane Q. Doe"}]`
+	plan = planJobs(t, env, "people", "terms")
+	if len(plan.Changes) != 1 || plan.Changes[0].Table != "review_items" || plan.Changes[0].Value != "person_match/legistar/legistar_id=162" {
+		t.Fatalf("expected one review item and no person or term, got %+v", plan.Changes)
+	}
+
+	// and are not queued again
+	f.rows["review_items"] = `[{"id": 1, "key": "person_match/legistar/legistar_id=162"}]`
+	if plan = planJobs(t, env, "people"); len(plan.Changes) != 0 {
+		t.Errorf("expected no changes, got %+v", plan.Changes)
+	}
+}
+
+func TestReviewResolutionsAreApplied(t *testing.T) {
+	f, env := newFakeBackends(t)
+
+	// Two people named Jane Doe in no known ward: the one chosen in review
+	// is linked, and a new person is created if neither is
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe"}, {"id": 6, "full_name": "Jane Q. Doe"}]`
+	f.rows["review_items"] = `[{"id": 1, "key": "person_match/legistar/legistar_id=162", "status": "resolved", "resolution": {"person_id": 6},
+		"candidates": [{"person_id": 5}, {"person_id": 6}]}]`
+	plan := planJobs(t, env, "people")
+	if len(plan.Changes) != 1 || plan.Changes[0].Op != OpUpdate || plan.Changes[0].Value != "6" {
+		t.Fatalf("expected an update of person 6, got %+v", plan.Changes)
+	}
+	f.rows["review_items"] = `[{"id": 1, "key": "person_match/legistar/legistar_id=162", "status": "resolved", "resolution": {"new_person": true},
+		"candidates": [{"person_id": 5}, {"person_id": 6}]}]`
+	plan = planJobs(t, env, "people")
+	if len(plan.Changes) != 1 || plan.Changes[0].Op != OpCreate || plan.Changes[0].Table != "people" {
+		t.Fatalf("expected a new person, got %+v", plan.Changes)
+	}
+	f.rows["review_items"] = `[{"id": 1, "key": "person_match/legistar/legistar_id=162", "status": "dismissed", "resolution": {}}]`
+	if plan = planJobs(t, env, "people"); len(plan.Changes) != 0 {
+		t.Errorf("expected a dismissed record skipped, got %+v", plan.Changes)
+	}
+}
+
+func TestDeletedPeopleAreNotMatched(t *testing.T) {
+	f, env := newFakeBackends(t)
+
+	// A deleted person is neither written nor created again; the record
+	// waits for an admin to restore them or choose a new person
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "external_ids": {"legistar_id": 162}, "deleted_at": "2024-05-03T10:00:00+00:00"}]`
+	plan := planJobs(t, env, "people", "terms")
+	if len(plan.Changes) != 1 || plan.Changes[0].Table != "review_items" || plan.Changes[0].Value != "person_match/legistar/legistar_id=162" {
+		t.Fatalf("expected one review item and no person or term, got %+v", plan.Changes)
+	}
+	if candidates := plan.Changes[0].row()["candidates"].([]interface{}); len(candidates) != 1 || candidates[0].(map[string]interface{})["deleted"] != true {
+		t.Errorf("expected the deleted person as the only candidate, got %+v", candidates)
+	}
+
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "deleted_at": "2024-05-03T10:00:00+00:00"}, {"id": 6, "full_name": "Jane Doe"}]`
+	if clusters, err := FindDuplicates(context.Background(), env); err != nil || len(clusters) != 0 {
+		t.Errorf("expected no duplicates among live people, got %+v, %v", clusters, err)
+	}
+	if err := Run(context.Background(), env, MergePeople(6, []int{5}, "test"), NewPlan(env.Jurisdiction, SourceLegistar)); err == nil {
+		t.Error("expected merging a deleted person to fail")
+	}
+}
+
+func TestUnknownPositionIsReviewed(t *testing.T) {
+	f, env := newFakeBackends(t)
+	records := legistarFixtures["officerecords"]
+	legistarFixtures["officerecords"] = `[{"OfficeRecordId": 300, "OfficeRecordPersonId": 162, "OfficeRecordFullName": "Jane Doe",
+		"OfficeRecordTitle": "Floor Leader", "OfficeRecordBodyName": "City Council", "OfficeRecordStartDate": "2023-05-15T00:00:00"}]`
+	t.Cleanup(func() { legistarFixtures["officerecords"] = records })
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "external_ids": {"legistar_id": 162}}]`
+
+	plan := planJobs(t, env, "terms")
+	if len(plan.Changes) != 1 || plan.Changes[0].Table != "review_items" || plan.Changes[0].Value != "position/legistar/office_record_id=300" {
+		t.Fatalf("expected a position review item and no term, got %+v", plan.Changes)
+	}
+	row := plan.Changes[0].row()
+	if row["kind"] != "position" || len(row["candidates"].([]interface{})) != 1 {
+		t.Errorf("expected the jurisdiction's position as candidate, got %+v", row)
+	}
+
+	// The ward chosen in review decides the position
+	f.rows["review_items"] = `[{"id": 2, "key": "position/legistar/office_record_id=300", "status": "resolved", "resolution": {"ward": 1}}]`
+	plan = planJobs(t, env, "terms")
+	if len(plan.Changes) != 1 || plan.Changes[0].Table != "terms" || keyString(plan.Changes[0].row()["position_id"]) != "7" {
+		t.Fatalf("expected a term of position 7, got %+v", plan.Changes)
+	}
+
+	f.rows["review_items"] = `[{"id": 2, "key": "position/legistar/office_record_id=300", "status": "dismissed"}]`
+	if plan = planJobs(t, env, "terms"); len(plan.Changes) != 0 {
+		t.Errorf("expected a dismissed record skipped, got %+v", plan.Changes)
+	}
+}
+
+func TestELMSTermStartIsReviewed(t *testing.T) {
+	f, env := newFakeBackends(t)
+	elms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Name,Ward,Phone,Fax,Email,Website\n\"Doe, Jane\",1,312-555-0101,,ward01@example.com,\n"))
+	}))
+	t.Cleanup(elms.Close)
+	exportURL := ELMSExportURL
+	ELMSExportURL = elms.URL
+	t.Cleanup(func() { ELMSExportURL = exportURL })
+	env.Source = SourceELMS
+	env.Jurisdiction.APIBaseURL = ""
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "email": "ward01@example.com", "external_ids": {"elms_ward": 1}}]`
+
+	// The export has no term dates, so the term waits for one in review
+	plan := planJobs(t, env, "terms")
+	if len(plan.Changes) != 1 || plan.Changes[0].Table != "review_items" || plan.Changes[0].Value != "term_start/elms/ward=1/Jane Doe" {
+		t.Fatalf("expected a term start review item and no term, got %+v", plan.Changes)
+	}
+
+	f.rows["review_items"] = `[{"id": 3, "key": "term_start/elms/ward=1/Jane Doe", "status": "resolved", "resolution": {"start_date": "2023-05-15"}}]`
+	plan = planJobs(t, env, "terms")
+	if len(plan.Changes) != 1 || plan.Changes[0].Table != "terms" || plan.Changes[0].row()["start_date"] != "2023-05-15" {
+		t.Fatalf("expected a term starting on the date given in review, got %+v", plan.Changes)
+	}
+
+	f.rows["review_items"] = `[{"id": 3, "key": "term_start/elms/ward=1/Jane Doe", "status": "dismissed"}]`
+	if plan = planJobs(t, env, "terms"); len(plan.Changes) != 0 {
+		t.Errorf("expected a dismissed term skipped, got %+v", plan.Changes)
+	}
+}
+
+func TestOverridesArePinned(t *testing.T) {
+	f, env := newFakeBackends(t)
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "first_name": "Jane", "last_name": "Doe", "email": "jane.doe@cityofchicago.org",
+		"external_ids": {"legistar_id": 162}}]`
+	f.rows["field_overrides"] = `[{"id": 1, "entity": "person", "entity_id": "5", "field": "email", "value": "jane.doe@cityofchicago.org"},
+		{"id": 2, "entity": "person", "entity_id": "5", "field": "full_name", "value": "Jane A. Doe"}]`
+
+	// Upstream has Ward01@cityofchicago.org, and the stored email stays
+	// pinned; the pinned name is restored
+	plan := planJobs(t, env, "people")
+	if len(plan.Changes) != 1 {
+		t.Fatalf("expected an update of person 5, got %+v", plan.Changes)
+	}
+	c := plan.Changes[0]
+	for _, field := range c.Fields {
+		if field.Field == "email" {
+			t.Errorf("expected the pinned email kept, got %+v", field)
+		}
+	}
+	if row := c.row(); row["full_name"] != "Jane A. Doe" {
+		t.Errorf("expected the pinned name written, got %v", row["full_name"])
+	}
+	if !strings.Contains(c.Note, "kept by an override: email, full_name") {
+		t.Errorf("expected the pinned fields noted, got %q", c.Note)
+	}
+}
+
+func TestApplyRecordsProvenance(t *testing.T) {
+	f, env := newFakeBackends(t)
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "first_name": "Jane", "last_name": "Doe", "email": "jane.doe@cityofchicago.org",
+		"external_ids": {"legistar_id": 162}}]`
+	f.rows["field_overrides"] = `[{"id": 2, "entity": "person", "entity_id": "5", "field": "full_name", "value": "Jane A. Doe"}]`
+
+	plan := planJobs(t, env, "people")
+	ctx := middleware.WithRequestID(context.Background(), "run-1")
+	if _, err := Apply(ctx, env, plan); err != nil {
+		t.Fatal(err)
+	}
+	w := f.writes["field_provenance"]
+	if len(w) != 1 {
+		t.Fatalf("expected one provenance write, got %v", w)
+	}
+	var fields []map[string]interface{}
+	if err := json.Unmarshal([]byte(w[0]), &fields); err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]string{}
+	for _, field := range fields {
+		if field["entity"] != "person" || field["entity_id"] != "5" || field["run_id"] != "run-1" {
+			t.Errorf("expected provenance of person 5 from run-1, got %v", field)
+		}
+		sources[field["field"].(string)] = fmt.Sprintf("%v %v", field["system"], field["record_id"])
+	}
+	if got := sources["full_name"]; got != "override field_overrides/2" {
+		t.Errorf("expected the pinned name sourced from its override, got %q", got)
+	}
+	if got := sources["email"]; got != "legistar /officerecords/300" {
+		t.Errorf("expected the email sourced from the office record, got %q", got)
+	}
+	if got := sources["phone"]; got != "legistar /persons/162" {
+		t.Errorf("expected the phone sourced from the person, got %q", got)
+	}
+}
+
+func TestMergeAndUndo(t *testing.T) {
+	f, env := newFakeBackends(t)
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "external_ids": {"legistar_id": 162}},
+		{"id": 6, "full_name": "Doe, Jane", "external_ids": {"legistar_id": 162, "elms_ward": 1}}]`
+	f.rows["terms"] = `[{"id": 1, "person_id": 5, "position_id": 7, "start_date": "2023-05-15"},
+		{"id": 2, "person_id": 6, "position_id": 7, "start_date": "2023-05-15"},
+		{"id": 3, "person_id": 6, "position_id": 7, "start_date": "2019-05-20"}]`
+	f.rows["votes"] = `[{"id": 10, "person_id": 6}]`
+	f.rows["person_metrics"] = `[{"person_id": 6, "total_votes": 3}]`
+
+	plan := NewPlan(env.Jurisdiction, "")
+	if err := Run(context.Background(), env, MergePeople(5, []int{6}, "test"), plan); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"create person_merges/merged_id=6", "update terms/id=3", "update votes/id=10", "update person_metrics/person_id=6",
+		"delete terms/id=2", "update people/id=5", "delete people/id=6"}
+	if got := changeList(plan); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("expected changes %v, got %v", want, got)
+	}
+	record := plan.Changes[0].row()
+	if ids := record["linked_external_ids"].(map[string]interface{}); len(ids) != 1 || keyString(ids["elms_ward"]) != "1" {
+		t.Errorf("expected elms_ward linked, got %v", ids)
+	}
+
+	// The merge as stored, with the merged person gone
+	record["id"] = 1
+	stored, _ := json.Marshal([]interface{}{record})
+	f.rows["person_merges"] = string(stored)
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "external_ids": {"legistar_id": 162, "elms_ward": 1}}]`
+
+	plan = NewPlan(env.Jurisdiction, "")
+	if err := Run(context.Background(), env, UndoMerge(1, "test"), plan); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"create people/id=6", "update terms/id=3", "create terms/id=2", "update votes/id=10", "update person_metrics/person_id=5",
+		"update people/id=5", "update person_merges/id=1"}
+	if got := changeList(plan); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("expected changes %v, got %v", want, got)
+	}
+	if ids := plan.Changes[5].row()["external_ids"].(map[string]interface{}); len(ids) != 1 {
+		t.Errorf("expected elms_ward removed from the survivor, got %v", ids)
+	}
+}
+
+func TestPurgeRemovesDependentsFirst(t *testing.T) {
+	f, env := newFakeBackends(t)
+	f.rows["people"] = `[{"id": 5, "full_name": "Jane Doe", "deleted_at": "2024-01-02T00:00:00Z", "deleted_by": "key:admin"}]`
+	f.rows["terms"] = `[{"id": 1, "person_id": 5, "deleted_at": "2024-01-02T00:00:00Z"}]`
+	f.rows["votes"] = `[{"id": 10, "person_id": 5, "deleted_at": "2024-01-02T00:00:00Z"}]`
+
+	plan := NewPlan(env.Jurisdiction, "")
+	if err := Run(context.Background(), env, PurgeDeleted, plan); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"delete votes/id=10", "delete terms/id=1", "delete people/id=5"}
+	if got := changeList(plan); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("expected changes %v, got %v", want, got)
+	}
+
+	if _, err := Apply(context.Background(), env, plan); err != nil {
+		t.Fatal(err)
+	}
+	if q := f.queries["people"]; len(q) != 1 || q[0] != "id=eq.5" {
+		t.Errorf("expected person 5 deleted, got %v", q)
+	}
+}
+
+// changeList returns the op and ID of each change in plan
+func changeList(plan *Plan) []string {
+	var ids []string
+	for _, c := range plan.Changes {
+		ids = append(ids, c.Op+" "+c.ID)
+	}
+	return ids
+}
+
+func TestSponsorship(t *testing.T) {
+	intro := "2024-01-10T00:00:00"
+	matters := []sponsoredMatter{
+		{Sponsors: json.RawMessage(`[{"MatterSponsorName": "Hopkins, Brian"}]`), IntroDate: &intro},
+		{Sponsors: json.RawMessage(`"[{\"MatterSponsorName\": \"Ald. Brian Hopkins\"}]"`)},
+		{Sponsors: json.RawMessage(`[{"MatterSponsorName": "Brian Hopkinson"}]`)},
+	}
+	introduced, thisTerm, passed := sponsorship(matters, "Brian Hopkins", time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC))
+	if introduced != 2 || thisTerm != 1 || passed != 0 {
+		t.Errorf("expected 2 matters, 1 this term, got %d, %d, %d", introduced, thisTerm, passed)
+	}
+}
+
+func TestApplyRecordsCounts(t *testing.T) {
+	f, env := newFakeBackends(t)
+
+	counts, err := Apply(context.Background(), env, planJobs(t, env, "bodies"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := counts[db.EntityBodies]; c.Fetched != 2 || c.Upserted != 2 {
+		t.Errorf("unexpected counts %+v", *c)
+	}
+	if w := f.writes["bodies"]; len(w) != 1 || !strings.HasPrefix(w[0], "[") || !strings.Contains(w[0], "Committee on Finance") {
+		t.Errorf("expected both bodies upserted in one batch, got %v", w)
+	}
+	if w := f.writes["sync_state"]; len(w) != 1 || !strings.Contains(w[0], `"records_upserted":2`) {
+		t.Errorf("expected counts recorded in sync_state, got %v", w)
+	}
+}
+
+func TestBatchSizeAndConflictTarget(t *testing.T) {
+	f, env := newFakeBackends(t)
+	env.BatchSize = 1
+
+	counts, err := Apply(context.Background(), env, planJobs(t, env, "bodies"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := counts[db.EntityBodies]; c.Upserted != 2 {
+		t.Errorf("unexpected counts %+v", *c)
+	}
+	if w := f.writes["bodies"]; len(w) != 2 {
+		t.Errorf("expected a request per body, got %v", w)
+	}
+	for _, q := range f.queries["bodies"] {
+		if q != "on_conflict=body_id" {
+			t.Errorf("expected upserts on body_id, got %q", q)
+		}
+	}
+}
+
+func TestFailedRecordsAreCounted(t *testing.T) {
+	f, env := newFakeBackends(t)
+	f.failing["votes"] = true
+
+	counts, err := Apply(context.Background(), env, planJobs(t, env, "votes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := counts[db.EntityVotes]; c.Fetched != 1 || c.Failed != 1 || counts.Failed() != 1 {
+		t.Errorf("unexpected counts %+v", *c)
+	}
+
+	// A failed batch is retried one row at a time
+	f.failing["bodies"] = true
+	counts, err = Apply(context.Background(), env, planJobs(t, env, "bodies"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := counts[db.EntityBodies]; c.Upserted != 0 || c.Failed != 2 {
+		t.Errorf("unexpected counts %+v", *c)
+	}
+	if w := f.writes["bodies"]; len(w) != 3 || strings.HasPrefix(w[1], "[") {
+		t.Errorf("expected a batch and then 2 single rows, got %v", w)
+	}
+}
+
+func TestLoadPlan(t *testing.T) {
+	_, env := newFakeBackends(t)
+	plan := planJobs(t, env, "bodies")
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	var buf bytes.Buffer
+	if err := plan.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, buf.Bytes(), 0o644)
+
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Changes) != 2 || loaded.Jurisdiction.ID != 1 || loaded.Fetched[db.EntityBodies] != 2 {
+		t.Errorf("unexpected plan %+v", loaded)
+	}
+
+	os.WriteFile(path, []byte(`{"changes": [{"id": "bodies/body_id=1", "op": "truncate", "value": "1"}]}`), 0o644)
+	if _, err := LoadPlan(path); err == nil || !strings.Contains(err.Error(), "unknown op") {
+		t.Errorf("expected unknown op error, got %v", err)
+	}
+}
+
+func TestParseELMSKeepsNewestRowPerWard(t *testing.T) {
+	records := [][]string{
+		{"Old, Alder", "1", "312-555-0100", "", "old@example.com", ""},
+		{"Short, Row", "2"},
+		{"Doe, Jane Q", "01", "312-555-0101", "", "ward01@example.com", "https://ward1.example.com"},
+		{"Roe, Rick", "51", "", "", "", ""},
+	}
+	rows := parseELMS(context.Background(), records)
+	if len(rows) != 1 {
+		t.Fatalf("expected one row, got %+v", rows)
+	}
+	if r := rows[0]; r.FullName != "Jane Doe" || r.Ward != 1 || r.Website != "https://ward1.example.com" {
+		t.Errorf("unexpected row %+v", r)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	for _, tc := range []struct {
+		j    Jurisdiction
+		want string
+	}{
+		{Jurisdiction{Name: "Chicago", APIBaseURL: "https://webapi.legistar.com/v1/chicago"}, "chicago"},
+		{Jurisdiction{Name: "New York"}, "newyork"},
+	} {
+		if got := tc.j.Slug(); got != tc.want {
+			t.Errorf("Slug(%+v) = %q, want %q", tc.j, got, tc.want)
+		}
+	}
+}
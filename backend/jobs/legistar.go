@@ -0,0 +1,338 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Default number of recent records synced per run
+const (
+	defaultMatters = 100
+	defaultEvents  = 50
+)
+
+// legistarDateLayout is the layout of Legistar dates, which carry no zone
+const legistarDateLayout = "2006-01-02T15:04:05"
+
+// planBodies plans bodies and committees, which matters and events refer to
+func planBodies(ctx context.Context, env *Env, plan *Plan) error {
+	bodies, err := env.Legistar.GetBodies()
+	if err != nil {
+		return fmt.Errorf("failed to fetch bodies: %w", err)
+	}
+
+	slog.InfoContext(ctx, "fetched bodies", "count", len(bodies))
+	plan.fetched(db.EntityBodies, len(bodies))
+
+	rows := make([]map[string]interface{}, len(bodies))
+	for i, body := range bodies {
+		rows[i] = map[string]interface{}{
+			"body_id":        body.BodyID,
+			"body_name":      body.BodyName,
+			"body_type_id":   body.BodyTypeID,
+			"body_type_name": body.BodyTypeName,
+			"body_meet_flag": body.BodyMeetFlag,
+		}
+	}
+	return plan.diffRows(env, db.EntityBodies, "bodies", "body_id", rows, func(row map[string]interface{}) string {
+		return fmt.Sprint(row["body_name"])
+	})
+}
+
+// planMatters plans the most recently introduced matters
+func planMatters(ctx context.Context, env *Env, plan *Plan) error {
+	limit := env.limit(defaultMatters)
+	matters, err := env.Legistar.GetMatters(map[string]string{
+		"$top":     strconv.Itoa(limit),
+		"$orderby": "MatterIntroDate desc",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch matters: %w", err)
+	}
+
+	slog.InfoContext(ctx, "fetched matters", "count", len(matters))
+	plan.fetched(db.EntityMatters, len(matters))
+
+	rows := make([]map[string]interface{}, len(matters))
+	for i, matter := range matters {
+		// Sponsors and attachments are stored as JSONB
+		sponsorsJSON, _ := json.Marshal(matter.MatterSponsors)
+		attachmentsJSON, _ := json.Marshal(matter.MatterAttachments)
+
+		rows[i] = map[string]interface{}{
+			"matter_id":               strconv.Itoa(matter.MatterID),
+			"matter_file":             matter.MatterFile,
+			"matter_name":             matter.MatterName,
+			"matter_title":            matter.MatterTitle,
+			"matter_type_id":          matter.MatterTypeID,
+			"matter_type_name":        matter.MatterTypeName,
+			"matter_status_id":        matter.MatterStatusID,
+			"matter_status_name":      matter.MatterStatusName,
+			"matter_intro_date":       parseAPIDate(matter.MatterIntroDate),
+			"matter_agenda_date":      parseAPIDate(matter.MatterAgendaDate),
+			"matter_passed_date":      parseAPIDate(matter.MatterPassedDate),
+			"matter_enactment_date":   parseAPIDate(matter.MatterEnactmentDate),
+			"matter_enactment_number": matter.MatterEnactmentNumber,
+			"matter_requester":        matter.MatterRequester,
+			"matter_sponsors":         string(sponsorsJSON),
+			"matter_attachments":      string(attachmentsJSON),
+			"matter_text":             matter.MatterText,
+			"matter_version":          matter.MatterVersion,
+		}
+	}
+	return plan.diffRows(env, db.EntityMatters, "matters", "matter_id", rows, func(row map[string]interface{}) string {
+		return strings.TrimSpace(fmt.Sprint(row["matter_file"]) + " " + truncate(fmt.Sprint(row["matter_title"]), 60))
+	})
+}
+
+// planVotes plans the votes on the most recently introduced matters already
+// in the database, and on the matters the plan creates, so it runs after the
+// matters job
+func planVotes(ctx context.Context, env *Env, plan *Plan) error {
+	var stored []struct {
+		MatterID string `json:"matter_id"`
+	}
+	_, err := env.DB.From("matters").
+		Select("matter_id", "", false).
+		Order("matter_intro_date", &postgrest.OrderOpts{Ascending: false}).
+		Limit(env.limit(defaultMatters), "").
+		ExecuteTo(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to load matters: %w", err)
+	}
+
+	var matterIDs []string
+	for _, c := range plan.creates("matters") {
+		matterIDs = append(matterIDs, c.Value)
+	}
+	for _, m := range stored {
+		matterIDs = append(matterIDs, m.MatterID)
+	}
+	matterIDs = unique(matterIDs)
+
+	// Each matter's votes are fetched by a unit of work, so a run that stops
+	// part way resumes where it left off
+	payloads := make(map[string]interface{}, len(matterIDs))
+	for _, id := range matterIDs {
+		matterID, err := strconv.Atoi(id)
+		if err != nil {
+			slog.WarnContext(logging.With(ctx, "matter_id", id), "skipping matter with non-numeric id")
+			continue
+		}
+		payloads[id] = map[string]int{"matter_id": matterID}
+	}
+
+	slog.InfoContext(ctx, "fetching votes", "matters", len(payloads))
+	plan.fetched(db.EntityVotes, 0)
+	results, dead, err := runUnits(ctx, env, unitVotes, payloads, fetchVotes(env))
+	if err != nil {
+		return err
+	}
+	for _, id := range dead {
+		slog.WarnContext(logging.With(ctx, "matter_id", id), "failed to fetch votes")
+	}
+	plan.failed(db.EntityVotes, len(dead))
+
+	var rows []map[string]interface{}
+	for _, id := range matterIDs {
+		result, ok := results[id]
+		if !ok {
+			continue
+		}
+		var votes []cityapi.Vote
+		if err := json.Unmarshal(result, &votes); err != nil {
+			return fmt.Errorf("invalid votes of matter %s: %w", id, err)
+		}
+		if len(votes) == 0 {
+			continue
+		}
+		slog.DebugContext(logging.With(ctx, "matter_id", id), "fetched votes", "count", len(votes))
+		plan.fetched(db.EntityVotes, len(votes))
+
+		for _, vote := range votes {
+			rows = append(rows, map[string]interface{}{
+				"vote_id":       strconv.Itoa(vote.VoteID),
+				"matter_id":     strconv.Itoa(vote.VoteMatterID),
+				"person_id":     vote.VotePersonID,
+				"person_name":   vote.VotePersonName,
+				"vote_value":    vote.VoteValue,
+				"vote_date":     parseAPIDate(vote.VoteDate),
+				"vote_event_id": vote.VoteEventID,
+			})
+		}
+	}
+	return plan.diffRows(env, db.EntityVotes, "votes", "vote_id", rows, func(row map[string]interface{}) string {
+		return fmt.Sprintf("%v on matter %v: %v", row["person_name"], row["matter_id"], row["vote_value"])
+	})
+}
+
+// planEvents plans the most recent events and their agenda items, fetched
+// per event. Stored items that an event's agenda no longer lists are
+// deleted. Events whose items could not be fetched are left as they are.
+func planEvents(ctx context.Context, env *Env, plan *Plan) error {
+	limit := env.limit(defaultEvents)
+	events, err := env.Legistar.GetEvents(map[string]string{
+		"$top":     strconv.Itoa(limit),
+		"$orderby": "EventDate desc",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch events: %w", err)
+	}
+
+	slog.InfoContext(ctx, "fetched events", "count", len(events))
+	plan.fetched(db.EntityEvents, len(events))
+
+	payloads := make(map[string]interface{}, len(events))
+	for _, event := range events {
+		payloads[strconv.Itoa(event.EventID)] = map[string]int{"event_id": event.EventID}
+	}
+	results, dead, err := runUnits(ctx, env, unitEventItems, payloads, fetchEventItems(env))
+	if err != nil {
+		return err
+	}
+	for _, id := range dead {
+		slog.WarnContext(logging.With(ctx, "event_id", id), "failed to fetch agenda items")
+	}
+	plan.failed(db.EntityEvents, len(dead))
+
+	var eventRows []map[string]interface{}
+	var agendaEventIDs []string
+	var itemRows []map[string]interface{}
+	for _, event := range events {
+		eventID := strconv.Itoa(event.EventID)
+		result, ok := results[eventID]
+		if !ok {
+			continue
+		}
+		var items []cityapi.EventItem
+		if err := json.Unmarshal(result, &items); err != nil {
+			return fmt.Errorf("invalid agenda items of event %s: %w", eventID, err)
+		}
+
+		// Items are stored on the event as JSONB and as event_items rows
+		itemsJSON, _ := json.Marshal(items)
+		eventRows = append(eventRows, map[string]interface{}{
+			"event_id":           eventID,
+			"event_body_id":      event.EventBodyID,
+			"event_body_name":    event.EventBodyName,
+			"event_date":         parseAPIDate(event.EventDate),
+			"event_time":         event.EventTime,
+			"event_location":     event.EventLocation,
+			"event_agenda_file":  event.EventAgendaFile,
+			"event_minutes_file": event.EventMinutesFile,
+			"event_video_url":    event.EventVideoURL,
+			"event_items":        string(itemsJSON),
+		})
+
+		if len(items) > 0 {
+			agendaEventIDs = append(agendaEventIDs, eventID)
+		}
+		for _, item := range items {
+			itemRows = append(itemRows, map[string]interface{}{
+				"event_item_id":        strconv.Itoa(item.EventItemID),
+				"event_id":             eventID,
+				"matter_id":            strconv.Itoa(item.EventItemMatterID),
+				"item_agenda_sequence": item.EventItemAgendaSequence,
+				"item_agenda_number":   item.EventItemAgendaNumber,
+				"item_action":          item.EventItemAction,
+				"item_action_text":     item.EventItemActionText,
+			})
+		}
+	}
+
+	err = plan.diffRows(env, db.EntityEvents, "events", "event_id", eventRows, func(row map[string]interface{}) string {
+		return fmt.Sprintf("%v on %v", row["event_body_name"], textDate(row["event_date"]))
+	})
+	if err != nil {
+		return err
+	}
+	return planEventItems(env, plan, agendaEventIDs, itemRows)
+}
+
+// eventItemColumns are the columns of event_items the events job writes
+var eventItemColumns = []string{"event_item_id", "event_id", "matter_id", "item_agenda_sequence", "item_agenda_number", "item_action", "item_action_text"}
+
+// planEventItems plans the agenda items of the events with eventIDs, which
+// list at least one item. Item writes count towards no entity, as they are
+// part of their event.
+func planEventItems(env *Env, plan *Plan, eventIDs []string, rows []map[string]interface{}) error {
+	stored, err := selectIn(env, "event_items", "event_id", eventIDs, eventItemColumns)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]map[string]interface{}, len(stored))
+	for _, row := range stored {
+		current[keyString(row["event_item_id"])] = row
+	}
+
+	desired := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		id := row["event_item_id"].(string)
+		desired[id] = true
+		plan.diff(target{
+			table: "event_items",
+			key:   "event_item_id",
+			value: id,
+			label: fmt.Sprintf("item %v of event %v", row["item_agenda_number"], row["event_id"]),
+		}, current[id], row)
+	}
+	for _, row := range stored {
+		id := keyString(row["event_item_id"])
+		if desired[id] {
+			continue
+		}
+		plan.delete(target{
+			table: "event_items",
+			key:   "event_item_id",
+			value: id,
+			label: fmt.Sprintf("item %v of event %v", row["item_agenda_number"], keyString(row["event_id"])),
+			note:  "no longer on the event's agenda",
+		}, row)
+	}
+	return nil
+}
+
+// parseAPIDate parses a Legistar date, e.g. "2024-01-15T00:00:00", into an
+// RFC 3339 timestamp
+func parseAPIDate(dateStr string) *string {
+	if dateStr == "" {
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		parsed, err = time.Parse(legistarDateLayout, dateStr)
+		if err != nil {
+			return nil
+		}
+	}
+
+	formatted := parsed.Format(time.RFC3339)
+	return &formatted
+}
+
+// textDate formats a planned timestamp as its date, for labels
+func textDate(v interface{}) string {
+	if s, ok := v.(*string); ok && s != nil && len(*s) >= 10 {
+		return (*s)[:10]
+	}
+	return "unknown date"
+}
+
+// truncate shortens s to max bytes for logging
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
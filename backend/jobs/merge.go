@@ -0,0 +1,338 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/resolver"
+)
+
+// FindDuplicates returns the clusters of live stored people who are likely
+// the same person, with their terms in the jurisdiction
+func FindDuplicates(ctx context.Context, env *Env) ([]resolver.Cluster, error) {
+	stored, err := loadStoredPeople(env)
+	if err != nil {
+		return nil, err
+	}
+	live := stored.live()
+	clusters := resolver.Duplicates(live)
+	slog.InfoContext(ctx, "found duplicate people", "people", len(live), "clusters", len(clusters))
+	return clusters, nil
+}
+
+// personRef is a table whose rows refer to a person by person_id
+type personRef struct {
+	table  string
+	entity string   // sync_state entity, if any
+	key    string   // column identifying a row
+	unique []string // columns unique per person: a row the survivor already has is deleted, not moved
+	single bool     // a person has one row at most
+}
+
+// personRefs are the tables a merge moves from the merged people to the
+// survivor
+var personRefs = []personRef{
+	{table: "terms", entity: db.EntityTerms, key: "id", unique: []string{"position_id", "start_date"}},
+	{table: "votes", entity: db.EntityVotes, key: "id"},
+	{table: "sponsorships", key: "id", unique: []string{"legislation_id"}},
+	{table: "person_metrics", entity: db.EntityMetrics, key: "person_id", single: true},
+}
+
+// uniqueKey returns the values of ref's unique columns in row, or "" if
+// rows of ref have none
+func (ref personRef) uniqueKey(row map[string]interface{}) string {
+	if ref.single {
+		return "person"
+	}
+	if len(ref.unique) == 0 {
+		return ""
+	}
+	values := make([]string, len(ref.unique))
+	for i, column := range ref.unique {
+		values[i] = keyString(row[column])
+	}
+	return strings.Join(values, "|")
+}
+
+// MergePeople returns the job merging the people merged into survivor,
+// recording each merge in person_merges under by
+func MergePeople(survivor int, merged []int, by string) Job {
+	return Job{Name: "merge", Plan: func(ctx context.Context, env *Env, plan *Plan) error {
+		return planMerge(ctx, env, plan, survivor, merged, by)
+	}}
+}
+
+// UndoMerge returns the job undoing the merge recorded as person_merges row
+// id, recording by as who undid it
+func UndoMerge(id int, by string) Job {
+	return Job{Name: "unmerge", Plan: func(ctx context.Context, env *Env, plan *Plan) error {
+		return planUnmerge(ctx, env, plan, id, by)
+	}}
+}
+
+// loadPeople returns every column of the people with the given ids, by id
+func loadPeople(env *Env, ids []int) (map[string]map[string]interface{}, error) {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return loadRows(env, "people", "id", values, []string{"*"})
+}
+
+// planMerge moves everything that refers to each merged person to the
+// survivor, adds the merged people's external IDs the survivor lacks and
+// deletes the merged people. A merged person's row and the rows moved or
+// deleted are recorded first, so that the merge can be undone.
+func planMerge(ctx context.Context, env *Env, plan *Plan, survivorID int, mergedIDs []int, by string) error {
+	people, err := loadPeople(env, append([]int{survivorID}, mergedIDs...))
+	if err != nil {
+		return err
+	}
+	survivor := people[strconv.Itoa(survivorID)]
+	if survivor == nil {
+		return fmt.Errorf("person %d not found", survivorID)
+	}
+	for _, id := range mergedIDs {
+		if people[strconv.Itoa(id)] == nil {
+			return fmt.Errorf("person %d not found", id)
+		}
+	}
+	// A deleted person's rows are hidden; merging would hide or reveal them
+	for _, id := range append([]int{survivorID}, mergedIDs...) {
+		if people[strconv.Itoa(id)]["deleted_at"] != nil {
+			return fmt.Errorf("person %d is deleted; restore them before merging", id)
+		}
+	}
+
+	// Rows the survivor has, by table and unique key
+	held := make(map[string]map[string]bool, len(personRefs))
+	for _, ref := range personRefs {
+		rows, err := selectIn(env, ref.table, "person_id", []string{strconv.Itoa(survivorID)}, []string{"*"})
+		if err != nil {
+			return err
+		}
+		held[ref.table] = map[string]bool{}
+		for _, row := range rows {
+			if key := ref.uniqueKey(row); key != "" {
+				held[ref.table][key] = true
+			}
+		}
+	}
+
+	survivorIDs := linkExternalIDs(survivor, nil)
+	for _, mergedID := range mergedIDs {
+		merged := people[strconv.Itoa(mergedID)]
+		mctx := logging.With(ctx, "merged_id", mergedID)
+
+		// Work out every change before planning any, so that the record
+		// comes first
+		type move struct {
+			ref personRef
+			row map[string]interface{}
+		}
+		var moves, deletes []move
+		repointed := map[string][]string{}
+		deleted := map[string][]map[string]interface{}{}
+		for _, ref := range personRefs {
+			rows, err := selectIn(env, ref.table, "person_id", []string{strconv.Itoa(mergedID)}, []string{"*"})
+			if err != nil {
+				return err
+			}
+			sort.Slice(rows, func(i, j int) bool { return keyString(rows[i][ref.key]) < keyString(rows[j][ref.key]) })
+			for _, row := range rows {
+				key := ref.uniqueKey(row)
+				if key != "" && held[ref.table][key] {
+					deletes = append(deletes, move{ref, row})
+					deleted[ref.table] = append(deleted[ref.table], row)
+					continue
+				}
+				if key != "" {
+					held[ref.table][key] = true
+				}
+				moves = append(moves, move{ref, row})
+				after := keyString(row[ref.key])
+				if ref.key == "person_id" {
+					after = strconv.Itoa(survivorID)
+				}
+				repointed[ref.table] = append(repointed[ref.table], after)
+			}
+		}
+
+		linked := map[string]interface{}{}
+		if ids, ok := merged["external_ids"].(map[string]interface{}); ok {
+			for key, v := range ids {
+				have, ok := survivorIDs[key]
+				switch {
+				case !ok:
+					linked[key] = v
+					survivorIDs[key] = v
+				case keyString(have) != keyString(v):
+					slog.WarnContext(mctx, "survivor keeps its own external ID", "key", key, "survivor_value", have, "merged_value", v)
+				}
+			}
+		}
+
+		label := fmt.Sprintf("%v into %v", merged["full_name"], survivor["full_name"])
+		plan.diff(target{
+			table: "person_merges",
+			key:   "id",
+			id:    fmt.Sprintf("person_merges/merged_id=%d", mergedID),
+			label: "merge " + label,
+		}, nil, map[string]interface{}{
+			"survivor_id":         survivorID,
+			"merged_id":           mergedID,
+			"merged_person":       merged,
+			"linked_external_ids": linked,
+			"repointed":           repointed,
+			"deleted":             deleted,
+			"merged_by":           by,
+		})
+		for _, m := range moves {
+			plan.diff(target{
+				entity: m.ref.entity,
+				table:  m.ref.table,
+				key:    m.ref.key,
+				value:  keyString(m.row[m.ref.key]),
+				label:  label,
+			}, map[string]interface{}{"person_id": mergedID}, map[string]interface{}{"person_id": survivorID})
+		}
+		for _, d := range deletes {
+			plan.delete(target{
+				entity: d.ref.entity,
+				table:  d.ref.table,
+				key:    d.ref.key,
+				value:  keyString(d.row[d.ref.key]),
+				label:  label,
+				note:   "the survivor already has this row",
+			}, d.row)
+		}
+		slog.InfoContext(mctx, "planned merge", "survivor_id", survivorID, "moved", len(moves), "deleted", len(deletes), "linked_external_ids", len(linked))
+	}
+
+	plan.diff(target{
+		entity: db.EntityPeople,
+		table:  "people",
+		key:    "id",
+		value:  strconv.Itoa(survivorID),
+		label:  fmt.Sprintf("%v", survivor["full_name"]),
+	}, map[string]interface{}{"external_ids": survivor["external_ids"]}, map[string]interface{}{"external_ids": survivorIDs})
+	for _, mergedID := range mergedIDs {
+		merged := people[strconv.Itoa(mergedID)]
+		plan.delete(target{
+			entity: db.EntityPeople,
+			table:  "people",
+			key:    "id",
+			value:  strconv.Itoa(mergedID),
+			label:  fmt.Sprintf("%v", merged["full_name"]),
+			note:   fmt.Sprintf("merged into person %d", survivorID),
+		}, merged)
+	}
+	return nil
+}
+
+// personMerge is a person_merges row
+type personMerge struct {
+	ID                int                                 `json:"id"`
+	SurvivorID        int                                 `json:"survivor_id"`
+	MergedID          int                                 `json:"merged_id"`
+	MergedPerson      map[string]interface{}              `json:"merged_person"`
+	LinkedExternalIDs map[string]interface{}              `json:"linked_external_ids"`
+	Repointed         map[string][]string                 `json:"repointed"`
+	Deleted           map[string][]map[string]interface{} `json:"deleted"`
+	UndoneAt          *string                             `json:"undone_at"`
+}
+
+// planUnmerge restores the merged person of a recorded merge, moves the
+// rows that were moved back to them, recreates the rows that were deleted
+// and removes the external IDs the survivor gained
+func planUnmerge(ctx context.Context, env *Env, plan *Plan, id int, by string) error {
+	var merges []personMerge
+	_, err := env.DB.From("person_merges").
+		Select("*", "", false).
+		Eq("id", strconv.Itoa(id)).
+		ExecuteTo(&merges)
+	if err != nil {
+		return fmt.Errorf("failed to load merge: %w", err)
+	}
+	if len(merges) == 0 {
+		return fmt.Errorf("merge %d not found", id)
+	}
+	m := merges[0]
+	if m.UndoneAt != nil {
+		return fmt.Errorf("merge %d was already undone at %s", id, *m.UndoneAt)
+	}
+
+	people, err := loadPeople(env, []int{m.SurvivorID, m.MergedID})
+	if err != nil {
+		return err
+	}
+	if people[strconv.Itoa(m.MergedID)] != nil {
+		return fmt.Errorf("person %d exists again; the merge cannot be undone", m.MergedID)
+	}
+	survivor := people[strconv.Itoa(m.SurvivorID)]
+	if survivor == nil {
+		return fmt.Errorf("survivor %d no longer exists, e.g. it was merged too; undo that merge first", m.SurvivorID)
+	}
+
+	label := fmt.Sprintf("undo merge of %v into %v", m.MergedPerson["full_name"], survivor["full_name"])
+	plan.diff(target{
+		entity: db.EntityPeople,
+		table:  "people",
+		key:    "id",
+		value:  strconv.Itoa(m.MergedID),
+		label:  label,
+	}, nil, m.MergedPerson)
+
+	for _, ref := range personRefs {
+		for _, key := range m.Repointed[ref.table] {
+			plan.diff(target{
+				entity: ref.entity,
+				table:  ref.table,
+				key:    ref.key,
+				value:  key,
+				label:  label,
+			}, map[string]interface{}{"person_id": m.SurvivorID}, map[string]interface{}{"person_id": m.MergedID})
+		}
+		for _, row := range m.Deleted[ref.table] {
+			plan.diff(target{
+				entity: ref.entity,
+				table:  ref.table,
+				key:    ref.key,
+				value:  keyString(row[ref.key]),
+				label:  label,
+			}, nil, row)
+		}
+	}
+
+	externalIDs := linkExternalIDs(survivor, nil)
+	for key, v := range m.LinkedExternalIDs {
+		if keyString(externalIDs[key]) == keyString(v) {
+			delete(externalIDs, key)
+		}
+	}
+	plan.diff(target{
+		entity: db.EntityPeople,
+		table:  "people",
+		key:    "id",
+		value:  strconv.Itoa(m.SurvivorID),
+		label:  label,
+	}, map[string]interface{}{"external_ids": survivor["external_ids"]}, map[string]interface{}{"external_ids": externalIDs})
+
+	plan.diff(target{
+		table: "person_merges",
+		key:   "id",
+		value: strconv.Itoa(id),
+		label: label,
+	}, map[string]interface{}{"undone_by": nil, "undone_at": nil}, map[string]interface{}{
+		"undone_by": by,
+		"undone_at": time.Now().Format(time.RFC3339),
+	})
+	return nil
+}
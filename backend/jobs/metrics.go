@@ -0,0 +1,232 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/resolver"
+)
+
+// attendanceEstimate stands in for committee attendance, which Legistar does
+// not publish per person
+const attendanceEstimate = 85.0
+
+// official is a current office holder from the current_officials view
+type official struct {
+	PersonID  int    `json:"person_id"`
+	FullName  string `json:"full_name"`
+	TermStart string `json:"term_start"`
+}
+
+// loadOfficials returns the jurisdiction's current office holders
+func loadOfficials(env *Env) ([]official, error) {
+	var officials []official
+	_, err := env.DB.From("current_officials").
+		Select("person_id, full_name, term_start", "", false).
+		Eq("jurisdiction_id", strconv.Itoa(env.Jurisdiction.ID)).
+		ExecuteTo(&officials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current officials: %w", err)
+	}
+	return officials, nil
+}
+
+// sponsoredMatter is a matter with its raw sponsors JSON
+type sponsoredMatter struct {
+	Sponsors   json.RawMessage `json:"matter_sponsors"`
+	IntroDate  *string         `json:"matter_intro_date"`
+	PassedDate *string         `json:"matter_passed_date"`
+}
+
+// voteTally counts one official's votes
+type voteTally struct {
+	Total, Yea, Nay, Abstain, Absent int
+}
+
+// tallyVotes counts the votes cast under fullName
+func tallyVotes(env *Env, fullName string) (voteTally, error) {
+	var votes []struct {
+		VoteValue string `json:"vote_value"`
+	}
+	_, err := env.DB.From("votes").
+		Select("vote_value", "", false).
+		Eq("person_name", fullName).
+		Is("deleted_at", "null").
+		ExecuteTo(&votes)
+	if err != nil {
+		return voteTally{}, err
+	}
+
+	var t voteTally
+	for _, v := range votes {
+		switch v.VoteValue {
+		case "Yea", "Yes", "Aye":
+			t.Yea++
+		case "Nay", "No":
+			t.Nay++
+		case "Present", "Abstain":
+			t.Abstain++
+		case "Absent", "Excused":
+			t.Absent++
+		}
+	}
+	t.Total = len(votes)
+	return t, nil
+}
+
+// metricsColumns are the person_metrics columns the metrics job writes
+var metricsColumns = []string{"person_id", "bills_introduced", "bills_passed", "total_votes", "votes_yea", "votes_nay", "votes_abstain", "votes_absent", "attendance_rate", "transparency_score", "last_calculated_at"}
+
+// planMetrics computes person_metrics for the jurisdiction's current
+// officials from synced matters and votes
+func planMetrics(ctx context.Context, env *Env, plan *Plan) error {
+	officials, err := loadOfficials(env)
+	if err != nil {
+		return err
+	}
+
+	var matters []sponsoredMatter
+	_, err = env.DB.From("matters").
+		Select("matter_sponsors, matter_intro_date, matter_passed_date", "", false).
+		ExecuteTo(&matters)
+	if err != nil {
+		return fmt.Errorf("failed to load matters: %w", err)
+	}
+	slog.InfoContext(ctx, "computing metrics", "officials", len(officials), "matters", len(matters))
+
+	plan.fetched(db.EntityMetrics, len(officials))
+	personIDs := make([]string, len(officials))
+	for i, o := range officials {
+		personIDs[i] = strconv.Itoa(o.PersonID)
+	}
+	stored, err := loadRows(env, "person_metrics", "person_id", personIDs, metricsColumns)
+	if err != nil {
+		return err
+	}
+
+	// Participation is measured against the official who voted most
+	tallies := make([]voteTally, len(officials))
+	failed := make([]bool, len(officials))
+	mostVotes := 0
+	for i, o := range officials {
+		tallies[i], err = tallyVotes(env, o.FullName)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to query votes", "person_id", o.PersonID, "error", err)
+			failed[i] = true
+			plan.failed(db.EntityMetrics, 1)
+			continue
+		}
+		if tallies[i].Total > mostVotes {
+			mostVotes = tallies[i].Total
+		}
+	}
+
+	for i, o := range officials {
+		if failed[i] {
+			continue
+		}
+		termStart, _ := time.Parse("2006-01-02", o.TermStart)
+		introduced, introducedThisTerm, passed := sponsorship(matters, o.FullName, termStart)
+
+		t := tallies[i]
+		participation := 0.0
+		if mostVotes > 0 {
+			participation = 100 * float64(t.Total) / float64(mostVotes)
+		}
+		transparency := transparencyScore(participation, attendanceEstimate, introducedThisTerm)
+
+		row := map[string]interface{}{
+			"person_id":          o.PersonID,
+			"bills_introduced":   introduced,
+			"bills_passed":       passed,
+			"total_votes":        t.Total,
+			"votes_yea":          t.Yea,
+			"votes_nay":          t.Nay,
+			"votes_abstain":      t.Abstain,
+			"votes_absent":       t.Absent,
+			"attendance_rate":    attendanceEstimate,
+			"transparency_score": math.Round(transparency*100) / 100, // stored as DECIMAL(5,2)
+			"last_calculated_at": time.Now().Format(time.RFC3339),
+		}
+
+		plan.diff(target{
+			entity:   db.EntityMetrics,
+			table:    "person_metrics",
+			key:      "person_id",
+			value:    personIDs[i],
+			label:    o.FullName,
+			volatile: []string{"last_calculated_at"},
+		}, stored[personIDs[i]], row)
+	}
+	return nil
+}
+
+// sponsorNames returns the names of a matter's sponsors. Sponsors are
+// stored as Legistar's MatterSponsors, either as JSON or as a JSON string
+// holding it.
+func sponsorNames(raw json.RawMessage) []string {
+	var encoded string
+	if json.Unmarshal(raw, &encoded) == nil {
+		raw = json.RawMessage(encoded)
+	}
+	var sponsors []cityapi.MatterSponsor
+	if err := json.Unmarshal(raw, &sponsors); err != nil {
+		return nil
+	}
+	names := make([]string, len(sponsors))
+	for i, sponsor := range sponsors {
+		names[i] = sponsor.MatterSponsorName
+	}
+	return names
+}
+
+// sponsoredBy reports whether one of names is fullName, written however
+// Legistar writes it, e.g. "Hopkins, Brian"
+func sponsoredBy(names []string, fullName string) bool {
+	for _, name := range names {
+		if resolver.SameName(name, fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+// sponsorship counts the matters fullName sponsored, those introduced since
+// termStart and those passed
+func sponsorship(matters []sponsoredMatter, fullName string, termStart time.Time) (introduced, introducedThisTerm, passed int) {
+	for _, m := range matters {
+		if !sponsoredBy(sponsorNames(m.Sponsors), fullName) {
+			continue
+		}
+		introduced++
+		if m.PassedDate != nil {
+			passed++
+		}
+		if m.IntroDate != nil {
+			if t, err := time.Parse(time.RFC3339, *m.IntroDate); err == nil && !t.Before(termStart) {
+				introducedThisTerm++
+			} else if t, err := time.Parse(legistarDateLayout, *m.IntroDate); err == nil && !t.Before(termStart) {
+				introducedThisTerm++
+			}
+		}
+	}
+	return introduced, introducedThisTerm, passed
+}
+
+// transparencyScore is a 0-100 composite: 40% voting participation, 30%
+// attendance, up to 20 points for bills introduced this term and a 10 point
+// engagement baseline
+func transparencyScore(participation, attendance float64, billsThisTerm int) float64 {
+	activity := float64(billsThisTerm) * 2
+	if activity > 20 {
+		activity = 20
+	}
+	return participation*0.4 + attendance*0.3 + activity + 10
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/overrides"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+)
+
+// pinned are field values pinned by overrides: by table, then by the value
+// of the table's key column, then by field
+type pinned map[string]map[string]map[string]pin
+
+// pin is a pinned field value
+type pin struct {
+	id      int64 // the field_overrides row
+	value   interface{}
+	updated time.Time
+}
+
+// source returns the provenance of a value pinned by p
+func (p pin) source() provenance.Source {
+	return provenance.Source{System: provenance.SystemOverride, RecordID: fmt.Sprintf("field_overrides/%d", p.id), FetchedAt: p.updated}
+}
+
+// loadOverrides returns every field pinned by an override
+func loadOverrides(env *Env) (pinned, error) {
+	var rows []struct {
+		ID        int64       `json:"id"`
+		Entity    string      `json:"entity"`
+		EntityID  string      `json:"entity_id"`
+		Field     string      `json:"field"`
+		Value     interface{} `json:"value"`
+		UpdatedAt time.Time   `json:"updated_at"`
+	}
+	_, err := env.DB.From("field_overrides").
+		Select("id,entity,entity_id,field,value,updated_at", "", false).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load overrides: %w", err)
+	}
+
+	pins := pinned{}
+	for _, row := range rows {
+		entity, ok := overrides.Lookup(row.Entity)
+		if !ok || !entity.Pinnable(row.Field) {
+			continue
+		}
+		if pins[entity.Table] == nil {
+			pins[entity.Table] = map[string]map[string]pin{}
+		}
+		if pins[entity.Table][row.EntityID] == nil {
+			pins[entity.Table][row.EntityID] = map[string]pin{}
+		}
+		pins[entity.Table][row.EntityID][row.Field] = pin{id: row.ID, value: row.Value, updated: row.UpdatedAt}
+	}
+	return pins, nil
+}
+
+// apply returns desired with the fields pinned for the row t writes set to
+// their pinned values, the pins applied by field and the fields whose
+// upstream value was replaced. Only rows matched on their table's override
+// key are pinned.
+func (pins pinned) apply(t target, desired map[string]interface{}) (map[string]interface{}, map[string]pin, []string) {
+	entity, ok := overrides.ForTable(t.table)
+	if !ok || t.key != entity.Key || t.value == "" {
+		return desired, nil, nil
+	}
+	fields := pins[t.table][t.value]
+	if len(fields) == 0 {
+		return desired, nil, nil
+	}
+
+	merged := make(map[string]interface{}, len(desired))
+	for name, v := range desired {
+		merged[name] = v
+	}
+	applied := map[string]pin{}
+	var replaced []string
+	for name, p := range fields {
+		upstream, ok := desired[name]
+		if !ok {
+			continue
+		}
+		merged[name] = p.value
+		applied[name] = p
+		if !sameValue(normalize(upstream), normalize(p.value)) {
+			replaced = append(replaced, name)
+		}
+	}
+	sort.Strings(replaced)
+	return merged, applied, replaced
+}
+
+// pinnedNote adds the fields an override kept to a change's note
+func pinnedNote(note string, replaced []string) string {
+	pin := "kept by an override: " + strings.Join(replaced, ", ")
+	if note == "" {
+		return pin
+	}
+	return note + "; " + pin
+}
@@ -0,0 +1,722 @@
+package jobs
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+	"github.com/Jsanchez767/InfluencePower/backend/resolver"
+	"github.com/Jsanchez767/InfluencePower/backend/review"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// ELMSExportURL is the City Clerk's person export, a CSV of Chicago alderpersons
+var ELMSExportURL = "https://api.chicityclerkelms.chicago.gov/export/person"
+
+// planPeople plans the people holding current offices
+func planPeople(ctx context.Context, env *Env, plan *Plan) error {
+	if env.Source == SourceELMS {
+		return planELMSPeople(ctx, env, plan)
+	}
+	return planLegistarPeople(ctx, env, plan)
+}
+
+// planTerms plans the terms of current office holders. People must be
+// planned or synced first.
+func planTerms(ctx context.Context, env *Env, plan *Plan) error {
+	if env.Source == SourceELMS {
+		return planELMSTerms(ctx, env, plan)
+	}
+	return planLegistarTerms(ctx, env, plan)
+}
+
+// personColumns are the people columns the sync jobs write
+var personColumns = []string{"id", "first_name", "last_name", "full_name", "email", "phone", "website", "image_url", "headshot_last_updated", "external_ids"}
+
+// termColumns are the terms columns the sync jobs write
+var termColumns = []string{"id", "person_id", "position_id", "start_date", "end_date", "external_id", "external_guid", "term_number", "election_type"}
+
+// legistarRecord describes the holder of an office record to the resolver
+func legistarRecord(record cityapi.OfficeRecord) resolver.Record {
+	r := resolver.Record{
+		Source:      SourceLegistar,
+		ExternalIDs: map[string]string{"legistar_id": strconv.Itoa(record.OfficeRecordPersonID)},
+		Name:        record.OfficeRecordFullName,
+		Email:       record.OfficeRecordEmail,
+		Ward:        extractWard(record),
+	}
+	r.Start, _ = time.Parse(legistarDateLayout, record.OfficeRecordStartDate)
+	r.End, _ = time.Parse(legistarDateLayout, record.OfficeRecordEndDate)
+	return r
+}
+
+// legistarRecordKey identifies the holder of an office record in review items
+func legistarRecordKey(record cityapi.OfficeRecord) string {
+	return fmt.Sprintf("legistar_id=%d", record.OfficeRecordPersonID)
+}
+
+// legistarRecordKeys returns the legistarRecordKey of each record
+func legistarRecordKeys(records []cityapi.OfficeRecord) []string {
+	keys := make([]string, len(records))
+	for i, record := range records {
+		keys[i] = legistarRecordKey(record)
+	}
+	return keys
+}
+
+// officeRecordKey identifies an office record in position review items
+func officeRecordKey(record cityapi.OfficeRecord) string {
+	return fmt.Sprintf("office_record_id=%d", record.OfficeRecordID)
+}
+
+// legistarPersonChange is the ID of the change creating the person with a
+// Legistar person ID
+func legistarPersonChange(legistarID int) string {
+	return fmt.Sprintf("people/legistar_id=%d", legistarID)
+}
+
+// planLegistarPeople creates or updates a person for each current office
+// record. Records are resolved against the stored people, so a person
+// entered by hand or from the ELMS export is linked to their Legistar IDs
+// rather than duplicated.
+func planLegistarPeople(ctx context.Context, env *Env, plan *Plan) error {
+	records, err := currentOfficeRecords(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	persons, err := env.Legistar.GetPersons()
+	if err != nil {
+		return fmt.Errorf("failed to fetch persons: %w", err)
+	}
+	slog.InfoContext(ctx, "fetched persons", "count", len(persons))
+	fetchedAt := time.Now().UTC()
+
+	personMap := make(map[int]cityapi.Person)
+	for _, p := range persons {
+		personMap[p.PersonID] = p
+	}
+
+	plan.fetched(db.EntityPeople, len(records))
+
+	stored, err := loadStoredPeople(env)
+	if err != nil {
+		return err
+	}
+	if err := stored.loadReviews(env, SourceLegistar, legistarRecordKeys(records)); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		rctx := logging.With(ctx, "legistar_person_id", record.OfficeRecordPersonID, "full_name", record.OfficeRecordFullName)
+		current, ok := stored.resolve(rctx, legistarRecord(record), legistarRecordKey(record), record)
+		if !ok {
+			continue
+		}
+
+		externalIDs := map[string]interface{}{"legistar_id": record.OfficeRecordPersonID}
+		var sources map[string]provenance.Source
+		personData := map[string]interface{}{
+			"first_name": record.OfficeRecordFirstName,
+			"last_name":  record.OfficeRecordLastName,
+			"full_name":  record.OfficeRecordFullName,
+			"email":      record.OfficeRecordEmail,
+		}
+		if person, ok := personMap[record.OfficeRecordPersonID]; ok {
+			externalIDs["legistar_guid"] = person.PersonGUID
+			personData["phone"] = person.PersonPhone
+			personData["website"] = person.PersonWWW
+			personData["image_url"] = legistarImageURL(person.PersonID)
+			personData["headshot_last_updated"] = time.Now().Format(time.RFC3339)
+			sources = legistarSources(fmt.Sprintf("/persons/%d", person.PersonID), fetchedAt,
+				"phone", "website", "image_url", "headshot_last_updated")
+		}
+		personData["external_ids"] = linkExternalIDs(current, externalIDs)
+
+		plan.diff(target{
+			entity:   db.EntityPeople,
+			table:    "people",
+			key:      "id",
+			value:    keyString(current["id"]),
+			id:       legistarPersonChange(record.OfficeRecordPersonID),
+			label:    record.OfficeRecordFullName,
+			volatile: []string{"headshot_last_updated"},
+			source:   legistarSource(officeRecordPath(record), fetchedAt),
+			sources:  sources,
+		}, current, personData)
+	}
+	return planReviews(env, plan, stored.reviews)
+}
+
+// planLegistarTerms creates or updates a term for each current office
+// record, matched on the office record ID. The holder is resolved as by
+// planLegistarPeople. A record whose position cannot be worked out from its
+// title and ward is queued for review, and gets the position chosen there on
+// later runs.
+func planLegistarTerms(ctx context.Context, env *Env, plan *Plan) error {
+	records, err := currentOfficeRecords(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	plan.fetched(db.EntityTerms, len(records))
+	fetchedAt := time.Now().UTC()
+
+	recordIDs := make([]string, len(records))
+	positionKeys := make([]string, len(records))
+	for i, record := range records {
+		recordIDs[i] = strconv.Itoa(record.OfficeRecordID)
+		positionKeys[i] = reviewKey(review.KindPosition, SourceLegistar, officeRecordKey(record))
+	}
+	people, err := loadStoredPeople(env)
+	if err != nil {
+		return err
+	}
+	if err := people.loadReviews(env, SourceLegistar, legistarRecordKeys(records)); err != nil {
+		return err
+	}
+	positionReviews, err := loadReviews(env, positionKeys)
+	if err != nil {
+		return err
+	}
+	terms, err := loadRows(env, "terms", "external_id", recordIDs, termColumns)
+	if err != nil {
+		return err
+	}
+	var positions []map[string]interface{} // the jurisdiction's positions, loaded for the first review item
+
+	for i, record := range records {
+		ward := extractWard(record)
+		positionType := determinePositionType(record, ward)
+		rctx := logging.With(ctx,
+			"legistar_person_id", record.OfficeRecordPersonID,
+			"office_record_id", record.OfficeRecordID,
+			"full_name", record.OfficeRecordFullName,
+			"ward", ward)
+
+		decided := positionReviews[positionKeys[i]]
+		if decided.Status == review.StatusDismissed {
+			slog.DebugContext(rctx, "position dismissed in review, skipping")
+			continue
+		}
+		positionID, found, reason, err := legistarPosition(env, positionType, ward, decided)
+		if err != nil {
+			slog.ErrorContext(rctx, "failed to query positions", "error", err)
+			plan.failed(db.EntityTerms, 1)
+			continue
+		}
+		if !found {
+			slog.WarnContext(rctx, "position not found, queued for review", "position_type", positionType, "reason", reason)
+			if positions == nil {
+				if positions, err = loadPositions(env); err != nil {
+					return err
+				}
+			}
+			people.reviews = append(people.reviews, reviewItem{
+				Kind:       review.KindPosition,
+				Key:        positionKeys[i],
+				Source:     SourceLegistar,
+				Record:     record,
+				Reason:     reason,
+				Candidates: positions,
+				Label:      fmt.Sprintf("%s, %s", record.OfficeRecordFullName, record.OfficeRecordTitle),
+			})
+			continue
+		}
+
+		termData := map[string]interface{}{
+			"start_date": parseDate(record.OfficeRecordStartDate),
+		}
+		if endDate := parseDate(record.OfficeRecordEndDate); endDate != "" {
+			termData["end_date"] = endDate
+		}
+
+		person, ok := people.resolve(rctx, legistarRecord(record), legistarRecordKey(record), record)
+		if !ok {
+			continue
+		}
+		var refs map[string]string
+		personChange := legistarPersonChange(record.OfficeRecordPersonID)
+		if person != nil {
+			termData["person_id"] = person["id"]
+		} else if plan.creating(personChange) {
+			refs = map[string]string{"person_id": personChange}
+		} else {
+			slog.WarnContext(rctx, "person not synced yet, skipping")
+			continue
+		}
+
+		current := terms[recordIDs[i]]
+		if current == nil {
+			termData["position_id"] = positionID
+			termData["external_id"] = record.OfficeRecordID
+			termData["external_guid"] = record.OfficeRecordGUID
+		}
+		plan.diff(target{
+			entity: db.EntityTerms,
+			table:  "terms",
+			key:    "id",
+			value:  keyString(current["id"]),
+			id:     "terms/external_id=" + recordIDs[i],
+			label:  fmt.Sprintf("%s, %s", record.OfficeRecordFullName, record.OfficeRecordTitle),
+			refs:   refs,
+			source: legistarSource(officeRecordPath(record), fetchedAt),
+		}, current, termData)
+	}
+	return planReviews(env, plan, people.reviews)
+}
+
+// currentOfficeRecords fetches the City Council office records that have not
+// ended
+func currentOfficeRecords(ctx context.Context, env *Env) ([]cityapi.OfficeRecord, error) {
+	records, err := env.Legistar.GetOfficeRecords()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch office records: %w", err)
+	}
+	current := filterCurrentOfficials(records, time.Now())
+	slog.InfoContext(ctx, "fetched office records", "count", len(records), "current", len(current))
+	return current, nil
+}
+
+// filterCurrentOfficials keeps City Council records with no end date or one
+// after now
+func filterCurrentOfficials(records []cityapi.OfficeRecord, now time.Time) []cityapi.OfficeRecord {
+	var current []cityapi.OfficeRecord
+	for _, record := range records {
+		if record.OfficeRecordBodyName != "City Council" {
+			continue
+		}
+		if record.OfficeRecordEndDate == "" {
+			current = append(current, record)
+			continue
+		}
+		endDate, err := time.Parse(legistarDateLayout, record.OfficeRecordEndDate)
+		if err == nil && endDate.After(now) {
+			current = append(current, record)
+		}
+	}
+	return current
+}
+
+// wardPattern matches a ward number in an email address like
+// Ward01@cityofchicago.org or in free text
+var wardPattern = regexp.MustCompile(`[Ww]ard\s*0*(\d+)`)
+
+// extractWard returns the ward of an office record, or 0 for citywide offices
+func extractWard(record cityapi.OfficeRecord) int {
+	for _, s := range []string{record.OfficeRecordEmail, record.OfficeRecordExtraText} {
+		if matches := wardPattern.FindStringSubmatch(s); len(matches) > 1 {
+			if ward, err := strconv.Atoi(matches[1]); err == nil {
+				return ward
+			}
+		}
+	}
+	return 0
+}
+
+// determinePositionType maps an office record to a positions.position_type
+func determinePositionType(record cityapi.OfficeRecord, ward int) string {
+	title := strings.ToLower(record.OfficeRecordTitle)
+
+	switch {
+	case strings.Contains(title, "mayor"):
+		return "mayor"
+	case strings.Contains(title, "clerk"):
+		return "clerk"
+	case strings.Contains(title, "treasurer"):
+		return "treasurer"
+	case ward > 0:
+		return "alderman"
+	}
+	return "other"
+}
+
+// legistarImageURL returns the Legistar profile page of a person
+func legistarImageURL(personID int) string {
+	return fmt.Sprintf("https://chicago.legistar.com/people/%d", personID)
+}
+
+// parseDate converts a Legistar date to YYYY-MM-DD, or "" if it is empty or
+// malformed
+func parseDate(dateStr string) string {
+	t, err := time.Parse(legistarDateLayout, dateStr)
+	if err != nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// elmsRow is a current alderperson from the ELMS export
+type elmsRow struct {
+	FirstName, LastName, FullName string
+	Ward                          int
+	Phone, Email, Website         string
+}
+
+// fetchELMS fetches the ELMS export and returns the newest row per ward
+func fetchELMS(ctx context.Context, env *Env) ([]elmsRow, error) {
+	if env.Jurisdiction.Slug() != "chicago" {
+		return nil, fmt.Errorf("the ELMS export only covers Chicago, not %s", env.Jurisdiction.Name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ELMSExportURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set(middleware.RequestIDHeader, middleware.RequestIDFromContext(ctx))
+
+	resp, err := env.Legistar.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ELMS export: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ELMS export returned status %d", resp.StatusCode)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ELMS export: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("ELMS export has no rows")
+	}
+	slog.InfoContext(ctx, "fetched ELMS export", "rows", len(records)-1)
+
+	return parseELMS(ctx, records[1:]), nil
+}
+
+// parseELMS parses the data rows of the ELMS export. The export lists every
+// alderperson oldest first, so rows are read newest first and only the first
+// row per ward is kept.
+func parseELMS(ctx context.Context, records [][]string) []elmsRow {
+	var rows []elmsRow
+	seen := make(map[int]bool)
+
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		line := i + 2
+		if len(record) < 6 {
+			slog.WarnContext(ctx, "insufficient columns, skipping", "row", line)
+			continue
+		}
+
+		// Names are "Last, First Middle"
+		name := strings.TrimSpace(record[0])
+		nameParts := strings.SplitN(name, ",", 2)
+		firstNames := []string{}
+		if len(nameParts) == 2 {
+			firstNames = strings.Fields(strings.Trim(nameParts[1], "\" "))
+		}
+		if len(firstNames) == 0 {
+			slog.WarnContext(ctx, "could not parse name, skipping", "row", line, "name", name)
+			continue
+		}
+		lastName := strings.TrimSpace(strings.Trim(nameParts[0], "\""))
+
+		ward, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil || ward < 1 || ward > 50 {
+			slog.WarnContext(ctx, "invalid ward, skipping", "row", line, "ward", record[1])
+			continue
+		}
+		if seen[ward] {
+			continue
+		}
+		seen[ward] = true
+
+		rows = append(rows, elmsRow{
+			FirstName: firstNames[0],
+			LastName:  lastName,
+			FullName:  firstNames[0] + " " + lastName,
+			Ward:      ward,
+			Phone:     strings.TrimSpace(record[2]),
+			Email:     strings.TrimSpace(record[4]),
+			Website:   strings.TrimSpace(record[5]),
+		})
+	}
+	return rows
+}
+
+// elmsPersonChange is the ID of the change creating the person with a full
+// name from the ELMS export
+func elmsPersonChange(fullName string) string {
+	return "people/full_name=" + fullName
+}
+
+// elmsRecord describes an ELMS row's alderperson to the resolver
+func elmsRecord(row elmsRow) resolver.Record {
+	return resolver.Record{Source: SourceELMS, Name: row.FullName, Email: row.Email, Ward: row.Ward}
+}
+
+// elmsRecordKey identifies an ELMS row's alderperson in review items
+func elmsRecordKey(row elmsRow) string {
+	return fmt.Sprintf("ward=%d", row.Ward)
+}
+
+// elmsTermKey is the key of the term of an ELMS row, e.g.
+// "ward=1/Jane Doe". A new alderperson for the ward has a new term.
+func elmsTermKey(row elmsRow) string {
+	return fmt.Sprintf("ward=%d/%s", row.Ward, row.FullName)
+}
+
+// elmsRecordKeys returns the elmsRecordKey of each row
+func elmsRecordKeys(rows []elmsRow) []string {
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = elmsRecordKey(row)
+	}
+	return keys
+}
+
+// planELMSPeople creates or updates a person for each ward's alderperson,
+// resolved against the stored people on name, ward and email
+func planELMSPeople(ctx context.Context, env *Env, plan *Plan) error {
+	rows, err := fetchELMS(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	plan.fetched(db.EntityPeople, len(rows))
+	fetchedAt := time.Now().UTC()
+
+	stored, err := loadStoredPeople(env)
+	if err != nil {
+		return err
+	}
+	if err := stored.loadReviews(env, SourceELMS, elmsRecordKeys(rows)); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		rctx := logging.With(ctx, "full_name", row.FullName, "ward", row.Ward)
+		current, ok := stored.resolve(rctx, elmsRecord(row), elmsRecordKey(row), row)
+		if !ok {
+			continue
+		}
+
+		contact := map[string]interface{}{
+			"email":   row.Email,
+			"phone":   row.Phone,
+			"website": row.Website,
+		}
+		if current == nil {
+			contact["first_name"] = row.FirstName
+			contact["last_name"] = row.LastName
+			contact["full_name"] = row.FullName
+		}
+		plan.diff(target{
+			entity: db.EntityPeople,
+			table:  "people",
+			key:    "id",
+			value:  keyString(current["id"]),
+			id:     elmsPersonChange(row.FullName),
+			label:  fmt.Sprintf("%s, ward %d", row.FullName, row.Ward),
+			source: elmsSource(row, fetchedAt),
+		}, current, contact)
+	}
+	return planReviews(env, plan, stored.reviews)
+}
+
+// planELMSTerms creates a current term for each ward's alderperson unless
+// they already hold one. The ELMS export has no term dates, so each new term
+// is queued for review and created once an admin gives its start date.
+func planELMSTerms(ctx context.Context, env *Env, plan *Plan) error {
+	rows, err := fetchELMS(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	plan.fetched(db.EntityTerms, len(rows))
+	fetchedAt := time.Now().UTC()
+
+	people, err := loadStoredPeople(env)
+	if err != nil {
+		return err
+	}
+	if err := people.loadReviews(env, SourceELMS, elmsRecordKeys(rows)); err != nil {
+		return err
+	}
+	startKeys := make([]string, len(rows))
+	for i, row := range rows {
+		startKeys[i] = reviewKey(review.KindTermStart, SourceELMS, elmsTermKey(row))
+	}
+	startReviews, err := loadReviews(env, startKeys)
+	if err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		rctx := logging.With(ctx, "full_name", row.FullName, "ward", row.Ward)
+
+		positionID, found, err := findPosition(env, "alderman", row.Ward)
+		if err != nil {
+			slog.ErrorContext(rctx, "failed to query positions", "error", err)
+			plan.failed(db.EntityTerms, 1)
+			continue
+		}
+		if !found {
+			slog.WarnContext(rctx, "position not found for ward, skipping")
+			continue
+		}
+		rctx = logging.With(rctx, "position_id", positionID)
+
+		termData := map[string]interface{}{
+			"position_id":   positionID,
+			"term_number":   1,
+			"election_type": "general",
+		}
+
+		person, ok := people.resolve(rctx, elmsRecord(row), elmsRecordKey(row), row)
+		if !ok {
+			continue
+		}
+		var refs map[string]string
+		personChange := elmsPersonChange(row.FullName)
+		if person != nil {
+			personID := keyString(person["id"])
+			_, current, err := lookupID(env.DB.From("terms").
+				Select("id", "", false).
+				Eq("position_id", strconv.Itoa(positionID)).
+				Eq("person_id", personID).
+				Is("end_date", "null"))
+			if err != nil {
+				slog.ErrorContext(rctx, "failed to query terms", "person_id", personID, "error", err)
+				plan.failed(db.EntityTerms, 1)
+				continue
+			}
+			if current {
+				slog.DebugContext(rctx, "current term already exists", "person_id", personID)
+				continue
+			}
+			termData["person_id"] = person["id"]
+		} else if plan.creating(personChange) {
+			refs = map[string]string{"person_id": personChange}
+		} else {
+			slog.WarnContext(rctx, "person not synced yet, skipping")
+			continue
+		}
+
+		label := fmt.Sprintf("%s, alderperson of ward %d", row.FullName, row.Ward)
+		switch decided := startReviews[startKeys[i]]; decided.Status {
+		case review.StatusDismissed:
+			slog.DebugContext(rctx, "term start dismissed in review, skipping")
+			continue
+		case review.StatusResolved:
+			termData["start_date"] = decided.Resolution.StartDate
+		default:
+			slog.WarnContext(rctx, "term start unknown, queued for review")
+			people.reviews = append(people.reviews, reviewItem{
+				Kind:       review.KindTermStart,
+				Key:        startKeys[i],
+				Source:     SourceELMS,
+				Record:     row,
+				Reason:     "the ELMS export has no term dates",
+				Candidates: []interface{}{},
+				Label:      label,
+			})
+			continue
+		}
+
+		plan.diff(target{
+			entity: db.EntityTerms,
+			table:  "terms",
+			key:    "id",
+			id:     fmt.Sprintf("terms/ward=%d", row.Ward),
+			label:  label,
+			refs:   refs,
+			note:   "start_date was given in review; the ELMS export has no term dates",
+			source: elmsSource(row, fetchedAt),
+		}, nil, termData)
+	}
+	return planReviews(env, plan, people.reviews)
+}
+
+// legistarPosition returns the position of an office record of positionType
+// in ward, or the reason it has none. A resolved position review item
+// decides the position instead.
+func legistarPosition(env *Env, positionType string, ward int, decided reviewState) (id int, found bool, reason string, err error) {
+	if decided.Status == review.StatusResolved {
+		switch {
+		case decided.Resolution.PositionID != 0:
+			return decided.Resolution.PositionID, true, "", nil
+		case decided.Resolution.Ward != 0:
+			positionType, ward = "alderman", decided.Resolution.Ward
+		}
+	}
+	if positionType == "other" {
+		return 0, false, "no ward or citywide title in the office record", nil
+	}
+	id, found, err = findPosition(env, positionType, ward)
+	if !found {
+		reason = fmt.Sprintf("no %s position", positionType)
+		if ward > 0 {
+			reason += fmt.Sprintf(" for ward %d", ward)
+		}
+	}
+	return id, found, reason, err
+}
+
+// loadPositions returns the id, type, ward and title of each of the
+// jurisdiction's positions, the candidates of a position review item
+func loadPositions(env *Env) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	_, err := env.DB.From("positions").
+		Select("id,position_type,district_number,title", "", false).
+		Eq("jurisdiction_id", strconv.Itoa(env.Jurisdiction.ID)).
+		Order("id", &postgrest.OrderOpts{Ascending: true}).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions: %w", err)
+	}
+	positions := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		positions[i] = map[string]interface{}{
+			"position_id":   row["id"],
+			"position_type": row["position_type"],
+			"ward":          row["district_number"],
+			"title":         row["title"],
+		}
+	}
+	return positions, nil
+}
+
+// findPosition returns the id of the jurisdiction's position of the given
+// type, in a district for ward > 0 or citywide otherwise
+func findPosition(env *Env, positionType string, ward int) (int, bool, error) {
+	query := env.DB.From("positions").
+		Select("id", "", false).
+		Eq("jurisdiction_id", strconv.Itoa(env.Jurisdiction.ID)).
+		Eq("position_type", positionType)
+	if ward > 0 {
+		query = query.Eq("district_number", strconv.Itoa(ward))
+	} else {
+		query = query.Is("district_number", "null")
+	}
+	return lookupID(query)
+}
+
+// lookupID runs a query selecting id and returns the first row's id
+func lookupID(query *postgrest.FilterBuilder) (int, bool, error) {
+	var rows []struct {
+		ID int `json:"id"`
+	}
+	if _, err := query.ExecuteTo(&rows); err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	return rows[0].ID, true, nil
+}
@@ -0,0 +1,625 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/audit"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
+)
+
+// Operations of a change
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Plan is the set of writes a sync would make, computed by comparing
+// upstream records with the rows already stored. Applying a plan executes
+// exactly its changes, so a plan reviewed on a dry run is what gets written.
+type Plan struct {
+	Jurisdiction Jurisdiction   `json:"jurisdiction"`
+	Source       string         `json:"source,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Jobs         []string       `json:"jobs"`
+	Fetched      map[string]int `json:"fetched"`          // upstream records read, per entity
+	Failed       map[string]int `json:"failed,omitempty"` // upstream records that could not be read
+	Changes      []Change       `json:"changes"`
+
+	// overrides are the fields pinned by editors, loaded by the first job
+	// planned; diff writes them in place of upstream values
+	overrides pinned
+}
+
+// Change creates, updates or deletes one row
+type Change struct {
+	ID     string        `json:"id"` // unique in the plan, e.g. people/legistar_id=162
+	Op     string        `json:"op"`
+	Entity string        `json:"entity,omitempty"` // sync_state entity the change counts towards, if any
+	Table  string        `json:"table"`
+	Key    string        `json:"key"`             // column matching the row
+	Value  string        `json:"value,omitempty"` // its value; empty for rows created with a new id
+	Label  string        `json:"label,omitempty"` // what the row is, for people reading the plan
+	Fields []FieldChange `json:"fields,omitempty"`
+
+	// Refs sets fields to the id of a row created earlier in the plan,
+	// e.g. the person_id of a term for a new person
+	Refs map[string]string `json:"refs,omitempty"`
+	Note string            `json:"note,omitempty"`
+
+	// Source is where the values written came from, and FieldSources where
+	// the fields that came from elsewhere did; Apply records them as the
+	// fields' provenance
+	Source       *provenance.Source           `json:"source,omitempty"`
+	FieldSources map[string]provenance.Source `json:"field_sources,omitempty"`
+}
+
+// FieldChange is a field's stored and intended value. Old is null for
+// creates and New is null for deletes.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// NewPlan starts an empty plan for a jurisdiction
+func NewPlan(j Jurisdiction, source string) *Plan {
+	return &Plan{
+		Jurisdiction: j,
+		Source:       source,
+		CreatedAt:    time.Now().UTC(),
+		Fetched:      map[string]int{},
+		Changes:      []Change{},
+	}
+}
+
+// LoadPlan reads a plan written with WritePlan
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid plan %s: %w", path, err)
+	}
+	for _, c := range p.Changes {
+		switch c.Op {
+		case OpCreate, OpUpdate, OpDelete:
+		default:
+			return nil, fmt.Errorf("invalid plan %s: change %s has unknown op %q", path, c.ID, c.Op)
+		}
+		if c.Op != OpCreate && c.Value == "" {
+			return nil, fmt.Errorf("invalid plan %s: change %s does not say which row to %s", path, c.ID, c.Op)
+		}
+	}
+	return &p, nil
+}
+
+// WriteJSON writes the plan as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// Summary counts the changes by operation
+func (p *Plan) Summary() (creates, updates, deletes int) {
+	for _, c := range p.Changes {
+		switch c.Op {
+		case OpCreate:
+			creates++
+		case OpUpdate:
+			updates++
+		case OpDelete:
+			deletes++
+		}
+	}
+	return creates, updates, deletes
+}
+
+// textValueLimit shortens long values, such as matter text, in WriteText
+const textValueLimit = 80
+
+// WriteText writes the plan as a diff for people to review
+func (p *Plan) WriteText(w io.Writer) error {
+	creates, updates, deletes := p.Summary()
+	fmt.Fprintf(w, "Plan for %s", p.Jurisdiction.Name)
+	if p.Source != "" {
+		fmt.Fprintf(w, " from %s", p.Source)
+	}
+	fmt.Fprintf(w, " (%s): %d to create, %d to update, %d to delete\n",
+		strings.Join(p.Jobs, ", "), creates, updates, deletes)
+
+	for _, c := range p.Changes {
+		sign := map[string]string{OpCreate: "+", OpUpdate: "~", OpDelete: "-"}[c.Op]
+		fmt.Fprintf(w, "\n%s %s %s", sign, c.Op, c.ID)
+		if c.Label != "" {
+			fmt.Fprintf(w, "  %s", c.Label)
+		}
+		fmt.Fprintln(w)
+		for _, f := range c.Fields {
+			switch c.Op {
+			case OpCreate:
+				fmt.Fprintf(w, "    %s: %s\n", f.Field, textValue(f.New))
+			case OpUpdate:
+				fmt.Fprintf(w, "    %s: %s -> %s\n", f.Field, textValue(f.Old), textValue(f.New))
+			case OpDelete:
+				fmt.Fprintf(w, "    %s: %s\n", f.Field, textValue(f.Old))
+			}
+		}
+		for _, field := range sortedKeys(c.Refs) {
+			fmt.Fprintf(w, "    %s: id of %s\n", field, c.Refs[field])
+		}
+		if c.Note != "" {
+			fmt.Fprintf(w, "    note: %s\n", c.Note)
+		}
+	}
+
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// textValue formats v as JSON, shortened for WriteText
+func textValue(v interface{}) string {
+	b, _ := json.Marshal(v)
+	s := string(b)
+	if len(s) > textValueLimit {
+		s = s[:textValueLimit] + "..."
+	}
+	return s
+}
+
+// target identifies the row a change writes
+type target struct {
+	entity string
+	table  string
+	key    string // column matching an existing row
+	value  string // its value; empty when a new row gets a generated id
+	id     string // change ID when key and value do not identify the row
+	label  string
+
+	// volatile fields, such as computed-at timestamps, always differ; they
+	// are written with other changes but do not cause one
+	volatile []string
+	refs     map[string]string
+	note     string
+
+	// source is where the values came from, and sources where the fields
+	// that came from elsewhere did
+	source  *provenance.Source
+	sources map[string]provenance.Source
+}
+
+// changeID returns the ID of a change to t
+func (t target) changeID() string {
+	if t.id != "" {
+		return t.id
+	}
+	return t.table + "/" + t.key + "=" + t.value
+}
+
+// planMark is the state of a plan before a job adds to it
+type planMark struct {
+	changes int
+	fetched map[string]int
+	failed  map[string]int
+}
+
+// mark records the plan's state for rollback
+func (p *Plan) mark() planMark {
+	m := planMark{changes: len(p.Changes), fetched: map[string]int{}, failed: map[string]int{}}
+	for entity, n := range p.Fetched {
+		m.fetched[entity] = n
+	}
+	for entity, n := range p.Failed {
+		m.failed[entity] = n
+	}
+	return m
+}
+
+// rollback drops everything added to the plan since m
+func (p *Plan) rollback(m planMark) {
+	p.Changes = p.Changes[:m.changes]
+	p.Fetched = m.fetched
+	p.Failed = m.failed
+}
+
+// since returns a plan of the changes added since m
+func (p *Plan) since(m planMark) *Plan {
+	return &Plan{Changes: p.Changes[m.changes:]}
+}
+
+// fetched records n upstream records read for entity
+func (p *Plan) fetched(entity string, n int) {
+	p.Fetched[entity] += n
+}
+
+// failed records n upstream records of entity that could not be read
+func (p *Plan) failed(entity string, n int) {
+	if p.Failed == nil {
+		p.Failed = map[string]int{}
+	}
+	p.Failed[entity] += n
+}
+
+// diff adds the change that turns current into desired: a create when
+// current is nil, an update of the differing fields, or nothing. Fields
+// pinned by an override keep their pinned value.
+func (p *Plan) diff(t target, current, desired map[string]interface{}) {
+	desired, pins, replaced := p.overrides.apply(t, desired)
+	if len(replaced) > 0 {
+		t.note = pinnedNote(t.note, replaced)
+	}
+
+	var fields []FieldChange
+	changed := len(t.refs) > 0
+	for _, name := range sortedKeys(desired) {
+		want := normalize(desired[name])
+		if current == nil {
+			fields = append(fields, FieldChange{Field: name, New: want})
+			continue
+		}
+		have := normalize(current[name])
+		if sameValue(have, want) {
+			continue
+		}
+		fields = append(fields, FieldChange{Field: name, Old: have, New: want})
+		if !contains(t.volatile, name) {
+			changed = true
+		}
+	}
+	if current != nil && !changed {
+		return
+	}
+
+	op := OpCreate
+	if current != nil {
+		op = OpUpdate
+	}
+	var sources map[string]provenance.Source
+	if t.source != nil {
+		for _, f := range fields {
+			source, ok := t.sources[f.Field]
+			if p, pinned := pins[f.Field]; pinned {
+				source, ok = p.source(), true
+			}
+			if ok {
+				if sources == nil {
+					sources = map[string]provenance.Source{}
+				}
+				sources[f.Field] = source
+			}
+		}
+	}
+	p.Changes = append(p.Changes, Change{
+		ID:           t.changeID(),
+		Op:           op,
+		Entity:       t.entity,
+		Table:        t.table,
+		Key:          t.key,
+		Value:        t.value,
+		Label:        t.label,
+		Fields:       fields,
+		Refs:         t.refs,
+		Note:         t.note,
+		Source:       t.source,
+		FieldSources: sources,
+	})
+}
+
+// delete adds a change deleting the stored row current
+func (p *Plan) delete(t target, current map[string]interface{}) {
+	var fields []FieldChange
+	for _, name := range sortedKeys(current) {
+		fields = append(fields, FieldChange{Field: name, Old: current[name]})
+	}
+	p.Changes = append(p.Changes, Change{
+		ID:     t.changeID(),
+		Op:     OpDelete,
+		Entity: t.entity,
+		Table:  t.table,
+		Key:    t.key,
+		Value:  t.value,
+		Label:  t.label,
+		Fields: fields,
+		Note:   t.note,
+	})
+}
+
+// creates returns the plan's creates in table
+func (p *Plan) creates(table string) []Change {
+	var creates []Change
+	for _, c := range p.Changes {
+		if c.Op == OpCreate && c.Table == table {
+			creates = append(creates, c)
+		}
+	}
+	return creates
+}
+
+// creating reports whether the plan creates the row with change ID id
+func (p *Plan) creating(id string) bool {
+	for _, c := range p.Changes {
+		if c.Op == OpCreate && c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// row returns the values the change writes
+func (c Change) row() map[string]interface{} {
+	row := make(map[string]interface{}, len(c.Fields))
+	for _, f := range c.Fields {
+		row[f.Field] = f.New
+	}
+	return row
+}
+
+// Apply executes the plan's changes in order with a writer and returns the
+// records written and failed per entity. A failed change is logged with the
+// row it would have written and skipped, along with any change that refers
+// to the row it would have created. The counts are then recorded in
+// sync_state.
+func Apply(ctx context.Context, env *Env, plan *Plan) (Counts, error) {
+	ctx, span := tracing.Start(ctx, "apply plan")
+	env = env.withContext(ctx)
+	creates, updates, deletes := plan.Summary()
+	slog.InfoContext(ctx, "applying plan", "creates", creates, "updates", updates, "deletes", deletes)
+
+	counts := Counts{}
+	for entity, n := range plan.Fetched {
+		counts.entity(entity).Fetched = n
+	}
+	for entity, n := range plan.Failed {
+		counts.entity(entity).Failed = n
+	}
+
+	progress := newProgress(ctx, "apply progress", len(plan.Changes), 0)
+	var failures []rowError
+	var sources []provenance.Field
+	var entries []audit.Entry
+	runID := middleware.RequestIDFromContext(ctx)
+	actor := env.Actor
+	if actor == "" {
+		actor = defaultActor
+	}
+	w := newWriter(env)
+	w.write(ctx, plan.Changes, func(c Change, err error) {
+		cctx := logging.With(ctx, "change", c.ID, "op", c.Op)
+		if err != nil {
+			failure := newRowError(c, err)
+			failures = append(failures, failure)
+			slog.ErrorContext(cctx, "change failed", "table", failure.Table, "key", failure.Key, "value", failure.Value, "error", err)
+			if c.Entity != "" {
+				counts.entity(c.Entity).Failed++
+			}
+			progress.add(1, 1)
+			return
+		}
+		slog.DebugContext(cctx, "change applied")
+		if c.Op != OpDelete && c.Entity != "" {
+			counts.entity(c.Entity).Upserted++
+		}
+		sources = append(sources, fieldSources(w, c, runID)...)
+		entries = append(entries, auditEntry(w, c, actor, runID))
+		progress.add(1, 0)
+	})
+	if len(plan.Changes) > 0 {
+		progress.finish()
+	}
+	if len(failures) > 0 {
+		slog.ErrorContext(ctx, "changes failed", "count", len(failures), "changes", failedChanges(failures))
+	}
+	// The rows are written; provenance or audit entries missing for them
+	// are not worth failing the run over
+	if err := provenance.Record(env.DB, sources); err != nil {
+		slog.ErrorContext(ctx, "failed to record provenance", "fields", len(sources), "error", err)
+	}
+	if err := audit.Append(env.DB, entries); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit log", "entries", len(entries), "error", err)
+	}
+
+	var err error
+	if len(counts) > 0 {
+		err = db.RecordSyncCounts(env.DB, counts.Values())
+	}
+	tracing.End(span, err)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record sync counts", "error", err)
+		return counts, err
+	}
+	slog.InfoContext(ctx, "plan applied", summary(counts)...)
+	return counts, nil
+}
+
+// diffRows adds the changes that write rows to table, matched with the
+// stored rows on the key column. label describes a row for people reading
+// the plan. The rows are Legistar records, at /{table}/{key} in the Web API.
+func (p *Plan) diffRows(env *Env, entity, table, key string, rows []map[string]interface{}, label func(row map[string]interface{}) string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	fetchedAt := time.Now().UTC()
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		values[i] = keyString(normalize(row[key]))
+	}
+	stored, err := loadRows(env, table, key, values, columnsOf(rows[0]))
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		p.diff(target{
+			entity: entity,
+			table:  table,
+			key:    key,
+			value:  values[i],
+			label:  label(row),
+			source: legistarSource("/"+table+"/"+values[i], fetchedAt),
+		}, stored[values[i]], row)
+	}
+	return nil
+}
+
+// loadRows returns the rows of table whose key column is one of values,
+// selecting columns, by key value
+func loadRows(env *Env, table, key string, values []string, columns []string) (map[string]map[string]interface{}, error) {
+	if !contains(columns, key) {
+		columns = append([]string{key}, columns...)
+	}
+	batch, err := selectIn(env, table, key, values, columns)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]map[string]interface{}, len(batch))
+	for _, row := range batch {
+		rows[keyString(row[key])] = row
+	}
+	return rows, nil
+}
+
+// selectIn returns columns of the rows of table whose column is one of values
+func selectIn(env *Env, table, column string, values []string, columns []string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	values = unique(values)
+
+	// Keep query strings short
+	const chunk = 100
+	for start := 0; start < len(values); start += chunk {
+		end := start + chunk
+		if end > len(values) {
+			end = len(values)
+		}
+		var batch []map[string]interface{}
+		_, err := env.DB.From(table).
+			Select(strings.Join(columns, ","), "", false).
+			In(column, values[start:end]).
+			ExecuteTo(&batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", table, err)
+		}
+		rows = append(rows, batch...)
+	}
+	return rows, nil
+}
+
+// columnsOf returns the sorted field names of row
+func columnsOf(row map[string]interface{}) []string {
+	return sortedKeys(row)
+}
+
+// keyString formats a key value read from JSON, e.g. 162 rather than 1.62e+02
+func keyString(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+	case string:
+		return v
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// normalize converts v to the form it takes when read back as JSON
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// timeLayouts are the forms dates and timestamps take in Legistar and
+// PostgREST responses
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "2006-01-02"}
+
+// sameValue reports whether a stored value equals a normalized intended
+// value, treating timestamps in different layouts and JSON stored as text as
+// equal
+func sameValue(stored, want interface{}) bool {
+	if reflect.DeepEqual(stored, want) {
+		return true
+	}
+	s, sok := stored.(string)
+	w, wok := want.(string)
+	if sok && wok {
+		st, err1 := parseTime(s)
+		wt, err2 := parseTime(w)
+		return err1 == nil && err2 == nil && st.Equal(wt)
+	}
+	var decoded interface{}
+	if wok && json.Unmarshal([]byte(w), &decoded) == nil {
+		return reflect.DeepEqual(stored, decoded)
+	}
+	if sok && json.Unmarshal([]byte(s), &decoded) == nil {
+		return reflect.DeepEqual(decoded, want)
+	}
+	return false
+}
+
+// parseTime parses s in any of timeLayouts, as UTC when it has no zone
+func parseTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
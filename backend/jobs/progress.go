@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// progressInterval is how often long steps, such as fetching the votes of
+// every matter, log how far they have got
+var progressInterval = 10 * time.Second
+
+// progress counts the items a long step has handled and logs its rate and
+// the time it expects to take. It is safe for concurrent use.
+type progress struct {
+	ctx   context.Context
+	msg   string
+	total int
+
+	mu      sync.Mutex
+	done    int // items handled, including failed ones
+	failed  int
+	resumed int // items handled before the step started, e.g. by an earlier run
+	start   time.Time
+	lastLog time.Time
+}
+
+// newProgress starts counting a step of total items, of which resumed were
+// already handled
+func newProgress(ctx context.Context, msg string, total, resumed int) *progress {
+	now := time.Now()
+	return &progress{ctx: ctx, msg: msg, total: total, done: resumed, resumed: resumed, start: now, lastLog: now}
+}
+
+// add records n more items handled, of which failed failed, and logs the
+// progress if progressInterval has passed since it was last logged
+func (p *progress) add(n, failed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	p.failed += failed
+	if now := time.Now(); now.Sub(p.lastLog) >= progressInterval {
+		p.lastLog = now
+		p.log(now)
+	}
+}
+
+// finish logs the final counts
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.log(time.Now())
+}
+
+// log logs the counts, rate and remaining time. Called with p.mu held.
+func (p *progress) log(now time.Time) {
+	attrs := []interface{}{"done", p.done, "total", p.total, "failed", p.failed}
+	elapsed := now.Sub(p.start)
+	if handled := p.done - p.resumed; handled > 0 && elapsed > 0 {
+		rate := float64(handled) / elapsed.Seconds()
+		attrs = append(attrs, "per_second", float64(int(rate*100))/100)
+		if remaining := p.total - p.done; remaining > 0 {
+			attrs = append(attrs, "remaining", time.Duration(float64(remaining)/rate*float64(time.Second)).Round(time.Second).String())
+		}
+	}
+	slog.InfoContext(p.ctx, p.msg, attrs...)
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/overrides"
+	"github.com/Jsanchez767/InfluencePower/backend/provenance"
+)
+
+// fieldSources returns the provenance of the fields c wrote, or nil if c
+// has no source or does not write a person, term or matter
+func fieldSources(w *writer, c Change, runID string) []provenance.Field {
+	if c.Source == nil || c.Op == OpDelete {
+		return nil
+	}
+	entity, ok := overrides.ForTable(c.Table)
+	if !ok || c.Key != entity.Key {
+		return nil
+	}
+	id := w.rowKey(c)
+	if id == "" {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	fields := make([]provenance.Field, 0, len(c.Fields))
+	for _, f := range c.Fields {
+		source, ok := c.FieldSources[f.Field]
+		if !ok {
+			source = *c.Source
+		}
+		source.RunID = runID
+		fields = append(fields, provenance.Field{Entity: entity.Name, EntityID: id, Field: f.Field, Source: source, UpdatedAt: now})
+	}
+	return fields
+}
+
+// legistarSource returns the source of values read from the Legistar record
+// at path
+func legistarSource(path string, fetchedAt time.Time) *provenance.Source {
+	return &provenance.Source{System: provenance.SystemLegistar, RecordID: path, FetchedAt: fetchedAt}
+}
+
+// legistarSources returns the source of each of fields, read from the
+// Legistar record at path rather than the one the rest of the row came from
+func legistarSources(path string, fetchedAt time.Time, fields ...string) map[string]provenance.Source {
+	sources := make(map[string]provenance.Source, len(fields))
+	for _, field := range fields {
+		sources[field] = *legistarSource(path, fetchedAt)
+	}
+	return sources
+}
+
+// officeRecordPath is the Legistar path of an office record
+func officeRecordPath(record cityapi.OfficeRecord) string {
+	return fmt.Sprintf("/officerecords/%d", record.OfficeRecordID)
+}
+
+// elmsSource returns the source of values read from an ELMS row
+func elmsSource(row elmsRow, fetchedAt time.Time) *provenance.Source {
+	return &provenance.Source{System: provenance.SystemELMS, RecordID: elmsRecordKey(row), FetchedAt: fetchedAt}
+}
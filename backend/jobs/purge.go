@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/softdelete"
+)
+
+// DefaultRetention is how long soft-deleted rows are kept before the purge
+// job removes them
+const DefaultRetention = 90 * 24 * time.Hour
+
+// retention returns the configured retention or DefaultRetention
+func (e *Env) retention() time.Duration {
+	if e.Retention > 0 {
+		return e.Retention
+	}
+	return DefaultRetention
+}
+
+// planPurge deletes the rows soft-deleted longer ago than the retention
+// period. Dependents are removed before the rows they belong to.
+func planPurge(ctx context.Context, env *Env, plan *Plan) error {
+	cutoff := time.Now().Add(-env.retention()).UTC()
+	for i := len(softdelete.Entities) - 1; i >= 0; i-- {
+		table := softdelete.Entities[i].Table
+		var rows []map[string]interface{}
+		_, err := env.DB.From(table).
+			Select("*", "", false).
+			Lt("deleted_at", cutoff.Format(time.RFC3339)).
+			ExecuteTo(&rows)
+		if err != nil {
+			return fmt.Errorf("failed to load deleted %s: %w", table, err)
+		}
+		slog.InfoContext(ctx, "purging deleted rows", "table", table, "rows", len(rows), "cutoff", cutoff)
+		for _, row := range rows {
+			id := keyString(row["id"])
+			plan.delete(target{
+				table: table,
+				key:   "id",
+				value: id,
+				label: fmt.Sprintf("%s %s, deleted %v by %v", table, id, row["deleted_at"], row["deleted_by"]),
+				note:  "deleted longer than the retention period",
+			}, row)
+		}
+	}
+	return nil
+}
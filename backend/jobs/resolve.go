@@ -0,0 +1,321 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/resolver"
+	"github.com/Jsanchez767/InfluencePower/backend/review"
+)
+
+// storedPeople are the stored people upstream records are resolved against
+type storedPeople struct {
+	candidates []resolver.Candidate
+	rows       map[int]map[string]interface{} // personColumns, by id
+	deleted    map[int]bool                   // soft-deleted people, by id
+	reviewed   map[string]reviewState         // decided person_match items, by key
+	reviews    []reviewItem                   // ambiguous records, queued by planReviews
+}
+
+// reviewItem is a sync decision left for a person, a review_items row
+type reviewItem struct {
+	Kind       string
+	Key        string
+	Source     string
+	Record     interface{}
+	Reason     string
+	Candidates interface{}
+	Label      string
+}
+
+// reviewState is how a stored review item was decided
+type reviewState struct {
+	Status     string
+	Resolution review.Resolution
+	Candidates []int // the person_id of each candidate, for person_match items
+}
+
+// reviewKey is the key of the review item of kind about an upstream record
+// of source, e.g. "person_match/legistar/legistar_id=162"
+func reviewKey(kind, source, record string) string {
+	return kind + "/" + source + "/" + record
+}
+
+// loadReviews returns the state of the stored review items with the given
+// keys, by key. Open items are left out: only decided items change what a
+// sync does.
+func loadReviews(env *Env, keys []string) (map[string]reviewState, error) {
+	rows, err := loadRows(env, "review_items", "key", keys, []string{"status", "resolution", "candidates"})
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]reviewState, len(rows))
+	for key, row := range rows {
+		status := stringValue(row["status"])
+		if status == review.StatusOpen {
+			continue
+		}
+		state := reviewState{Status: status}
+		if resolution, ok := row["resolution"].(map[string]interface{}); ok {
+			state.Resolution.PersonID, _ = strconv.Atoi(keyString(resolution["person_id"]))
+			state.Resolution.NewPerson, _ = resolution["new_person"].(bool)
+			state.Resolution.PositionID, _ = strconv.Atoi(keyString(resolution["position_id"]))
+			state.Resolution.Ward, _ = strconv.Atoi(keyString(resolution["ward"]))
+			state.Resolution.StartDate = stringValue(resolution["start_date"])
+		}
+		candidates, _ := row["candidates"].([]interface{})
+		for _, c := range candidates {
+			candidate, _ := c.(map[string]interface{})
+			if id, err := strconv.Atoi(keyString(candidate["person_id"])); err == nil {
+				state.Candidates = append(state.Candidates, id)
+			}
+		}
+		states[key] = state
+	}
+	return states, nil
+}
+
+// loadStoredPeople returns every stored person with their live terms in the
+// jurisdiction. Deleted people are candidates too, so that a record of one
+// is not created again as someone new; resolve never matches them.
+func loadStoredPeople(env *Env) (*storedPeople, error) {
+	var rows []map[string]interface{}
+	_, err := env.DB.From("people").
+		Select(strings.Join(personColumns, ",")+",deleted_at,terms(start_date,end_date,positions(district_number,jurisdiction_id))", "", false).
+		Is("terms.deleted_at", "null").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load people: %w", err)
+	}
+
+	s := &storedPeople{rows: make(map[int]map[string]interface{}, len(rows)), deleted: map[int]bool{}}
+	for _, row := range rows {
+		id, err := strconv.Atoi(keyString(row["id"]))
+		if err != nil {
+			continue
+		}
+		terms, _ := row["terms"].([]interface{})
+		delete(row, "terms")
+		if row["deleted_at"] != nil {
+			s.deleted[id] = true
+		}
+		delete(row, "deleted_at")
+		s.rows[id] = row
+
+		c := resolver.Candidate{PersonID: id, Name: stringValue(row["full_name"]), Email: stringValue(row["email"]), ExternalIDs: map[string]string{}}
+		if c.Name == "" {
+			c.Name = strings.TrimSpace(stringValue(row["first_name"]) + " " + stringValue(row["last_name"]))
+		}
+		if externalIDs, ok := row["external_ids"].(map[string]interface{}); ok {
+			for key, v := range externalIDs {
+				c.ExternalIDs[key] = keyString(v)
+			}
+		}
+		for _, t := range terms {
+			term, _ := t.(map[string]interface{})
+			position, _ := term["positions"].(map[string]interface{})
+			if keyString(position["jurisdiction_id"]) != strconv.Itoa(env.Jurisdiction.ID) {
+				continue
+			}
+			ward, _ := strconv.Atoi(keyString(position["district_number"]))
+			c.Terms = append(c.Terms, resolver.Term{
+				Ward:  ward,
+				Start: parseDay(stringValue(term["start_date"])),
+				End:   parseDay(stringValue(term["end_date"])),
+			})
+		}
+		s.candidates = append(s.candidates, c)
+	}
+	return s, nil
+}
+
+// live returns the candidates who are not deleted
+func (s *storedPeople) live() []resolver.Candidate {
+	var live []resolver.Candidate
+	for _, c := range s.candidates {
+		if !s.deleted[c.PersonID] {
+			live = append(live, c)
+		}
+	}
+	return live
+}
+
+// loadReviews loads the decided person_match items of the records of source
+// with the given keys, for resolve to apply
+func (s *storedPeople) loadReviews(env *Env, source string, keys []string) error {
+	itemKeys := make([]string, len(keys))
+	for i, key := range keys {
+		itemKeys[i] = reviewKey(review.KindPersonMatch, source, key)
+	}
+	reviewed, err := loadReviews(env, itemKeys)
+	if err != nil {
+		return err
+	}
+	s.reviewed = reviewed
+	return nil
+}
+
+// resolve returns the stored person r describes, or nil if r is a new
+// person. A record whose review item was resolved gets the person chosen in
+// review, and one whose item was dismissed is skipped with ok false. An
+// ambiguous record, or one of a deleted person, is logged and queued for
+// review under key, with raw as the upstream record, and ok is false.
+func (s *storedPeople) resolve(ctx context.Context, r resolver.Record, key string, raw interface{}) (person map[string]interface{}, ok bool) {
+	key = reviewKey(review.KindPersonMatch, r.Source, key)
+	candidates := s.candidates
+	switch d := s.reviewed[key]; {
+	case d.Status == review.StatusDismissed:
+		slog.DebugContext(ctx, "person match dismissed in review, skipping")
+		return nil, false
+	case d.Status == review.StatusResolved && d.Resolution.PersonID != 0:
+		if s.deleted[d.Resolution.PersonID] {
+			slog.WarnContext(ctx, "person chosen in review is deleted, skipping", "person_id", d.Resolution.PersonID)
+			return nil, false
+		}
+		if person := s.rows[d.Resolution.PersonID]; person != nil {
+			slog.DebugContext(ctx, "person resolved in review", "person_id", d.Resolution.PersonID)
+			return person, true
+		}
+		slog.WarnContext(ctx, "person chosen in review no longer exists, resolving again", "person_id", d.Resolution.PersonID)
+	case d.Status == review.StatusResolved && d.Resolution.NewPerson:
+		// None of the candidates reviewed, but possibly the person created
+		// since
+		candidates = excluding(candidates, d.Candidates)
+	}
+
+	result := resolver.Resolve(r, candidates)
+	switch result.Decision {
+	case resolver.Matched:
+		match := result.Match.Candidate
+		if !s.deleted[match.PersonID] {
+			slog.DebugContext(ctx, "matched person", "person_id", match.PersonID, "score", result.Match.Score, "reasons", result.Match.Reasons)
+			return s.rows[match.PersonID], true
+		}
+		// Writing to a deleted person would hide the record's terms and
+		// votes; an admin restores them or resolves the item to a new person
+		slog.WarnContext(ctx, "matched a deleted person, queued for review", "person_id", match.PersonID)
+		s.reviews = append(s.reviews, reviewItem{
+			Kind:   review.KindPersonMatch,
+			Key:    key,
+			Source: r.Source,
+			Record: raw,
+			Reason: fmt.Sprintf("%s matches %s, who is deleted", r.Name, match.Name),
+			Candidates: []map[string]interface{}{{
+				"person_id": match.PersonID,
+				"name":      match.Name,
+				"score":     result.Match.Score,
+				"reasons":   result.Match.Reasons,
+				"deleted":   true,
+			}},
+			Label: r.Name,
+		})
+		return nil, false
+	case resolver.New:
+		return nil, true
+	}
+
+	ids := make([]int, len(result.Candidates))
+	matches := make([]map[string]interface{}, len(result.Candidates))
+	for i, m := range result.Candidates {
+		ids[i] = m.Candidate.PersonID
+		matches[i] = map[string]interface{}{
+			"person_id": m.Candidate.PersonID,
+			"name":      m.Candidate.Name,
+			"score":     m.Score,
+			"reasons":   m.Reasons,
+		}
+		if s.deleted[m.Candidate.PersonID] {
+			matches[i]["deleted"] = true
+		}
+	}
+	slog.WarnContext(ctx, "ambiguous person match, queued for review", "candidates", ids)
+	s.reviews = append(s.reviews, reviewItem{
+		Kind:       review.KindPersonMatch,
+		Key:        key,
+		Source:     r.Source,
+		Record:     raw,
+		Reason:     fmt.Sprintf("%s may be any of %d stored people", r.Name, len(result.Candidates)),
+		Candidates: matches,
+		Label:      r.Name,
+	})
+	return nil, false
+}
+
+// excluding returns the candidates other than the people with the given ids
+func excluding(candidates []resolver.Candidate, ids []int) []resolver.Candidate {
+	var kept []resolver.Candidate
+	for _, c := range candidates {
+		if !slices.Contains(ids, c.PersonID) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// planReviews creates a review item for each record left for review that
+// has none yet. Items that exist, whatever their status, are left alone.
+func planReviews(env *Env, plan *Plan, reviews []reviewItem) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	keys := make([]string, len(reviews))
+	for i, r := range reviews {
+		keys[i] = r.Key
+	}
+	stored, err := loadRows(env, "review_items", "key", keys, []string{"id"})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reviews {
+		if stored[r.Key] != nil || plan.creating("review_items/key="+r.Key) {
+			continue
+		}
+		plan.diff(target{
+			table: "review_items",
+			key:   "key",
+			value: r.Key,
+			label: "review " + r.Label,
+		}, nil, map[string]interface{}{
+			"kind":       r.Kind,
+			"key":        r.Key,
+			"source":     r.Source,
+			"record":     r.Record,
+			"reason":     r.Reason,
+			"candidates": r.Candidates,
+		})
+	}
+	return nil
+}
+
+// linkExternalIDs returns the external IDs of a stored person with ids added
+func linkExternalIDs(person map[string]interface{}, ids map[string]interface{}) map[string]interface{} {
+	linked := map[string]interface{}{}
+	if stored, ok := person["external_ids"].(map[string]interface{}); ok {
+		for key, v := range stored {
+			linked[key] = v
+		}
+	}
+	for key, v := range ids {
+		linked[key] = v
+	}
+	return linked
+}
+
+// stringValue returns v if it is a string, or ""
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// parseDay parses a YYYY-MM-DD date, returning the zero time if it is empty
+// or malformed
+func parseDay(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
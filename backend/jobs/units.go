@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"github.com/Jsanchez767/InfluencePower/backend/cityapi"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/queue"
+)
+
+// Kinds of units jobs queue
+const (
+	unitVotes      = "votes"       // the votes on one matter
+	unitEventItems = "event_items" // the agenda items of one event
+)
+
+// UnitKinds lists the kinds of units jobs queue
+var UnitKinds = []string{unitVotes, unitEventItems}
+
+// runUnits fetches through the queue: one unit of kind per subject, with
+// its payload. An unfinished batch of the jurisdiction left by an earlier
+// run is resumed, so units it finished are not fetched again. It returns the
+// results of the subjects' finished units and the subjects whose units are
+// dead.
+func runUnits(ctx context.Context, env *Env, kind string, payloads map[string]interface{}, handler queue.Handler) (map[string]json.RawMessage, []string, error) {
+	store := env.Queue
+	if store == nil {
+		store = queue.NewMemoryStore()
+	}
+	cfg := env.QueueConfig.WithDefaults()
+
+	scope := strconv.Itoa(env.Jurisdiction.ID)
+	batch, err := store.Unfinished(ctx, kind, scope+"/")
+	if err != nil {
+		return nil, nil, err
+	}
+	resumed := 0
+	if batch == "" {
+		batch = queue.NewBatch(scope)
+	} else {
+		earlier, err := store.Batch(ctx, kind, batch)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range earlier {
+			if _, ok := payloads[t.Subject()]; ok && (t.Status == queue.StatusDone || t.Status == queue.StatusDead) {
+				resumed++
+			}
+		}
+		slog.InfoContext(ctx, "resuming unfinished batch", "kind", kind, "batch", batch, "finished", resumed)
+	}
+
+	subjects := make([]string, 0, len(payloads))
+	for subject := range payloads {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	tasks := make([]queue.Task, len(subjects))
+	for i, subject := range subjects {
+		if tasks[i], err = queue.NewTask(kind, batch, subject, payloads[subject]); err != nil {
+			return nil, nil, err
+		}
+	}
+	added, err := store.Enqueue(ctx, tasks, cfg.MaxAttempts)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.InfoContext(ctx, "queued units", "kind", kind, "batch", batch, "units", len(tasks), "added", added)
+
+	progress := newProgress(logging.With(ctx, "kind", kind, "batch", batch), "fetch progress", len(tasks), resumed)
+	pool := &queue.Pool{Store: store, Kind: kind, Handler: handler, Config: cfg, Finished: func(t queue.Task) {
+		switch t.Status {
+		case queue.StatusDone:
+			progress.add(1, 0)
+		case queue.StatusDead:
+			progress.add(1, 1)
+		}
+	}}
+	slog.InfoContext(ctx, "running units", "kind", kind, "batch", batch, "workers", cfg.Workers)
+	err = pool.Drain(ctx, batch)
+	progress.finish()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to run %s units: %w", kind, err)
+	}
+
+	finished, err := store.Batch(ctx, kind, batch)
+	if err != nil {
+		return nil, nil, err
+	}
+	results := make(map[string]json.RawMessage, len(finished))
+	var dead []string
+	for _, t := range finished {
+		subject := t.Subject()
+		if _, ok := payloads[subject]; !ok {
+			continue
+		}
+		switch t.Status {
+		case queue.StatusDone:
+			results[subject] = t.Result
+		case queue.StatusDead:
+			dead = append(dead, subject)
+		}
+	}
+
+	// Results are only needed until the plan is made
+	if err := store.DeleteDone(ctx, kind, batch); err != nil {
+		slog.WarnContext(ctx, "failed to delete done units", "kind", kind, "batch", batch, "error", err)
+	}
+	return results, dead, nil
+}
+
+// fetchVotes fetches the votes on the matter in a votes unit. Units run on
+// several workers at once; the Legistar client's limiter paces their
+// requests.
+func fetchVotes(env *Env) queue.Handler {
+	return func(ctx context.Context, task queue.Task) (json.RawMessage, error) {
+		var payload struct {
+			MatterID int `json:"matter_id"`
+		}
+		if err := json.Unmarshal(task.Payload, &payload); err != nil {
+			return nil, err
+		}
+		votes, err := env.Legistar.WithContext(ctx).GetVotes(payload.MatterID)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(votes)
+	}
+}
+
+// fetchEventItems fetches the agenda items of the event in an event_items
+// unit
+func fetchEventItems(env *Env) queue.Handler {
+	return func(ctx context.Context, task queue.Task) (json.RawMessage, error) {
+		var payload struct {
+			EventID int `json:"event_id"`
+		}
+		if err := json.Unmarshal(task.Payload, &payload); err != nil {
+			return nil, err
+		}
+		items, err := env.Legistar.WithContext(ctx).GetEventItems(payload.EventID)
+		if err != nil {
+			return nil, err
+		}
+		if items == nil {
+			items = []cityapi.EventItem{}
+		}
+		return json.Marshal(items)
+	}
+}
@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// DefaultBatchSize is the most rows a writer upserts in one request when
+// Options.BatchSize is not set
+const DefaultBatchSize = 500
+
+// conflictTargets are the unique columns that rows created in each table are
+// upserted on, so that a row stored since the plan was made, e.g. by an
+// overlapping run, is updated rather than duplicated or failed. Rows of
+// other tables are created with generated ids.
+var conflictTargets = map[string]string{
+	"bodies":         "body_id",
+	"matters":        "matter_id",
+	"votes":          "vote_id",
+	"events":         "event_id",
+	"event_items":    "event_item_id",
+	"person_metrics": "person_id",
+	"review_items":   "key",
+}
+
+// writer writes a plan's changes in order. Runs of creates in one table are
+// upserted in batches; a batch that fails is written again one row at a
+// time, so that a bad row only fails itself.
+type writer struct {
+	env       *Env
+	batchSize int
+	created   map[string]int // ids of rows created with a generated id, by change ID
+}
+
+// newWriter returns a writer using env's batch size
+func newWriter(env *Env) *writer {
+	size := env.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	return &writer{env: env, batchSize: size, created: map[string]int{}}
+}
+
+// write writes changes and calls done with each of them and its error, in
+// order
+func (w *writer) write(ctx context.Context, changes []Change, done func(c Change, err error)) {
+	for start := 0; start < len(changes); {
+		batch := w.nextBatch(changes[start:])
+		start += len(batch)
+		if len(batch) > 1 {
+			err := w.upsert(batch)
+			if err == nil {
+				for _, c := range batch {
+					done(c, nil)
+				}
+				continue
+			}
+			slog.WarnContext(ctx, "batch upsert failed; writing rows one at a time", "table", batch[0].Table, "rows", len(batch), "error", err)
+		}
+		for _, c := range batch {
+			done(c, w.writeOne(c))
+		}
+	}
+}
+
+// batchable reports whether c can be upserted along with other creates: it
+// creates a row matched on its table's conflict target and refers to no
+// other change
+func batchable(c Change) bool {
+	return c.Op == OpCreate && c.Key == conflictTargets[c.Table] && len(c.Refs) == 0
+}
+
+// nextBatch returns the changes at the start of changes to write in one
+// request: up to batchSize batchable creates in the same table with the same
+// fields, as the rows of a bulk upsert must have, or else the first change
+func (w *writer) nextBatch(changes []Change) []Change {
+	first := changes[0]
+	if !batchable(first) {
+		return changes[:1]
+	}
+	n := 1
+	for n < len(changes) && n < w.batchSize {
+		c := changes[n]
+		if !batchable(c) || c.Table != first.Table || !sameFields(c, first) {
+			break
+		}
+		n++
+	}
+	return changes[:n]
+}
+
+// sameFields reports whether a and b write the same fields
+func sameFields(a, b Change) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Field != b.Fields[i].Field {
+			return false
+		}
+	}
+	return true
+}
+
+// upsert writes a batch from nextBatch in one request
+func (w *writer) upsert(batch []Change) error {
+	rows := make([]map[string]interface{}, len(batch))
+	for i, c := range batch {
+		rows[i] = c.row()
+	}
+	table := batch[0].Table
+	_, _, err := w.env.DB.From(table).Upsert(rows, conflictTargets[table], "", "minimal").Execute()
+	return err
+}
+
+// writeOne writes one change. Rows created with a generated id record it for
+// later refs.
+func (w *writer) writeOne(c Change) error {
+	row := c.row()
+	for field, ref := range c.Refs {
+		id, ok := w.created[ref]
+		if !ok {
+			return fmt.Errorf("%s was not created", ref)
+		}
+		row[field] = id
+	}
+
+	table := w.env.DB.From(c.Table)
+	switch {
+	case c.Op == OpCreate && c.Key == "id":
+		var result []struct {
+			ID int `json:"id"`
+		}
+		if _, err := table.Insert(row, false, "", "", "").ExecuteTo(&result); err != nil {
+			return err
+		}
+		if len(result) == 0 {
+			return fmt.Errorf("insert into %s returned no row", c.Table)
+		}
+		w.created[c.ID] = result[0].ID
+		return nil
+	case c.Op == OpCreate:
+		target := conflictTargets[c.Table]
+		if target == "" {
+			target = c.Key
+		}
+		_, _, err := table.Upsert(row, target, "", "minimal").Execute()
+		return err
+	case c.Op == OpUpdate:
+		_, _, err := table.Update(row, "minimal", "").Eq(c.Key, c.Value).Execute()
+		return err
+	default:
+		_, _, err := table.Delete("minimal", "").Eq(c.Key, c.Value).Execute()
+		return err
+	}
+}
+
+// rowKey returns the key of the row c wrote: its Value, or the id generated
+// for a row created with a new id. It is empty for a change not written.
+func (w *writer) rowKey(c Change) string {
+	if c.Value != "" {
+		return c.Value
+	}
+	if id, ok := w.created[c.ID]; ok {
+		return strconv.Itoa(id)
+	}
+	return ""
+}
+
+// rowError is a change that could not be written
+type rowError struct {
+	Change string
+	Table  string
+	Key    string
+	Value  string
+	Err    error
+}
+
+// newRowError describes the row c failed to write
+func newRowError(c Change, err error) rowError {
+	return rowError{Change: c.ID, Table: c.Table, Key: c.Key, Value: c.Value, Err: err}
+}
+
+// maxReportedFailures caps the change IDs listed in the summary of failures
+const maxReportedFailures = 20
+
+// failedChanges returns the IDs of failed changes for a summary log line
+func failedChanges(failures []rowError) []string {
+	ids := make([]string, 0, len(failures))
+	for i, f := range failures {
+		if i == maxReportedFailures {
+			ids = append(ids, fmt.Sprintf("and %d more", len(failures)-i))
+			break
+		}
+		ids = append(ids, f.Change)
+	}
+	return ids
+}
@@ -0,0 +1,139 @@
+// Package logging configures structured log/slog output shared by the server
+// and the sync jobs. Records logged with a context carry its request ID and
+// any fields attached with With.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config selects the log level and output format
+type Config struct {
+	Level  string // debug, info, warn or error
+	Format string // json or text
+}
+
+// FromEnv reads LOG_LEVEL and LOG_FORMAT, for jobs that don't load the
+// server configuration
+func FromEnv() Config {
+	return Config{Level: os.Getenv("LOG_LEVEL"), Format: os.Getenv("LOG_FORMAT")}
+}
+
+// ParseLevel parses a level name, defaulting to info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("unknown log level %q: expected debug, info, warn or error", s)
+	}
+	return level, nil
+}
+
+// New creates a logger writing to w
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q: expected json or text", cfg.Format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Setup makes a logger writing to stderr the default for slog and for the
+// standard log package
+func Setup(cfg Config) error {
+	logger, err := New(os.Stderr, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+type fieldsKey struct{}
+
+// With returns a copy of ctx whose log records carry the given key-value
+// pairs, e.g. With(ctx, "entity", "votes", "matter_id", id). A key already
+// attached to ctx is replaced.
+func With(ctx context.Context, args ...any) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	record := slog.Record{}
+	record.Add(args...)
+
+	added := make(map[string]bool, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		added[a.Key] = true
+		return true
+	})
+
+	merged := make([]slog.Attr, 0, len(fields)+record.NumAttrs())
+	for _, f := range fields {
+		if !added[f.Key] {
+			merged = append(merged, f)
+		}
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		merged = append(merged, a)
+		return true
+	})
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// contextHandler adds the request ID, the trace and span IDs of the current
+// span and fields from With to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := middleware.RequestIDFromContext(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+		if fields, ok := ctx.Value(fieldsKey{}).([]slog.Attr); ok {
+			// Fields passed to the log call win over those on ctx
+			set := make(map[string]bool, r.NumAttrs())
+			r.Attrs(func(a slog.Attr) bool {
+				set[a.Key] = true
+				return true
+			})
+			for _, f := range fields {
+				if !set[f.Key] {
+					r.AddAttrs(f)
+				}
+			}
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+func TestContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Level: "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := middleware.WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, "entity", "votes")
+	child := With(ctx, "matter_id", "M1", "entity", "matters")
+	logger.InfoContext(child, "vote upserted", "person_id", 7)
+	logger.InfoContext(ctx, "sync finished", "entity", "people")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if n := bytes.Count(line, []byte(`"entity"`)); n != 1 {
+			t.Errorf("expected one entity field, got %d: %s", n, line)
+		}
+	}
+
+	var first, second map[string]interface{}
+	if err := json.Unmarshal(lines[0], &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first["request_id"] != "req-1" || first["entity"] != "matters" || first["matter_id"] != "M1" || first["person_id"] != float64(7) {
+		t.Errorf("unexpected record: %v", first)
+	}
+	if _, ok := second["matter_id"]; ok || second["entity"] != "people" {
+		t.Errorf("child fields leaked into parent context: %v", second)
+	}
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Config{Level: "loud"}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, err := New(&bytes.Buffer{}, Config{Format: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Config{Level: "warn", Format: "text"})
+	logger.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("info record written at warn level: %s", buf.String())
+	}
+}
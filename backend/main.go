@@ -1,54 +1,21 @@
+// Command influencepower serves the InfluencePower API and runs its sync,
+// maintenance and migration jobs. Run it without arguments to serve.
 package main
 
 import (
-	"log"
-	"net/http"
 	"os"
 
-	"github.com/Jsanchez767/InfluencePower/backend/api"
-	"github.com/Jsanchez767/InfluencePower/backend/db"
-	"github.com/gorilla/mux"
+	"github.com/Jsanchez767/InfluencePower/backend/cli"
 	"github.com/joho/godotenv"
-	"github.com/rs/cors"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
-
-	// Initialize Supabase client
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	supabaseKey := os.Getenv("SUPABASE_SERVICE_ROLE_KEY")
-	
-	if supabaseURL == "" || supabaseKey == "" {
-		log.Fatal("SUPABASE_URL and SUPABASE_SERVICE_ROLE_KEY must be set")
-	}
-
-	db.InitSupabase(supabaseURL, supabaseKey)
-
-	// Setup router
-	router := mux.NewRouter()
-	api.SetupRoutes(router)
-
-	// CORS configuration
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
-	})
-
-	handler := c.Handler(router)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	// Load environment variables; a missing .env file is fine
+	godotenv.Load()
 
-	log.Printf("Server starting on port %s", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
-		log.Fatal(err)
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
 	}
+	os.Exit(cli.Main(args, os.Stdout, os.Stderr))
 }
@@ -0,0 +1,71 @@
+// Package mergepatch applies JSON Merge Patches (RFC 7396), the partial
+// updates PATCH routes take: members of the patch replace those of the
+// target, null members remove them and objects are merged recursively.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ContentType is the media type of a merge patch
+const ContentType = "application/merge-patch+json"
+
+// ErrNotObject is returned by Fields for a patch that is not a JSON object
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply returns target with patch applied. A patch that is not an object
+// replaces the target.
+func Apply(target, patch json.RawMessage) (json.RawMessage, error) {
+	var t, p interface{}
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := unmarshal(target, &t); err != nil {
+			return nil, err
+		}
+	}
+	if err := unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(t, p))
+}
+
+// Fields returns the names of the members of patch, which must be an object
+func Fields(patch json.RawMessage) ([]string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return nil, ErrNotObject
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// unmarshal decodes data keeping numbers as written
+func unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// merge is the MergePatch function of RFC 7396
+func merge(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = merge(result[name], value)
+	}
+	return result
+}
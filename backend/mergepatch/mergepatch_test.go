@@ -0,0 +1,50 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestApply runs the examples of RFC 7396, appendix A
+func TestApply(t *testing.T) {
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":12345678901234567890}`, `{"a":12345678901234567890}`},
+	}
+	for _, tt := range tests {
+		got, err := Apply(json.RawMessage(tt.target), json.RawMessage(tt.patch))
+		if err != nil {
+			t.Errorf("%s + %s: %v", tt.target, tt.patch, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s + %s: expected %s, got %s", tt.target, tt.patch, tt.want, got)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	fields, err := Fields(json.RawMessage(`{"email": null}`))
+	if err != nil || len(fields) != 1 || fields[0] != "email" {
+		t.Errorf("expected [email], got %v, %v", fields, err)
+	}
+	for _, patch := range []string{`null`, `["email"]`, `"email"`} {
+		if _, err := Fields(json.RawMessage(patch)); err != ErrNotObject {
+			t.Errorf("%s: expected ErrNotObject, got %v", patch, err)
+		}
+	}
+}
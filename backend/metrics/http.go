@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/gorilla/mux"
+)
+
+// Instrument records the count and latency of requests under their mux route
+// template, so that /officials/1 and /officials/2 share a series. Requests
+// that match no route are recorded as "unmatched".
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := middleware.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		HTTPRequests.Inc(r.Method, route, strconv.Itoa(rec.Status))
+		HTTPDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
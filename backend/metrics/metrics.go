@@ -0,0 +1,309 @@
+// Package metrics collects counters, gauges and histograms and serves them
+// in the Prometheus text exposition format.
+//
+// The metrics exported by the API, its database and Legistar clients and the
+// sync jobs are declared here so that their names and labels stay in one
+// place.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds metrics and writes them in registration order
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*family
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry served at /metrics
+var Default = NewRegistry()
+
+// Metrics recorded by the API server
+var (
+	HTTPRequests = Default.Counter("http_requests_total",
+		"HTTP requests by method, route template and status code.", "method", "route", "status")
+	HTTPDuration = Default.Histogram("http_request_duration_seconds",
+		"HTTP request latency by method and route template.", DefaultBuckets, "method", "route")
+)
+
+// Metrics recorded by the Legistar client
+var (
+	LegistarRequests = Default.Counter("legistar_requests_total",
+		"Legistar API requests by endpoint and status code; status is \"error\" when no response was received.", "endpoint", "status")
+	LegistarErrors = Default.Counter("legistar_request_errors_total",
+		"Legistar API requests that failed after all retries.", "endpoint")
+	LegistarRetries = Default.Counter("legistar_request_retries_total",
+		"Legistar API requests retried after a network error, 429 or 5xx.", "endpoint")
+	LegistarDuration = Default.Histogram("legistar_request_duration_seconds",
+		"Legistar API request latency by endpoint.", DefaultBuckets, "endpoint")
+)
+
+// Metrics recorded by the database client
+var (
+	DBDuration = Default.Histogram("db_query_duration_seconds",
+		"PostgREST call latency by method and table.", DefaultBuckets, "method", "table")
+	DBErrors = Default.Counter("db_query_errors_total",
+		"PostgREST calls that failed or returned a 4xx/5xx status.", "method", "table")
+)
+
+// Metrics describing sync jobs, read from sync_state
+var (
+	SyncRecords = Default.Gauge("sync_records",
+		"Records handled by the last successful sync of each entity, by outcome (fetched, upserted, failed).", "entity", "outcome")
+	SyncLastSuccess = Default.Gauge("sync_last_success_timestamp_seconds",
+		"Unix time of the last successful sync of each entity.", "entity")
+)
+
+// Counter registers a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, "counter", nil, labels)}
+}
+
+// Gauge registers a gauge with the given label names
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, "gauge", nil, labels)}
+}
+
+// Histogram registers a histogram with the given upper bucket bounds, which
+// must be sorted, and label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{r.register(name, help, "histogram", buckets, labels)}
+}
+
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *family {
+	f := &family{name: name, help: help, typ: typ, buckets: buckets, labels: labels, series: make(map[string]*series)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.metrics {
+		if m.name == name {
+			panic("metrics: duplicate metric " + name)
+		}
+	}
+	r.metrics = append(r.metrics, f)
+	return f
+}
+
+// Write writes every metric in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]*family(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range metrics {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry. A non-empty token must be presented as a
+// bearer token.
+func (r *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" && req.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		r.Write(w)
+	})
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct{ f *family }
+
+// Inc adds one to the series with the given label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values
+func (c *CounterVec) Add(v float64, values ...string) {
+	c.f.update(values, func(s *series) { s.value += v })
+}
+
+// Value returns the current value of a series, for tests
+func (c *CounterVec) Value(values ...string) float64 {
+	return c.f.value(values)
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct{ f *family }
+
+// Set sets the series with the given label values
+func (g *GaugeVec) Set(v float64, values ...string) {
+	g.f.update(values, func(s *series) { s.value = v })
+}
+
+// Value returns the current value of a series, for tests
+func (g *GaugeVec) Value(values ...string) float64 {
+	return g.f.value(values)
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct{ f *family }
+
+// Observe records v in the series with the given label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.f.update(values, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.f.buckets))
+		}
+		for i, bound := range h.f.buckets {
+			if v <= bound {
+				s.counts[i]++
+			}
+		}
+		s.count++
+		s.sum += v
+	})
+}
+
+// Count returns the number of observations in a series, for tests
+func (h *HistogramVec) Count(values ...string) uint64 {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	if s, ok := h.f.series[seriesKey(values)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+// family is one metric name with all its labelled series
+type family struct {
+	name, help, typ string
+	buckets         []float64
+	labels          []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64
+
+	// histograms only; counts are cumulative per bucket
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func (f *family) update(values []string, fn func(*series)) {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := seriesKey(values)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		f.series[key] = s
+	}
+	fn(s)
+}
+
+func (f *family) value(values []string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[seriesKey(values)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.typ != "histogram" {
+			writeSample(w, f.name, f.labels, s.values, "", "", s.value)
+			continue
+		}
+		for i, bound := range f.buckets {
+			writeSample(w, f.name+"_bucket", f.labels, s.values, "le", formatFloat(bound), float64(s.counts[i]))
+		}
+		writeSample(w, f.name+"_bucket", f.labels, s.values, "le", "+Inf", float64(s.count))
+		writeSample(w, f.name+"_sum", f.labels, s.values, "", "", s.sum)
+		writeSample(w, f.name+"_count", f.labels, s.values, "", "", float64(s.count))
+	}
+}
+
+// writeSample writes one line, with an optional extra label such as le
+func writeSample(w *bufio.Writer, name string, labels, values []string, extra, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extra != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extra, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestWriteTextFormat(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("jobs_total", "Jobs run.", "job")
+	h := r.Histogram("job_seconds", "Job latency.", []float64{1, 5}, "job")
+	g := r.Gauge("queue_depth", "Queued jobs.")
+
+	c.Inc(`sync "votes"`)
+	c.Add(2, "metrics")
+	h.Observe(0.5, "metrics")
+	h.Observe(3, "metrics")
+	g.Set(7)
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total{job="metrics"} 2
+jobs_total{job="sync \"votes\""} 1
+# HELP job_seconds Job latency.
+# TYPE job_seconds histogram
+job_seconds_bucket{job="metrics",le="1"} 1
+job_seconds_bucket{job="metrics",le="5"} 2
+job_seconds_bucket{job="metrics",le="+Inf"} 2
+job_seconds_sum{job="metrics"} 3.5
+job_seconds_count{job="metrics"} 2
+# HELP queue_depth Queued jobs.
+# TYPE queue_depth gauge
+queue_depth 7
+`
+	if b.String() != want {
+		t.Errorf("unexpected output:\n%s", b.String())
+	}
+}
+
+func TestWrongLabelCountPanics(t *testing.T) {
+	c := NewRegistry().Counter("x_total", "X.", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	c.Inc("only-one")
+}
+
+func TestInstrumentUsesRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Instrument)
+	router.HandleFunc("/things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	router.NotFoundHandler = Instrument(http.NotFoundHandler())
+
+	for _, path := range []string{"/things/1", "/things/2", "/nowhere"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if got := HTTPRequests.Value("GET", "/things/{id}", "418"); got != 2 {
+		t.Errorf("expected 2 requests for the template, got %v", got)
+	}
+	if got := HTTPDuration.Count("GET", "/things/{id}"); got != 2 {
+		t.Errorf("expected 2 latency observations, got %d", got)
+	}
+	if got := HTTPRequests.Value("GET", "unmatched", "404"); got != 1 {
+		t.Errorf("expected 1 unmatched request, got %v", got)
+	}
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("up", "Up.").Set(1)
+	h := r.Handler("secret")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "up 1\n") {
+		t.Errorf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -5,13 +5,13 @@ import "time"
 // Official represents a city official
 type Official struct {
 	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Ward      *int      `json:"ward,omitempty"`
-	Party     string    `json:"party"`
-	Role      string    `json:"role"`
-	Contact   string    `json:"contact"`
-	Email     string    `json:"email"`
-	ImageURL  string    `json:"image_url,omitempty"`
+	Name      string    `json:"name" validate:"required,max=200"`
+	Ward      *int      `json:"ward,omitempty" validate:"omitempty,ward"`
+	Party     string    `json:"party" validate:"max=100"`
+	Role      string    `json:"role" validate:"required,oneof=Mayor|Alderman|City Clerk|City Treasurer"`
+	Contact   string    `json:"contact" validate:"max=200"`
+	Email     string    `json:"email" validate:"omitempty,email"`
+	ImageURL  string    `json:"image_url,omitempty" validate:"omitempty,url"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -19,11 +19,11 @@ type Official struct {
 // VotingRecord represents a voting record for an official
 type VotingRecord struct {
 	ID          int       `json:"id"`
-	OfficialID  int       `json:"official_id"`
-	BillTitle   string    `json:"bill_title"`
-	Vote        string    `json:"vote"` // "yes", "no", "abstain"
-	VoteDate    time.Time `json:"vote_date"`
-	Description string    `json:"description,omitempty"`
+	OfficialID  int       `json:"official_id" validate:"required,min=1"`
+	BillTitle   string    `json:"bill_title" validate:"required,max=500"`
+	Vote        string    `json:"vote" validate:"required,oneof=yes|no|abstain|yea|nay|present|absent|excused"` // "yes", "no", "abstain"
+	VoteDate    time.Time `json:"vote_date" validate:"required,notfuture"`
+	Description string    `json:"description,omitempty" validate:"max=5000"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -55,3 +55,52 @@ type WardStatistic struct {
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
 }
+
+// PersonMetrics holds the precomputed metrics for a person (person_metrics table)
+type PersonMetrics struct {
+	PersonID                   int        `json:"person_id"`
+	BillsIntroduced            int        `json:"bills_introduced"`
+	BillsPassed                int        `json:"bills_passed"`
+	BillsFailed                int        `json:"bills_failed"`
+	AmendmentsProposed         int        `json:"amendments_proposed"`
+	TotalVotes                 int        `json:"total_votes"`
+	VotesYea                   int        `json:"votes_yea"`
+	VotesNay                   int        `json:"votes_nay"`
+	VotesAbstain               int        `json:"votes_abstain"`
+	VotesAbsent                int        `json:"votes_absent"`
+	AttendanceRate             float64    `json:"attendance_rate"`
+	LegislativeImpactScore     float64    `json:"legislative_impact_score"`
+	ConstituentEngagementScore float64    `json:"constituent_engagement_score"`
+	TransparencyScore          float64    `json:"transparency_score"`
+	OverallScore               float64    `json:"overall_score"`
+	LastCalculatedAt           *time.Time `json:"last_calculated_at"`
+	UpdatedAt                  *time.Time `json:"updated_at"`
+}
+
+// VotingAlly is another official's voting alignment with an official
+type VotingAlly struct {
+	OfficialID int     `json:"official_id"`
+	Name       string  `json:"name"`
+	Ward       *int    `json:"ward"`
+	Party      string  `json:"party"`
+	Alignment  float64 `json:"alignment"` // percentage of shared votes cast the same way
+	Bloc       string  `json:"bloc"`
+}
+
+// RecentVote is a vote cast by an official with the matter it was cast on
+type RecentVote struct {
+	ID         int64       `json:"id"`
+	VoteID     *string     `json:"vote_id"`
+	MatterID   *string     `json:"matter_id"`
+	PersonID   *int        `json:"person_id"`
+	PersonName *string     `json:"person_name"`
+	VoteValue  *string     `json:"vote_value"` // "Yea", "Nay", "Abstain", "Present", etc.
+	VoteDate   *string     `json:"vote_date"`
+	Matter     *VoteMatter `json:"matters"`
+}
+
+// VoteMatter is the matter embedded in a RecentVote
+type VoteMatter struct {
+	MatterName     *string `json:"matter_name"`
+	MatterTypeName *string `json:"matter_type_name"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Person is a row of people: anyone who holds or held a position
+type Person struct {
+	ID                  int             `json:"id"`
+	FirstName           string          `json:"first_name"`
+	LastName            string          `json:"last_name"`
+	FullName            string          `json:"full_name"`
+	MiddleName          *string         `json:"middle_name"`
+	Suffix              *string         `json:"suffix"`
+	ExternalIDs         json.RawMessage `json:"external_ids"` // e.g. {"legistar_id": 162}
+	Email               *string         `json:"email"`
+	Phone               *string         `json:"phone"`
+	Website             *string         `json:"website"`
+	TwitterHandle       *string         `json:"twitter_handle"`
+	FacebookURL         *string         `json:"facebook_url"`
+	InstagramHandle     *string         `json:"instagram_handle"`
+	ImageURL            *string         `json:"image_url"`
+	HeadshotLastUpdated *time.Time      `json:"headshot_last_updated"`
+	DateOfBirth         *string         `json:"date_of_birth"` // YYYY-MM-DD
+	PartyAffiliation    *string         `json:"party_affiliation"`
+	CreatedAt           *time.Time      `json:"created_at"`
+	UpdatedAt           *time.Time      `json:"updated_at"`
+}
+
+// Position is a row of positions: an office, such as the alderperson of a
+// ward, held by as many people at once as it has seats
+type Position struct {
+	ID             int        `json:"id"`
+	JurisdictionID int        `json:"jurisdiction_id"`
+	PositionType   string     `json:"position_type"` // e.g. "alderman" or "mayor"
+	DistrictNumber *int       `json:"district_number"`
+	DistrictName   *string    `json:"district_name"`
+	Title          string     `json:"title"`
+	BodyName       *string    `json:"body_name"`
+	BodyID         *int       `json:"body_id"`
+	Seats          int        `json:"seats"`
+	CreatedAt      *time.Time `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+}
+
+// Term is a row of terms: a person holding a position from start_date,
+// until end_date if it ended
+type Term struct {
+	ID           int        `json:"id"`
+	PositionID   int        `json:"position_id"`
+	PersonID     int        `json:"person_id"`
+	StartDate    string     `json:"start_date"` // YYYY-MM-DD
+	EndDate      *string    `json:"end_date"`   // null while the term is current
+	ExternalID   *int       `json:"external_id"`
+	ExternalGUID *string    `json:"external_guid"`
+	TermNumber   *int       `json:"term_number"`
+	ElectionType *string    `json:"election_type"` // "general", "special" or "appointment"
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
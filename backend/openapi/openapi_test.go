@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Note string `json:"note"`
+}
+
+type widget struct {
+	inner
+	ID      int        `json:"id"`
+	Name    string     `json:"name" validate:"required,max=50"`
+	Kind    string     `json:"kind,omitempty" validate:"omitempty,oneof=a|b"`
+	Parent  *widget    `json:"parent"`
+	Tags    []string   `json:"tags"`
+	Seen    *time.Time `json:"seen,omitempty"`
+	private string
+	Skipped string `json:"-"`
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	schemas := NewSchemas()
+	ref := schemas.For([]widget{})
+	if ref.Type != "array" || ref.Items.RefName() != "Widget" {
+		t.Fatalf("unexpected schema %+v", ref)
+	}
+
+	s := schemas.Components()["Widget"]
+	for _, name := range []string{"note", "id", "name", "kind", "parent", "tags", "seen"} {
+		if s.Properties[name] == nil {
+			t.Errorf("missing property %q", name)
+		}
+	}
+	if len(s.Properties) != 7 {
+		t.Errorf("expected 7 properties, got %d", len(s.Properties))
+	}
+	if got := strings.Join(s.Required, ","); got != "note,id,name,parent,tags" {
+		t.Errorf("unexpected required %s", got)
+	}
+	if *s.Properties["name"].MaxLength != 50 {
+		t.Errorf("expected maxLength from validate tag")
+	}
+	if strings.Join(s.Properties["kind"].Enum, ",") != "a,b" {
+		t.Errorf("expected enum from validate tag")
+	}
+	if p := s.Properties["parent"]; !p.Nullable || p.AllOf[0].RefName() != "Widget" {
+		t.Errorf("expected nullable self reference, got %+v", p)
+	}
+	if p := s.Properties["seen"]; p.Format != "date-time" || !p.Nullable {
+		t.Errorf("expected nullable date-time, got %+v", p)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schemas := NewSchemas()
+	doc := &Document{Components: Components{}}
+	schema := schemas.For(widget{})
+	doc.Components.Schemas = schemas.Components()
+
+	valid := `{"note": "", "id": 1, "name": "w", "kind": "c", "parent": {"note": "", "id": 2, "name": "p", "parent": null, "tags": []}, "tags": ["x"]}`
+	if err := doc.Validate(schema, []byte(valid)); err != nil {
+		t.Errorf("expected valid, got %v", err)
+	}
+
+	cases := map[string]string{
+		`{"note": "", "id": 1.5, "name": "w", "parent": null, "tags": []}`:              "$.id: expected integer",
+		`{"note": "", "id": 1, "parent": null, "tags": []}`:                             `missing required property "name"`,
+		`{"note": "", "id": 1, "name": "w", "parent": null, "tags": null}`:              "$.tags: null is not allowed",
+		`{"note": "", "id": 1, "name": "w", "parent": null, "tags": [], "extra": true}`: `unexpected property "extra"`,
+		`{"note": "", "id": 1, "name": "w", "parent": {"id": "2"}, "tags": []}`:         "$.parent.id: expected integer, got string",
+	}
+	for body, want := range cases {
+		err := doc.Validate(schema, []byte(body))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected %q, got %v", body, want, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schemas generates component schemas from Go types. Named struct types
+// become components referenced by name; their properties follow the
+// encoding/json rules (json tags, omitempty, embedded structs) and pick up
+// constraints from `validate` tags.
+type Schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+// NewSchemas creates an empty schema registry
+func NewSchemas() *Schemas {
+	return &Schemas{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// Components returns the generated component schemas
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// For returns the schema for the type of v, registering components as needed
+func (s *Schemas) For(v interface{}) *Schema {
+	return s.forType(reflect.TypeOf(v))
+}
+
+// Named registers the type of v under name, for unexported or anonymous types
+func (s *Schemas) Named(name string, v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s.names[t] = name
+	return s.forType(t)
+}
+
+func (s *Schemas) forType(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := s.forValueType(t)
+	if nullable {
+		if schema.Ref != "" {
+			// $ref siblings are ignored in OpenAPI 3.0, so wrap nullable refs
+			return &Schema{Nullable: true, AllOf: []*Schema{schema}}
+		}
+		c := *schema
+		c.Nullable = true
+		return &c
+	}
+	return schema
+}
+
+func (s *Schemas) forValueType(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.forType(indirect(t.Elem()))}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.forType(indirect(t.Elem()))}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		name := s.componentName(t)
+		if name == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s.components[name]; !ok {
+			// Register before recursing so self-referencing types terminate
+			s.components[name] = &Schema{}
+			*s.components[name] = *s.structSchema(t)
+		}
+		return Ref(name)
+	}
+	return &Schema{}
+}
+
+// componentName returns the component name for a struct type, or "" for
+// anonymous structs, which are inlined
+func (s *Schemas) componentName(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	if t.Name() == "" {
+		return ""
+	}
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	s.names[t] = string(name)
+	return string(name)
+}
+
+func (s *Schemas) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	s.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON properties of struct type t to schema, flattening
+// embedded structs the way encoding/json does
+func (s *Schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(schema, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := s.forType(f.Type)
+		if rules := f.Tag.Get("validate"); rules != "" {
+			prop = applyRules(prop, rules)
+		}
+		schema.Properties[name] = prop
+
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyRules copies validate constraints onto a property schema
+func applyRules(prop *Schema, rules string) *Schema {
+	if prop.Ref != "" || len(prop.AllOf) > 0 {
+		return prop
+	}
+	c := *prop
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch c.Type {
+			case "string":
+				if name == "min" {
+					c.MinLength = &n
+				} else {
+					c.MaxLength = &n
+				}
+			case "integer", "number":
+				f := float64(n)
+				if name == "min" {
+					c.Minimum = &f
+				} else {
+					c.Maximum = &f
+				}
+			}
+		case "oneof":
+			c.Enum = strings.Split(param, "|")
+		case "email":
+			c.Format = "email"
+		case "url":
+			c.Format = "uri"
+		case "date":
+			c.Format = "date"
+		}
+	}
+	return &c
+}
+
+// indirect strips pointers from slice and map elements, which the API never
+// encodes as null
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
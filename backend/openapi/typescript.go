@@ -0,0 +1,277 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// pathParamPattern matches {name} segments of OpenAPI paths
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// TypeScript renders the document's schemas as TypeScript interfaces and its
+// operations as a typed fetch client. Operation paths are relative to the
+// first server URL, which callers pass as baseUrl. header is emitted as a
+// leading comment.
+func (d *Document) TypeScript(header string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(header, "\n") {
+		b.WriteString("// " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema := d.Components.Schemas[name]
+		if schema.Type == "object" && len(schema.Properties) > 0 {
+			fmt.Fprintf(&b, "export interface %s %s\n\n", name, tsObject(schema, ""))
+		} else {
+			fmt.Fprintf(&b, "export type %s = %s;\n\n", name, tsType(schema, ""))
+		}
+	}
+
+	b.WriteString(clientPrelude)
+	b.WriteString("\n  return {\n")
+	base := ""
+	if len(d.Servers) > 0 {
+		base = d.Servers[0].URL
+	}
+	for _, op := range d.sortedOperations() {
+		b.WriteString(tsOperation(strings.TrimPrefix(op.path, base), op.method, op.Operation))
+	}
+	b.WriteString("  };\n}\n")
+	return b.String()
+}
+
+type pathOperation struct {
+	path, method string
+	*Operation
+}
+
+// sortedOperations returns operations ordered by operation ID
+func (d *Document) sortedOperations() []pathOperation {
+	var ops []pathOperation
+	for path, item := range d.Paths {
+		for method, op := range *item {
+			ops = append(ops, pathOperation{path, method, op})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].OperationID < ops[j].OperationID })
+	return ops
+}
+
+func tsOperation(path, method string, op *Operation) string {
+	var args, query, headers []string
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			args = append(args, fmt.Sprintf("%s: %s", p.Name, tsType(p.Schema, "")))
+		}
+	}
+	for _, p := range op.Parameters {
+		if p.In == "query" {
+			optional := "?"
+			if p.Required {
+				optional = ""
+			}
+			query = append(query, fmt.Sprintf("%s%s: %s", tsKey(p.Name), optional, tsType(p.Schema, "")))
+		}
+		if p.In == "header" {
+			headers = append(headers, fmt.Sprintf("%s?: %s", tsKey(p.Name), tsType(p.Schema, "")))
+		}
+	}
+
+	opts := []string{}
+	if len(query) > 0 {
+		optional := "?"
+		for _, q := range query {
+			if !strings.Contains(strings.SplitN(q, ":", 2)[0], "?") {
+				optional = ""
+			}
+		}
+		args = append(args, fmt.Sprintf("query%s: { %s }", optional, strings.Join(query, "; ")))
+		opts = append(opts, "query")
+	}
+	if op.RequestBody != nil {
+		args = append(args, "body: "+tsType(op.RequestBody.Content["application/json"].Schema, ""))
+		opts = append(opts, "body")
+	}
+	// Header parameters are all optional and come last
+	if len(headers) > 0 {
+		args = append(args, fmt.Sprintf("headers?: { %s }", strings.Join(headers, "; ")))
+		opts = append(opts, "headers")
+	}
+
+	result := "void"
+	for _, status := range []string{"200", "201"} {
+		if resp, ok := op.Responses[status]; ok && resp.Content != nil {
+			result = tsType(resp.Content["application/json"].Schema, "    ")
+		}
+	}
+
+	url := pathParamPattern.ReplaceAllString(path, "$${encodeURIComponent(String($1))}")
+	call := fmt.Sprintf("request<%s>('%s', `%s`", result, strings.ToUpper(method), url)
+	if len(opts) > 0 {
+		call += ", { " + strings.Join(opts, ", ") + " }"
+	}
+	call += ")"
+
+	var b strings.Builder
+	switch {
+	case op.Deprecated:
+		fmt.Fprintf(&b, "    /** @deprecated %s */\n", op.Summary)
+	case op.Summary != "":
+		fmt.Fprintf(&b, "    /** %s */\n", op.Summary)
+	}
+	fmt.Fprintf(&b, "    %s: (%s): Promise<%s> =>\n      %s,\n", op.OperationID, strings.Join(args, ", "), result, call)
+	return b.String()
+}
+
+// tsType renders a schema as a TypeScript type; indent is the indentation of
+// the line the type starts on
+func tsType(s *Schema, indent string) string {
+	if s == nil {
+		return "unknown"
+	}
+	var t string
+	switch {
+	case s.RefName() != "":
+		t = s.RefName()
+	case len(s.AllOf) == 1:
+		t = tsType(s.AllOf[0], indent)
+	case len(s.Enum) > 0:
+		quoted := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			quoted[i] = fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", `\'`))
+		}
+		t = strings.Join(quoted, " | ")
+	case s.Type == "string":
+		t = "string"
+	case s.Type == "integer", s.Type == "number":
+		t = "number"
+	case s.Type == "boolean":
+		t = "boolean"
+	case s.Type == "array":
+		item := tsType(s.Items, indent)
+		if strings.ContainsAny(item, "|{") {
+			item = "(" + item + ")"
+		}
+		t = item + "[]"
+	case s.Type == "object" && len(s.Properties) > 0:
+		t = tsObject(s, indent)
+	case s.Type == "object":
+		if extra, ok := s.AdditionalProperties.(*Schema); ok {
+			t = "Record<string, " + tsType(extra, indent) + ">"
+		} else {
+			t = "Record<string, unknown>"
+		}
+	default:
+		t = "unknown"
+	}
+	if s.Nullable && t != "unknown" {
+		t += " | null"
+	}
+	return t
+}
+
+func tsObject(s *Schema, indent string) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsKey(name), optional, tsType(s.Properties[name], indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func tsKey(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("'%s'", name)
+}
+
+const clientPrelude = `/** ApiError is thrown for non-2xx responses and carries the API error envelope */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly requestId?: string,
+    readonly details?: unknown
+  ) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
+export interface ClientOptions {
+  /** Base URL including the version prefix, e.g. http://localhost:8080/api/v1 */
+  baseUrl: string;
+  /** Extra headers sent with every request, e.g. X-API-Key */
+  headers?: Record<string, string>;
+}
+
+type QueryValue = string | number | boolean | undefined;
+
+export function createClient({ baseUrl, headers = {} }: ClientOptions) {
+  const request = async <T>(
+    method: string,
+    path: string,
+    {
+      query,
+      body,
+      headers: extra,
+    }: { query?: Record<string, QueryValue>; body?: unknown; headers?: Record<string, string | undefined> } = {}
+  ): Promise<T> => {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) params.set(key, String(value));
+    }
+    const sent: Record<string, string> = { ...headers };
+    for (const [key, value] of Object.entries(extra ?? {})) {
+      if (value !== undefined) sent[key] = value;
+    }
+    const qs = params.toString();
+    const response = await fetch(` + "`${baseUrl}${path}${qs ? `?${qs}` : ''}`" + `, {
+      method,
+      headers: body === undefined ? sent : { 'Content-Type': 'application/json', ...sent },
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const envelope = await response.json().catch(() => null);
+      const error = envelope?.error;
+      throw new ApiError(
+        response.status,
+        error?.code ?? 'unknown',
+        error?.message ?? response.statusText,
+        error?.request_id,
+        error?.details
+      );
+    }
+    if (response.status === 204) return undefined as T;
+    return response.json() as Promise<T>;
+  };
+`
@@ -0,0 +1,142 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists every mismatch between a value and a schema
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// Validate checks that the JSON in data matches schema. Only the shape is
+// checked (types, required and unknown properties); enum, length and range
+// constraints come from request validation rules and are ignored, since
+// stored data may predate them.
+func (d *Document) Validate(schema *Schema, data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var errs ValidationError
+	d.validate(schema, v, "$", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Document) validate(schema *Schema, v interface{}, path string, errs *ValidationError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if name := schema.RefName(); name != "" {
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			fail("unknown schema %q", name)
+			return
+		}
+		d.validate(target, v, path, errs)
+		return
+	}
+
+	if v == nil {
+		if !schema.Nullable && (schema.Type != "" || len(schema.AllOf) > 0) {
+			fail("null is not allowed")
+		}
+		return
+	}
+
+	for _, sub := range schema.AllOf {
+		d.validate(sub, v, path, errs)
+	}
+
+	switch schema.Type {
+	case "":
+		// Any value
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			fail("expected object, got %s", jsonType(v))
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := schema.Properties[k]; ok {
+				d.validate(prop, obj[k], path+"."+k, errs)
+				continue
+			}
+			switch extra := schema.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					fail("unexpected property %q", k)
+				}
+			case *Schema:
+				d.validate(extra, obj[k], path+"."+k, errs)
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			fail("expected array, got %s", jsonType(v))
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range arr {
+				d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			fail("expected string, got %s", jsonType(v))
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			fail("expected integer, got %s", jsonType(v))
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			fail("expected number, got %s", jsonType(v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected boolean, got %s", jsonType(v))
+		}
+	}
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,105 @@
+// Package overrides pins field values of people, terms and matters that
+// upstream data gets wrong, such as a misspelled name or a missing email.
+// Setting an override writes the value to the row at once, and the sync jobs
+// write the pinned value in place of the upstream one, so a fix made by an
+// editor survives later syncs. Each override records why it was set and by
+// whom.
+package overrides
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+)
+
+// Entity is a kind of row whose fields can be pinned
+type Entity struct {
+	Name   string   // e.g. "person"
+	Table  string   // the table its rows are in
+	Key    string   // the column an override's EntityID matches
+	Sync   string   // the sync_state entity of the table, which is also its cache tag
+	Fields []string // the fields that can be pinned: those the sync jobs write
+}
+
+// Entities lists every entity whose fields can be pinned
+var Entities = []Entity{
+	{Name: "person", Table: "people", Key: "id", Sync: db.EntityPeople,
+		Fields: []string{"first_name", "last_name", "full_name", "email", "phone", "website", "image_url"}},
+	{Name: "term", Table: "terms", Key: "id", Sync: db.EntityTerms,
+		Fields: []string{"start_date", "end_date", "term_number", "election_type"}},
+	{Name: "matter", Table: "matters", Key: "matter_id", Sync: db.EntityMatters,
+		Fields: []string{"matter_file", "matter_name", "matter_title", "matter_type_name", "matter_status_name",
+			"matter_intro_date", "matter_agenda_date", "matter_passed_date", "matter_enactment_date",
+			"matter_enactment_number", "matter_requester", "matter_sponsors", "matter_text"}},
+}
+
+// Lookup returns the entity named name
+func Lookup(name string) (Entity, bool) {
+	for _, e := range Entities {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+// ForTable returns the entity whose rows are in table
+func ForTable(table string) (Entity, bool) {
+	for _, e := range Entities {
+		if e.Table == table {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+// Pinnable reports whether field of e can be pinned
+func (e Entity) Pinnable(field string) bool {
+	return slices.Contains(e.Fields, field)
+}
+
+// Override is a pinned field value, as stored in field_overrides
+type Override struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"`    // "person", "term" or "matter"
+	EntityID  string          `json:"entity_id"` // the row's id, or matter_id for matters
+	Field     string          `json:"field"`
+	Value     json.RawMessage `json:"value"` // the value the row keeps, possibly null
+	Reason    string          `json:"reason"`
+	CreatedBy string          `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Filter selects overrides to list
+type Filter struct {
+	Entity   string
+	EntityID string
+}
+
+// Store keeps overrides
+type Store interface {
+	// List returns the overrides matching filter, by entity, entity ID
+	// and field
+	List(ctx context.Context, filter Filter) ([]Override, error)
+	// Set pins a field, replacing any override of the same field, and
+	// writes the value to the row. It returns ErrEntityNotFound if the row
+	// does not exist.
+	Set(ctx context.Context, o Override) (Override, error)
+	// Delete unpins a field, returning the override removed or
+	// ErrNotFound. The row keeps the value until the next sync.
+	Delete(ctx context.Context, id int64) (Override, error)
+}
+
+// Default is the store behind the API, set when the server starts
+var Default Store
+
+// Errors returned by stores
+var (
+	ErrNotFound       = errors.New("override not found")
+	ErrEntityNotFound = errors.New("overridden row not found")
+)
@@ -0,0 +1,144 @@
+package overrides
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// PostgrestStore keeps overrides in the field_overrides table
+// (db/schema_overrides.sql)
+type PostgrestStore struct {
+	client func(ctx context.Context) *postgrest.Client
+}
+
+// NewPostgrestStore creates a store using the clients db.WithContext returns
+func NewPostgrestStore() *PostgrestStore {
+	return &PostgrestStore{client: db.WithContext}
+}
+
+// overrideColumns are the field_overrides columns read back
+const overrideColumns = "id, entity, entity_id, field, value, reason, created_by, created_at, updated_at"
+
+// overrideRow is the field_overrides table representation
+type overrideRow struct {
+	ID        int64           `json:"id,omitempty"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Field     string          `json:"field"`
+	Value     json.RawMessage `json:"value"`
+	Reason    string          `json:"reason"`
+	CreatedBy string          `json:"created_by"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (row overrideRow) toOverride() Override {
+	o := Override{
+		ID:        row.ID,
+		Entity:    row.Entity,
+		EntityID:  row.EntityID,
+		Field:     row.Field,
+		Value:     row.Value,
+		Reason:    row.Reason,
+		CreatedBy: row.CreatedBy,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.CreatedAt != nil {
+		o.CreatedAt = *row.CreatedAt
+	}
+	if len(o.Value) == 0 {
+		o.Value = json.RawMessage("null")
+	}
+	return o
+}
+
+// List implements Store
+func (s *PostgrestStore) List(ctx context.Context, filter Filter) ([]Override, error) {
+	query := s.client(ctx).From("field_overrides").Select(overrideColumns, "", false)
+	if filter.Entity != "" {
+		query = query.Eq("entity", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Eq("entity_id", filter.EntityID)
+	}
+	query = query.Order("entity", &postgrest.OrderOpts{Ascending: true}).
+		Order("entity_id", &postgrest.OrderOpts{Ascending: true}).
+		Order("field", &postgrest.OrderOpts{Ascending: true})
+
+	var rows []overrideRow
+	if _, err := query.ExecuteTo(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list overrides: %w", err)
+	}
+	list := make([]Override, len(rows))
+	for i, row := range rows {
+		list[i] = row.toOverride()
+	}
+	return list, nil
+}
+
+// Set implements Store. The row is written first, so an override is never
+// stored for a row that does not exist.
+func (s *PostgrestStore) Set(ctx context.Context, o Override) (Override, error) {
+	entity, ok := Lookup(o.Entity)
+	if !ok {
+		return Override{}, fmt.Errorf("unknown entity %q", o.Entity)
+	}
+	var value interface{}
+	if err := json.Unmarshal(o.Value, &value); err != nil {
+		return Override{}, fmt.Errorf("invalid value for %s: %w", o.Field, err)
+	}
+
+	var updated []map[string]interface{}
+	_, err := s.client(ctx).From(entity.Table).
+		Update(map[string]interface{}{o.Field: value}, "representation", "").
+		Eq(entity.Key, o.EntityID).
+		ExecuteTo(&updated)
+	if err != nil {
+		return Override{}, fmt.Errorf("failed to write %s of %s %s: %w", o.Field, o.Entity, o.EntityID, err)
+	}
+	if len(updated) == 0 {
+		return Override{}, ErrEntityNotFound
+	}
+
+	var rows []overrideRow
+	_, err = s.client(ctx).From("field_overrides").
+		Upsert(overrideRow{
+			Entity:    o.Entity,
+			EntityID:  o.EntityID,
+			Field:     o.Field,
+			Value:     o.Value,
+			Reason:    o.Reason,
+			CreatedBy: o.CreatedBy,
+			UpdatedAt: time.Now().UTC(),
+		}, "entity,entity_id,field", "representation", "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return Override{}, fmt.Errorf("failed to store override: %w", err)
+	}
+	if len(rows) == 0 {
+		return Override{}, fmt.Errorf("failed to store override: no row returned")
+	}
+	return rows[0].toOverride(), nil
+}
+
+// Delete implements Store
+func (s *PostgrestStore) Delete(ctx context.Context, id int64) (Override, error) {
+	var rows []overrideRow
+	_, err := s.client(ctx).From("field_overrides").
+		Delete("representation", "").
+		Eq("id", strconv.FormatInt(id, 10)).
+		ExecuteTo(&rows)
+	if err != nil {
+		return Override{}, fmt.Errorf("failed to delete override %d: %w", id, err)
+	}
+	if len(rows) == 0 {
+		return Override{}, ErrNotFound
+	}
+	return rows[0].toOverride(), nil
+}
@@ -0,0 +1,120 @@
+// Package provenance records where each field of people, terms and matters
+// came from: the source system, the upstream record, when it was fetched and
+// the sync run or request that wrote it. Sync jobs record the fields they
+// write when a plan is applied, and the API records fields set by hand, so
+// editors can audit a value and public pages can cite its source.
+package provenance
+
+import (
+	"fmt"
+	"time"
+
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Systems a value comes from
+const (
+	SystemLegistar = "legistar" // the Legistar Web API
+	SystemELMS     = "elms"     // the City Clerk's ELMS person export
+	SystemOverride = "override" // pinned by an editor; see package overrides
+	SystemManual   = "manual"   // written through the API
+)
+
+// Source is where a value came from
+type Source struct {
+	System    string    `json:"system"`
+	RecordID  string    `json:"record_id,omitempty"` // the upstream record, e.g. "/persons/162"
+	FetchedAt time.Time `json:"fetched_at"`          // when the value was read from the system, or entered
+	RunID     string    `json:"run_id,omitempty"`    // the sync run or request that wrote it, as in job_runs.request_id
+}
+
+// Field is the source of one field of a row, as stored in field_provenance
+type Field struct {
+	Entity   string `json:"entity"`    // "person", "term" or "matter"
+	EntityID string `json:"entity_id"` // the row's id, or matter_id for matters
+	Field    string `json:"field"`
+	Source
+	UpdatedAt time.Time `json:"updated_at"` // when the value was written
+}
+
+// Fields returns the provenance of each of fields of a row, all from source
+func Fields(entity, entityID string, fields []string, source Source) []Field {
+	now := time.Now().UTC()
+	rows := make([]Field, len(fields))
+	for i, field := range fields {
+		rows[i] = Field{Entity: entity, EntityID: entityID, Field: field, Source: source, UpdatedAt: now}
+	}
+	return rows
+}
+
+// pageSize bounds the rows written per request
+const pageSize = 500
+
+// fieldRow is the field_provenance table representation. Every row of a
+// bulk upsert must have the same keys, so none are omitted.
+type fieldRow struct {
+	Entity    string    `json:"entity"`
+	EntityID  string    `json:"entity_id"`
+	Field     string    `json:"field"`
+	System    string    `json:"system"`
+	RecordID  *string   `json:"record_id"`
+	FetchedAt time.Time `json:"fetched_at"`
+	RunID     *string   `json:"run_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Record stores the provenance of fields, replacing what was recorded for
+// the same fields before
+func Record(client *postgrest.Client, fields []Field) error {
+	for start := 0; start < len(fields); start += pageSize {
+		end := start + pageSize
+		if end > len(fields) {
+			end = len(fields)
+		}
+		rows := make([]fieldRow, 0, end-start)
+		for _, f := range fields[start:end] {
+			rows = append(rows, fieldRow{
+				Entity:    f.Entity,
+				EntityID:  f.EntityID,
+				Field:     f.Field,
+				System:    f.System,
+				RecordID:  optional(f.RecordID),
+				FetchedAt: f.FetchedAt,
+				RunID:     optional(f.RunID),
+				UpdatedAt: f.UpdatedAt,
+			})
+		}
+		_, _, err := client.From("field_provenance").
+			Upsert(rows, "entity,entity_id,field", "", "minimal").
+			Execute()
+		if err != nil {
+			return fmt.Errorf("failed to record provenance: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the recorded provenance of a row's fields, by field
+func List(client *postgrest.Client, entity, entityID string) ([]Field, error) {
+	var fields []Field
+	_, err := client.From("field_provenance").
+		Select("entity,entity_id,field,system,record_id,fetched_at,run_id,updated_at", "", false).
+		Eq("entity", entity).
+		Eq("entity_id", entityID).
+		Order("field", &postgrest.OrderOpts{Ascending: true}).
+		ExecuteTo(&fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load provenance of %s %s: %w", entity, entityID, err)
+	}
+	if fields == nil {
+		fields = []Field{}
+	}
+	return fields, nil
+}
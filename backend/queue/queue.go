@@ -0,0 +1,312 @@
+// Package queue is a durable queue of small units of sync work, such as
+// fetching the votes of one matter. A job splits its work into a batch of
+// units, a pool of workers runs them with retries and backoff, and units that
+// keep failing are set aside as dead letters. Units are stored with their
+// results, so a job that stops part way resumes its batch on the next run
+// instead of starting over.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/tracing"
+)
+
+// Statuses of a unit
+const (
+	StatusPending = "pending" // waiting to run, possibly after a failed attempt
+	StatusRunning = "running" // leased to a worker
+	StatusDone    = "done"
+	StatusDead    = "dead" // failed MaxAttempts times; kept until retried or discarded
+)
+
+// Statuses lists every status, in lifecycle order
+var Statuses = []string{StatusPending, StatusRunning, StatusDone, StatusDead}
+
+// Task is a unit of work, as stored in queue_tasks
+type Task struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`  // what the unit does, e.g. "votes"
+	Batch       string          `json:"batch"` // the job run the unit belongs to
+	Key         string          `json:"key"`   // idempotency key: kind, batch and subject
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"` // when a pending unit may next run
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Subject returns the part of the task's key after its kind and batch
+func (t Task) Subject() string {
+	prefix := t.Kind + ":" + t.Batch + ":"
+	if len(t.Key) > len(prefix) && t.Key[:len(prefix)] == prefix {
+		return t.Key[len(prefix):]
+	}
+	return t.Key
+}
+
+// NewTask creates a pending unit of kind in batch for subject, e.g. a
+// matter ID. Units with the same kind, batch and subject are queued once.
+func NewTask(kind, batch, subject string, payload interface{}) (Task, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Task{}, fmt.Errorf("invalid payload for %s %s: %w", kind, subject, err)
+	}
+	return Task{
+		Kind:    kind,
+		Batch:   batch,
+		Key:     kind + ":" + batch + ":" + subject,
+		Payload: body,
+		Status:  StatusPending,
+	}, nil
+}
+
+// NewBatch returns a new batch name for units of scope, e.g. a jurisdiction
+func NewBatch(scope string) string {
+	return scope + "/" + time.Now().UTC().Format("20060102T150405.000")
+}
+
+// Default is the store behind the admin endpoints, set when the server
+// starts
+var Default Store
+
+// Errors returned by stores
+var (
+	ErrNotFound = errors.New("task not found")
+)
+
+// Config controls retries and leases
+type Config struct {
+	Workers     int           // units run at once; 1 if zero
+	MaxAttempts int           // attempts before a unit is dead; DefaultMaxAttempts if zero
+	Backoff     time.Duration // wait before the first retry, doubled each attempt; DefaultBackoff if zero
+	Lease       time.Duration // how long a worker holds a unit; DefaultLease if zero
+}
+
+// Defaults of Config
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 2 * time.Second
+	DefaultLease       = 5 * time.Minute
+
+	// maxBackoff caps the wait between attempts
+	maxBackoff = 10 * time.Minute
+)
+
+// WithDefaults returns c with zero fields set to their defaults
+func (c Config) WithDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultBackoff
+	}
+	if c.Lease <= 0 {
+		c.Lease = DefaultLease
+	}
+	return c
+}
+
+// backoff returns the wait after a unit's attempts-th failed attempt
+func (c Config) backoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return maxBackoff
+	}
+	if d := c.Backoff << (attempts - 1); d < maxBackoff {
+		return d
+	}
+	return maxBackoff
+}
+
+// Handler runs one unit and returns its result
+type Handler func(ctx context.Context, task Task) (json.RawMessage, error)
+
+// Pool runs the units of one kind with a fixed number of workers
+type Pool struct {
+	Store   Store
+	Kind    string
+	Handler Handler
+	Config  Config
+	Worker  string // identifies this process in leases; host:pid if empty
+
+	// Finished, if set, is called with each unit once its outcome is
+	// recorded, from the worker that ran it
+	Finished func(task Task)
+
+	now func() time.Time
+}
+
+// pollInterval bounds how long Drain waits for units that are not runnable
+// yet, such as those leased to a worker that died
+const pollInterval = 2 * time.Second
+
+// Drain runs the batch's units until none is pending or running, and
+// returns when the last one finishes or ctx is done. Units leased by another
+// worker are waited for, and taken over if their lease expires.
+func (p *Pool) Drain(ctx context.Context, batch string) error {
+	cfg := p.Config.WithDefaults()
+	worker := p.Worker
+	if worker == "" {
+		host, _ := os.Hostname()
+		worker = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	now := p.now
+	if now == nil {
+		now = time.Now
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	finished := make(chan struct{}, cfg.Workers)
+	inFlight := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var claimed []Task
+		if free := cfg.Workers - inFlight; free > 0 {
+			var err error
+			claimed, err = p.Store.Claim(ctx, p.Kind, batch, worker, free, cfg.Lease)
+			if err != nil {
+				return err
+			}
+		}
+		for _, task := range claimed {
+			inFlight++
+			wg.Add(1)
+			go func(task Task) {
+				defer wg.Done()
+				p.run(ctx, cfg, task, now)
+				finished <- struct{}{}
+			}(task)
+		}
+
+		if inFlight > 0 {
+			// Claim again as soon as a worker frees up
+			select {
+			case <-ctx.Done():
+			case <-finished:
+				inFlight--
+			}
+			continue
+		}
+		if len(claimed) > 0 {
+			continue
+		}
+
+		next, ok, err := p.Store.Next(ctx, p.Kind, batch)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		wait := next.Sub(now())
+		if wait > pollInterval {
+			wait = pollInterval
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+// run runs one claimed unit and records its outcome
+func (p *Pool) run(ctx context.Context, cfg Config, task Task, now func() time.Time) {
+	ctx = logging.With(ctx, "unit", task.Key, "attempt", task.Attempts)
+	ctx, span := tracing.Start(ctx, "unit "+task.Kind)
+	result, err := p.Handler(ctx, task)
+	tracing.End(span, err)
+
+	task.LockedBy = ""
+	task.LockedUntil = nil
+	switch {
+	case err == nil:
+		task.Status = StatusDone
+		task.Result = result
+		task.LastError = ""
+	case ctx.Err() != nil:
+		// Stopped rather than failed; the attempt doesn't count
+		task.Status = StatusPending
+		task.Attempts--
+		task.RunAt = now().UTC()
+	case task.Attempts >= task.MaxAttempts:
+		task.Status = StatusDead
+		task.LastError = err.Error()
+		slog.WarnContext(ctx, "unit failed for the last time", "error", err)
+	default:
+		task.Status = StatusPending
+		task.LastError = err.Error()
+		task.RunAt = now().UTC().Add(cfg.backoff(task.Attempts))
+		slog.InfoContext(ctx, "unit failed; retrying", "error", err, "retry_at", task.RunAt)
+	}
+
+	// Record the outcome even when ctx is done
+	if err := p.Store.Finish(context.WithoutCancel(ctx), task); err != nil {
+		slog.ErrorContext(ctx, "failed to record unit outcome", "error", err)
+	}
+	if p.Finished != nil {
+		p.Finished(task)
+	}
+}
+
+// Store holds units
+type Store interface {
+	// Enqueue adds the tasks whose keys are not queued yet and reports how
+	// many were added. maxAttempts applies to the added tasks.
+	Enqueue(ctx context.Context, tasks []Task, maxAttempts int) (int, error)
+	// Claim leases up to n runnable units of kind in batch to worker until
+	// lease passes: pending units due to run, and running units whose lease
+	// expired. Each claim counts as an attempt.
+	Claim(ctx context.Context, kind, batch, worker string, n int, lease time.Duration) ([]Task, error)
+	// Finish records the outcome of a claimed unit
+	Finish(ctx context.Context, task Task) error
+	// Next reports when the next unfinished unit of kind in batch becomes
+	// runnable, or false if every unit is done or dead
+	Next(ctx context.Context, kind, batch string) (time.Time, bool, error)
+	// Unfinished returns the oldest batch of kind whose name starts with
+	// scope and that still has pending or running units, or ""
+	Unfinished(ctx context.Context, kind, scope string) (string, error)
+	// Batch returns the units of kind in batch, with their results
+	Batch(ctx context.Context, kind, batch string) ([]Task, error)
+	// DeleteDone removes the done units of kind in batch once their results
+	// have been used
+	DeleteDone(ctx context.Context, kind, batch string) error
+	// List returns units, newest first, without their results
+	List(ctx context.Context, filter Filter) ([]Task, error)
+	// Count returns the number of units of kind with status
+	Count(ctx context.Context, kind, status string) (int, error)
+	// Retry makes a dead unit pending again
+	Retry(ctx context.Context, id int64) (Task, error)
+	// Discard deletes a dead unit
+	Discard(ctx context.Context, id int64) error
+}
+
+// Filter selects units to List; empty fields match everything
+type Filter struct {
+	Kind   string
+	Status string
+	Limit  int
+}
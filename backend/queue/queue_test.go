@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTasks creates units of kind "votes" in batch for subjects
+func newTasks(t *testing.T, batch string, subjects ...string) []Task {
+	t.Helper()
+	tasks := make([]Task, len(subjects))
+	for i, subject := range subjects {
+		task, err := NewTask("votes", batch, subject, map[string]string{"matter_id": subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks[i] = task
+	}
+	return tasks
+}
+
+// byStatus counts the batch's units by status
+func byStatus(t *testing.T, store Store, batch string) map[string]int {
+	t.Helper()
+	tasks, err := store.Batch(context.Background(), "votes", batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, task := range tasks {
+		counts[task.Status]++
+	}
+	return counts
+}
+
+func TestEnqueueIsIdempotent(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	added, err := store.Enqueue(ctx, newTasks(t, "1/a", "10", "11"), 3)
+	if err != nil || added != 2 {
+		t.Fatalf("expected 2 added, got %d (%v)", added, err)
+	}
+	added, _ = store.Enqueue(ctx, newTasks(t, "1/a", "11", "12"), 3)
+	if added != 1 {
+		t.Errorf("expected only the new key to be added, got %d", added)
+	}
+	added, _ = store.Enqueue(ctx, newTasks(t, "1/b", "11"), 3)
+	if added != 1 {
+		t.Errorf("expected the same subject in another batch to be added, got %d", added)
+	}
+}
+
+func TestDrainRetriesThenDeadLetters(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	store.Enqueue(ctx, newTasks(t, "1/a", "10", "11", "12"), 3)
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	pool := &Pool{
+		Store:  store,
+		Kind:   "votes",
+		Config: Config{Workers: 2, Backoff: time.Millisecond},
+		Handler: func(ctx context.Context, task Task) (json.RawMessage, error) {
+			mu.Lock()
+			calls[task.Subject()]++
+			n := calls[task.Subject()]
+			mu.Unlock()
+			switch {
+			case task.Subject() == "11":
+				return nil, errors.New("API returned status 500")
+			case task.Subject() == "12" && n == 1:
+				return nil, errors.New("timeout")
+			}
+			return json.RawMessage(`[{"VoteId": 1}]`), nil
+		},
+	}
+	if err := pool.Drain(ctx, "1/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls["10"] != 1 || calls["11"] != 3 || calls["12"] != 2 {
+		t.Errorf("unexpected attempts %v", calls)
+	}
+	tasks, _ := store.Batch(ctx, "votes", "1/a")
+	for _, task := range tasks {
+		switch task.Subject() {
+		case "11":
+			if task.Status != StatusDead || task.LastError != "API returned status 500" || task.Attempts != 3 {
+				t.Errorf("expected a dead letter, got %+v", task)
+			}
+		default:
+			if task.Status != StatusDone || string(task.Result) != `[{"VoteId": 1}]` || task.LastError != "" {
+				t.Errorf("expected a done unit with its result, got %+v", task)
+			}
+		}
+	}
+
+	// Dead letters can be retried and discarded
+	var dead Task
+	for _, task := range tasks {
+		if task.Status == StatusDead {
+			dead = task
+		}
+	}
+	retried, err := store.Retry(ctx, dead.ID)
+	if err != nil || retried.Status != StatusPending || retried.Attempts != 0 {
+		t.Errorf("expected the dead unit pending again, got %+v (%v)", retried, err)
+	}
+	if _, err := store.Retry(ctx, dead.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected only dead units to be retried, got %v", err)
+	}
+	if err := store.Discard(ctx, dead.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected only dead units to be discarded, got %v", err)
+	}
+}
+
+func TestUnfinishedBatchResumes(t *testing.T) {
+	store := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	store.Enqueue(ctx, newTasks(t, "1/a", "10", "11", "12"), 3)
+
+	// The first run stops after one unit
+	var ran atomic.Int32
+	pool := &Pool{Store: store, Kind: "votes", Handler: func(ctx context.Context, task Task) (json.RawMessage, error) {
+		if ran.Add(1) > 1 {
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return json.RawMessage(`[]`), nil
+	}}
+	if err := pool.Drain(ctx, "1/a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the drain to stop, got %v", err)
+	}
+	if got := byStatus(t, store, "1/a"); got[StatusDone] != 1 || got[StatusPending] != 2 {
+		t.Fatalf("expected 1 done and 2 pending, got %v", got)
+	}
+
+	batch, err := store.Unfinished(context.Background(), "votes", "1/")
+	if err != nil || batch != "1/a" {
+		t.Fatalf("expected batch 1/a to be unfinished, got %q (%v)", batch, err)
+	}
+	if other, _ := store.Unfinished(context.Background(), "votes", "2/"); other != "" {
+		t.Errorf("expected no unfinished batch of another scope, got %q", other)
+	}
+
+	// The next run only runs the units left, and the stopped attempt didn't count
+	var resumed []string
+	pool.Handler = func(ctx context.Context, task Task) (json.RawMessage, error) {
+		if task.Attempts != 1 {
+			t.Errorf("%s: expected attempt 1, got %d", task.Key, task.Attempts)
+		}
+		resumed = append(resumed, task.Subject())
+		return json.RawMessage(`[]`), nil
+	}
+	if err := pool.Drain(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed) != 2 {
+		t.Errorf("expected the 2 pending units to run, got %v", resumed)
+	}
+
+	store.DeleteDone(context.Background(), "votes", batch)
+	if got := byStatus(t, store, batch); len(got) != 0 {
+		t.Errorf("expected done units deleted, got %v", got)
+	}
+	if batch, _ := store.Unfinished(context.Background(), "votes", "1/"); batch != "" {
+		t.Errorf("expected no unfinished batch, got %q", batch)
+	}
+}
+
+func TestExpiredLeaseIsTakenOver(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	store.Enqueue(ctx, newTasks(t, "1/a", "10"), 3)
+
+	// A worker that died holds the unit
+	claimed, _ := store.Claim(ctx, "votes", "1/a", "dead:1", 1, 20*time.Millisecond)
+	if len(claimed) != 1 {
+		t.Fatalf("expected a claim, got %v", claimed)
+	}
+	if again, _ := store.Claim(ctx, "votes", "1/a", "live:2", 1, time.Minute); len(again) != 0 {
+		t.Errorf("expected a leased unit not to be claimed, got %v", again)
+	}
+
+	pool := &Pool{Store: store, Kind: "votes", Worker: "live:2", Handler: func(ctx context.Context, task Task) (json.RawMessage, error) {
+		return json.RawMessage(`[]`), nil
+	}}
+	if err := pool.Drain(ctx, "1/a"); err != nil {
+		t.Fatal(err)
+	}
+	tasks, _ := store.Batch(ctx, "votes", "1/a")
+	if tasks[0].Status != StatusDone || tasks[0].Attempts != 2 {
+		t.Errorf("expected the unit taken over and done on attempt 2, got %+v", tasks[0])
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{Backoff: time.Second}.WithDefaults()
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 30: maxBackoff} {
+		if got := cfg.backoff(attempts); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempts, want, got)
+		}
+	}
+}
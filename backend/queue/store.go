@@ -0,0 +1,649 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// MemoryStore keeps units in process. Batches survive failed runs but not
+// restarts; it is used for dry runs, tests and deployments without the
+// queue_tasks table.
+type MemoryStore struct {
+	mu     sync.Mutex
+	nextID int64
+	tasks  map[int64]*Task
+	keys   map[string]int64
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tasks: make(map[int64]*Task), keys: make(map[string]int64)}
+}
+
+// Enqueue implements Store
+func (s *MemoryStore) Enqueue(ctx context.Context, tasks []Task, maxAttempts int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	added := 0
+	for _, task := range tasks {
+		t := task
+		if _, ok := s.keys[t.Key]; ok {
+			continue
+		}
+		s.nextID++
+		t.ID = s.nextID
+		t.Status = StatusPending
+		t.MaxAttempts = maxAttempts
+		t.RunAt, t.CreatedAt, t.UpdatedAt = now, now, now
+		s.tasks[t.ID] = &t
+		s.keys[t.Key] = t.ID
+		added++
+	}
+	return added, nil
+}
+
+// runnable reports whether t may be claimed at now
+func runnable(t *Task, now time.Time) bool {
+	switch t.Status {
+	case StatusPending:
+		return !t.RunAt.After(now)
+	case StatusRunning:
+		return t.LockedUntil != nil && t.LockedUntil.Before(now)
+	}
+	return false
+}
+
+// sorted returns the tasks matching keep by ID
+func (s *MemoryStore) sorted(keep func(*Task) bool) []*Task {
+	var tasks []*Task
+	for _, t := range s.tasks {
+		if keep(t) {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// Claim implements Store
+func (s *MemoryStore) Claim(ctx context.Context, kind, batch, worker string, n int, lease time.Duration) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	until := now.Add(lease)
+
+	var claimed []Task
+	for _, t := range s.sorted(func(t *Task) bool { return t.Kind == kind && t.Batch == batch && runnable(t, now) }) {
+		if len(claimed) == n {
+			break
+		}
+		t.Status = StatusRunning
+		t.Attempts++
+		t.LockedBy = worker
+		t.LockedUntil = &until
+		t.UpdatedAt = now
+		claimed = append(claimed, *t)
+	}
+	return claimed, nil
+}
+
+// Finish implements Store
+func (s *MemoryStore) Finish(ctx context.Context, task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[task.ID]; !ok {
+		return ErrNotFound
+	}
+	task.UpdatedAt = time.Now().UTC()
+	s.tasks[task.ID] = &task
+	return nil
+}
+
+// Next implements Store
+func (s *MemoryStore) Next(ctx context.Context, kind, batch string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	found := false
+	for _, t := range s.tasks {
+		if t.Kind != kind || t.Batch != batch {
+			continue
+		}
+		var at time.Time
+		switch {
+		case t.Status == StatusPending:
+			at = t.RunAt
+		case t.Status == StatusRunning && t.LockedUntil != nil:
+			at = *t.LockedUntil
+		default:
+			continue
+		}
+		if !found || at.Before(next) {
+			next, found = at, true
+		}
+	}
+	return next, found, nil
+}
+
+// Unfinished implements Store
+func (s *MemoryStore) Unfinished(ctx context.Context, kind, scope string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.sorted(func(t *Task) bool {
+		return t.Kind == kind && strings.HasPrefix(t.Batch, scope) && (t.Status == StatusPending || t.Status == StatusRunning)
+	}) {
+		return t.Batch, nil
+	}
+	return "", nil
+}
+
+// Batch implements Store
+func (s *MemoryStore) Batch(ctx context.Context, kind, batch string) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tasks []Task
+	for _, t := range s.sorted(func(t *Task) bool { return t.Kind == kind && t.Batch == batch }) {
+		tasks = append(tasks, *t)
+	}
+	return tasks, nil
+}
+
+// DeleteDone implements Store
+func (s *MemoryStore) DeleteDone(ctx context.Context, kind, batch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, t := range s.tasks {
+		if t.Kind == kind && t.Batch == batch && t.Status == StatusDone {
+			delete(s.keys, t.Key)
+			delete(s.tasks, id)
+		}
+	}
+	return nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches := s.sorted(func(t *Task) bool {
+		return (filter.Kind == "" || t.Kind == filter.Kind) && (filter.Status == "" || t.Status == filter.Status)
+	})
+	tasks := []Task{}
+	for i := len(matches) - 1; i >= 0 && (filter.Limit <= 0 || len(tasks) < filter.Limit); i-- {
+		t := *matches[i]
+		t.Result = nil
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+// Count implements Store
+func (s *MemoryStore) Count(ctx context.Context, kind, status string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, t := range s.tasks {
+		if t.Kind == kind && t.Status == status {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Retry implements Store
+func (s *MemoryStore) Retry(ctx context.Context, id int64) (Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok || t.Status != StatusDead {
+		return Task{}, ErrNotFound
+	}
+	now := time.Now().UTC()
+	t.Status = StatusPending
+	t.Attempts = 0
+	t.RunAt, t.UpdatedAt = now, now
+	return *t, nil
+}
+
+// Discard implements Store
+func (s *MemoryStore) Discard(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok || t.Status != StatusDead {
+		return ErrNotFound
+	}
+	delete(s.keys, t.Key)
+	delete(s.tasks, id)
+	return nil
+}
+
+// PostgrestStore keeps units in the queue_tasks table
+// (db/schema_queue.sql), so batches survive restarts and instances sharing
+// a database can drain one batch together
+type PostgrestStore struct {
+	client func(ctx context.Context) *postgrest.Client
+}
+
+// NewPostgrestStore creates a store using the clients db.WithContext returns
+func NewPostgrestStore() *PostgrestStore {
+	return &PostgrestStore{client: db.WithContext}
+}
+
+// taskRow is the queue_tasks table representation
+type taskRow struct {
+	ID          int64      `json:"id,omitempty"`
+	Kind        string     `json:"kind"`
+	Batch       string     `json:"batch"`
+	Key         string     `json:"key"`
+	Payload     rawJSON    `json:"payload"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	RunAt       time.Time  `json:"run_at"`
+	LockedBy    *string    `json:"locked_by"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LastError   *string    `json:"last_error"`
+	Result      rawJSON    `json:"result,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// rawJSON is a JSONB value; null reads as empty
+type rawJSON []byte
+
+// MarshalJSON writes the value, or null when empty
+func (r rawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON keeps the value, reading null as empty
+func (r *rawJSON) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*r = nil
+		return nil
+	}
+	*r = append((*r)[:0], b...)
+	return nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func toRow(t Task) taskRow {
+	return taskRow{
+		ID:          t.ID,
+		Kind:        t.Kind,
+		Batch:       t.Batch,
+		Key:         t.Key,
+		Payload:     rawJSON(t.Payload),
+		Status:      t.Status,
+		Attempts:    t.Attempts,
+		MaxAttempts: t.MaxAttempts,
+		RunAt:       t.RunAt,
+		LockedBy:    optional(t.LockedBy),
+		LockedUntil: t.LockedUntil,
+		LastError:   optional(t.LastError),
+		Result:      rawJSON(t.Result),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+func (row taskRow) toTask() Task {
+	t := Task{
+		ID:          row.ID,
+		Kind:        row.Kind,
+		Batch:       row.Batch,
+		Key:         row.Key,
+		Payload:     []byte(row.Payload),
+		Status:      row.Status,
+		Attempts:    row.Attempts,
+		MaxAttempts: row.MaxAttempts,
+		RunAt:       row.RunAt,
+		LockedUntil: row.LockedUntil,
+		Result:      []byte(row.Result),
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	if row.LockedBy != nil {
+		t.LockedBy = *row.LockedBy
+	}
+	if row.LastError != nil {
+		t.LastError = *row.LastError
+	}
+	return t
+}
+
+// Columns of queue_tasks read back, with and without results
+const (
+	taskColumns       = "id, kind, batch, key, payload, status, attempts, max_attempts, run_at, locked_by, locked_until, last_error, created_at, updated_at"
+	taskResultColumns = taskColumns + ", result"
+)
+
+// pageSize bounds the rows read or written per request
+const pageSize = 500
+
+// timestamp formats t for a PostgREST filter
+func timestamp(t time.Time) string {
+	return `"` + t.UTC().Format(time.RFC3339Nano) + `"`
+}
+
+// Enqueue implements Store. Keys already queued are skipped; an insert that
+// races with another instance falls back to one row at a time.
+func (s *PostgrestStore) Enqueue(ctx context.Context, tasks []Task, maxAttempts int) (int, error) {
+	added := 0
+	for start := 0; start < len(tasks); start += pageSize {
+		end := start + pageSize
+		if end > len(tasks) {
+			end = len(tasks)
+		}
+		page := tasks[start:end]
+
+		keys := make([]string, len(page))
+		for i, t := range page {
+			keys[i] = t.Key
+		}
+		var existing []struct {
+			Key string `json:"key"`
+		}
+		_, err := s.client(ctx).From("queue_tasks").
+			Select("key", "", false).
+			In("key", keys).
+			ExecuteTo(&existing)
+		if err != nil {
+			return added, fmt.Errorf("failed to load queued units: %w", err)
+		}
+		queued := make(map[string]bool, len(existing))
+		for _, e := range existing {
+			queued[e.Key] = true
+		}
+
+		now := time.Now().UTC()
+		var rows []taskRow
+		for _, t := range page {
+			if queued[t.Key] {
+				continue
+			}
+			queued[t.Key] = true
+			t.ID = 0
+			t.Status = StatusPending
+			t.MaxAttempts = maxAttempts
+			t.RunAt, t.CreatedAt, t.UpdatedAt = now, now, now
+			rows = append(rows, toRow(t))
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		_, _, err = s.client(ctx).From("queue_tasks").Insert(rows, false, "", "minimal", "").Execute()
+		if err == nil {
+			added += len(rows)
+			continue
+		}
+		if apierror.UpstreamCode(err) != "23505" {
+			return added, fmt.Errorf("failed to queue units: %w", err)
+		}
+		for _, row := range rows {
+			_, _, err := s.client(ctx).From("queue_tasks").Insert(row, false, "", "minimal", "").Execute()
+			switch {
+			case err == nil:
+				added++
+			case apierror.UpstreamCode(err) != "23505":
+				return added, fmt.Errorf("failed to queue unit %s: %w", row.Key, err)
+			}
+		}
+	}
+	return added, nil
+}
+
+// Claim implements Store. Candidates are read first, then each is leased
+// with an update conditioned on the attempts read, so when two workers race
+// for a unit only one update matches.
+func (s *PostgrestStore) Claim(ctx context.Context, kind, batch, worker string, n int, lease time.Duration) ([]Task, error) {
+	now := time.Now().UTC()
+	var candidates []taskRow
+	_, err := s.client(ctx).From("queue_tasks").
+		Select("id, attempts", "", false).
+		Eq("kind", kind).
+		Eq("batch", batch).
+		Or(fmt.Sprintf("and(status.eq.pending,run_at.lte.%s),and(status.eq.running,locked_until.lt.%s)", timestamp(now), timestamp(now)), "").
+		Order("id", &postgrest.OrderOpts{Ascending: true}).
+		Limit(n, "").
+		ExecuteTo(&candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim units: %w", err)
+	}
+
+	until := now.Add(lease)
+	var claimed []Task
+	for _, c := range candidates {
+		var rows []taskRow
+		_, err := s.client(ctx).From("queue_tasks").
+			Update(map[string]interface{}{
+				"status":       StatusRunning,
+				"attempts":     c.Attempts + 1,
+				"locked_by":    worker,
+				"locked_until": until,
+				"updated_at":   now,
+			}, "representation", "").
+			Eq("id", strconv.FormatInt(c.ID, 10)).
+			Eq("attempts", strconv.Itoa(c.Attempts)).
+			In("status", []string{StatusPending, StatusRunning}).
+			ExecuteTo(&rows)
+		if err != nil {
+			return claimed, fmt.Errorf("failed to claim unit %d: %w", c.ID, err)
+		}
+		for _, row := range rows {
+			claimed = append(claimed, row.toTask())
+		}
+	}
+	return claimed, nil
+}
+
+// Finish implements Store
+func (s *PostgrestStore) Finish(ctx context.Context, task Task) error {
+	task.UpdatedAt = time.Now().UTC()
+	_, _, err := s.client(ctx).From("queue_tasks").
+		Update(toRow(task), "minimal", "").
+		Eq("id", strconv.FormatInt(task.ID, 10)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record outcome of unit %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+// Next implements Store
+func (s *PostgrestStore) Next(ctx context.Context, kind, batch string) (time.Time, bool, error) {
+	var next time.Time
+	found := false
+	for _, q := range []struct{ status, column string }{
+		{StatusPending, "run_at"},
+		{StatusRunning, "locked_until"},
+	} {
+		var rows []map[string]*time.Time
+		_, err := s.client(ctx).From("queue_tasks").
+			Select(q.column, "", false).
+			Eq("kind", kind).
+			Eq("batch", batch).
+			Eq("status", q.status).
+			Order(q.column, &postgrest.OrderOpts{Ascending: true}).
+			Limit(1, "").
+			ExecuteTo(&rows)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to check batch %s: %w", batch, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		at := time.Now()
+		if t := rows[0][q.column]; t != nil {
+			at = *t
+		}
+		if !found || at.Before(next) {
+			next, found = at, true
+		}
+	}
+	return next, found, nil
+}
+
+// Unfinished implements Store
+func (s *PostgrestStore) Unfinished(ctx context.Context, kind, scope string) (string, error) {
+	var rows []struct {
+		Batch string `json:"batch"`
+	}
+	_, err := s.client(ctx).From("queue_tasks").
+		Select("batch", "", false).
+		Eq("kind", kind).
+		Like("batch", scope+"*").
+		In("status", []string{StatusPending, StatusRunning}).
+		Order("id", &postgrest.OrderOpts{Ascending: true}).
+		Limit(1, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return "", fmt.Errorf("failed to look for unfinished %s units: %w", kind, err)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	return rows[0].Batch, nil
+}
+
+// Batch implements Store, reading the batch a page at a time
+func (s *PostgrestStore) Batch(ctx context.Context, kind, batch string) ([]Task, error) {
+	var tasks []Task
+	var after int64
+	for {
+		var rows []taskRow
+		_, err := s.client(ctx).From("queue_tasks").
+			Select(taskResultColumns, "", false).
+			Eq("kind", kind).
+			Eq("batch", batch).
+			Gt("id", strconv.FormatInt(after, 10)).
+			Order("id", &postgrest.OrderOpts{Ascending: true}).
+			Limit(pageSize, "").
+			ExecuteTo(&rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load batch %s: %w", batch, err)
+		}
+		for _, row := range rows {
+			tasks = append(tasks, row.toTask())
+		}
+		if len(rows) < pageSize {
+			return tasks, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+// DeleteDone implements Store
+func (s *PostgrestStore) DeleteDone(ctx context.Context, kind, batch string) error {
+	_, _, err := s.client(ctx).From("queue_tasks").
+		Delete("minimal", "").
+		Eq("kind", kind).
+		Eq("batch", batch).
+		Eq("status", StatusDone).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to delete done units of batch %s: %w", batch, err)
+	}
+	return nil
+}
+
+// List implements Store
+func (s *PostgrestStore) List(ctx context.Context, filter Filter) ([]Task, error) {
+	query := s.client(ctx).From("queue_tasks").Select(taskColumns, "", false)
+	if filter.Kind != "" {
+		query = query.Eq("kind", filter.Kind)
+	}
+	if filter.Status != "" {
+		query = query.Eq("status", filter.Status)
+	}
+	query = query.Order("id", &postgrest.OrderOpts{Ascending: false})
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit, "")
+	}
+
+	var rows []taskRow
+	if _, err := query.ExecuteTo(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
+	}
+	tasks := make([]Task, len(rows))
+	for i, row := range rows {
+		tasks[i] = row.toTask()
+	}
+	return tasks, nil
+}
+
+// Count implements Store
+func (s *PostgrestStore) Count(ctx context.Context, kind, status string) (int, error) {
+	_, count, err := s.client(ctx).From("queue_tasks").
+		Select("id", "exact", true).
+		Eq("kind", kind).
+		Eq("status", status).
+		Execute()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s units: %w", kind, err)
+	}
+	return int(count), nil
+}
+
+// Retry implements Store
+func (s *PostgrestStore) Retry(ctx context.Context, id int64) (Task, error) {
+	now := time.Now().UTC()
+	var rows []taskRow
+	_, err := s.client(ctx).From("queue_tasks").
+		Update(map[string]interface{}{
+			"status":     StatusPending,
+			"attempts":   0,
+			"run_at":     now,
+			"updated_at": now,
+		}, "representation", "").
+		Eq("id", strconv.FormatInt(id, 10)).
+		Eq("status", StatusDead).
+		ExecuteTo(&rows)
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to retry unit %d: %w", id, err)
+	}
+	if len(rows) == 0 {
+		return Task{}, ErrNotFound
+	}
+	t := rows[0].toTask()
+	t.Result = nil
+	return t, nil
+}
+
+// Discard implements Store
+func (s *PostgrestStore) Discard(ctx context.Context, id int64) error {
+	var rows []taskRow
+	_, err := s.client(ctx).From("queue_tasks").
+		Delete("representation", "").
+		Eq("id", strconv.FormatInt(id, 10)).
+		Eq("status", StatusDead).
+		ExecuteTo(&rows)
+	if err != nil {
+		return fmt.Errorf("failed to discard unit %d: %w", id, err)
+	}
+	if len(rows) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
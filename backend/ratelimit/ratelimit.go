@@ -0,0 +1,425 @@
+// Package ratelimit applies per-client token-bucket limits to API requests and
+// tracks usage per client.
+//
+// Clients authenticated with an API key or JWT are limited per credential
+// using the tier for their role; anonymous clients are limited per IP address.
+// Failed authentications are also limited per IP address, ahead of
+// authentication, so that keys cannot be guessed at the rate of the
+// anonymous tier.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+	"github.com/gorilla/mux"
+)
+
+// Tier is a token-bucket policy: clients may make Burst requests at once and
+// regain Rate requests per second. A zero Rate means unlimited.
+type Tier struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the tier imposes no limit
+func (t Tier) Unlimited() bool {
+	return t.Rate <= 0
+}
+
+// DefaultTiers are the tiers used when none are configured
+var DefaultTiers = map[auth.Role]Tier{
+	auth.RoleAnonymous: {Name: "anonymous", Rate: 1, Burst: 30},
+	auth.RoleReader:    {Name: "reader", Rate: 10, Burst: 100},
+	auth.RoleEditor:    {Name: "editor", Rate: 20, Burst: 200},
+	auth.RoleAdmin:     {Name: "admin"},
+}
+
+// DefaultFailedAuth allows an IP address 10 failed authentications at once,
+// and another each 6 seconds
+var DefaultFailedAuth = Tier{Name: "failed_auth", Rate: 1.0 / 6, Burst: 10}
+
+// DefaultCosts charges more for routes that fan out to many database calls
+var DefaultCosts = map[string]int{
+	"/api/v1/officials/{id}/voting-allies": 10,
+	"/api/v1/wards/{ward}/statistics":      5,
+	"/api/v1/search":                       2,
+}
+
+// ParseTiers parses tier overrides such as "anonymous=60/m:30,reader=600/m:100",
+// where each entry is role=requests/period:burst and period is s, m or h. A
+// rate of 0 makes the role unlimited. Roles not mentioned keep their defaults.
+func ParseTiers(spec string) (map[auth.Role]Tier, error) {
+	tiers := make(map[auth.Role]Tier, len(DefaultTiers))
+	for role, tier := range DefaultTiers {
+		tiers[role] = tier
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, policy, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: expected role=requests/period:burst", entry)
+		}
+		role := auth.RoleAnonymous
+		if name = strings.TrimSpace(name); name != "anonymous" {
+			r, err := auth.ParseRole(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit %q: %w", entry, err)
+			}
+			role = r
+		}
+		tier, err := parsePolicy(name, policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit %q: %w", entry, err)
+		}
+		tiers[role] = tier
+	}
+	return tiers, nil
+}
+
+func parsePolicy(name, policy string) (Tier, error) {
+	rate, burstStr, hasBurst := strings.Cut(strings.TrimSpace(policy), ":")
+	countStr, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Tier{}, fmt.Errorf("missing period")
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return Tier{}, fmt.Errorf("invalid request count %q", countStr)
+	}
+
+	var seconds float64
+	switch period {
+	case "s":
+		seconds = 1
+	case "m":
+		seconds = 60
+	case "h":
+		seconds = 3600
+	default:
+		return Tier{}, fmt.Errorf("invalid period %q", period)
+	}
+
+	burst := count
+	if hasBurst {
+		if burst, err = strconv.Atoi(burstStr); err != nil || burst < 1 {
+			return Tier{}, fmt.Errorf("invalid burst %q", burstStr)
+		}
+	}
+	return Tier{Name: name, Rate: float64(count) / seconds, Burst: burst}, nil
+}
+
+// Headers are the response headers set by the limiter, for exposing via CORS
+var Headers = []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"}
+
+// Config configures a Limiter
+type Config struct {
+	Tiers map[auth.Role]Tier
+	Costs map[string]int // Tokens charged per route template; default 1
+
+	// FailedAuth limits failed authentications per IP address; default
+	// DefaultFailedAuth
+	FailedAuth Tier
+
+	// TrustProxy uses the last X-Forwarded-For address, the one the proxy
+	// in front of the API appended, as the client IP. Addresses before it
+	// are sent by the client and are not trusted. Only enable it behind a
+	// proxy that appends to the header.
+	TrustProxy bool
+}
+
+// Usage is the request count for one client since the server started
+type Usage struct {
+	Client    string    `json:"client"`
+	Name      string    `json:"name,omitempty"`
+	Tier      string    `json:"tier"`
+	Requests  int64     `json:"requests"`
+	Limited   int64     `json:"limited"`
+	Remaining int       `json:"remaining"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// client is the bucket and usage counters of one client
+type client struct {
+	tier    Tier
+	tokens  float64
+	updated time.Time
+	usage   Usage
+}
+
+// Limiter holds token buckets for all clients
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+// idleTimeout is how long an idle client's state is kept once its bucket has refilled
+const idleTimeout = time.Hour
+
+// NewLimiter creates a limiter from cfg, filling in default tiers and costs
+func NewLimiter(cfg Config) *Limiter {
+	if cfg.Tiers == nil {
+		cfg.Tiers = DefaultTiers
+	}
+	if cfg.Costs == nil {
+		cfg.Costs = DefaultCosts
+	}
+	if cfg.FailedAuth == (Tier{}) {
+		cfg.FailedAuth = DefaultFailedAuth
+	}
+	return &Limiter{cfg: cfg, now: time.Now, clients: make(map[string]*client)}
+}
+
+// Default is the limiter used by the Limit middleware
+var Default = NewLimiter(Config{})
+
+// Init configures the default limiter
+func Init(cfg Config) {
+	Default = NewLimiter(cfg)
+}
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Tier       Tier
+	Remaining  int
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the request would be allowed, if it wasn't
+}
+
+// Allow charges cost tokens to the client identified by key
+func (l *Limiter) Allow(key, name string, tier Tier, cost int) Result {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	c, ok := l.clients[key]
+	if !ok || c.tier != tier {
+		c = &client{tier: tier, tokens: float64(tier.Burst), updated: now, usage: Usage{Client: key, FirstSeen: now}}
+		if ok {
+			c.usage = l.clients[key].usage
+		}
+		l.clients[key] = c
+	}
+	c.usage.Name = name
+	c.usage.Tier = tier.Name
+	c.usage.LastSeen = now
+	c.usage.Requests++
+
+	if tier.Unlimited() {
+		return Result{Allowed: true, Tier: tier}
+	}
+
+	c.tokens = math.Min(float64(tier.Burst), c.tokens+now.Sub(c.updated).Seconds()*tier.Rate)
+	c.updated = now
+
+	// A request costing more than the burst could never succeed, so charge at most the burst
+	need := math.Min(float64(cost), float64(tier.Burst))
+	res := Result{Tier: tier}
+	if c.tokens >= need {
+		c.tokens -= need
+		res.Allowed = true
+	} else {
+		c.usage.Limited++
+		res.RetryAfter = secondsToDuration((need - c.tokens) / tier.Rate)
+	}
+	res.Remaining = int(c.tokens)
+	res.Reset = secondsToDuration((float64(tier.Burst) - c.tokens) / tier.Rate)
+	c.usage.Remaining = res.Remaining
+	return res
+}
+
+// waiting returns how long until the client identified by key has a token,
+// without charging one
+func (l *Limiter) waiting(key string, tier Tier) time.Duration {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.clients[key]
+	if !ok || c.tier != tier || tier.Unlimited() {
+		return 0
+	}
+	tokens := math.Min(float64(tier.Burst), c.tokens+now.Sub(c.updated).Seconds()*tier.Rate)
+	if tokens >= 1 {
+		return 0
+	}
+	return secondsToDuration((1 - tokens) / tier.Rate)
+}
+
+// sweep drops idle clients whose buckets have refilled. Called with l.mu held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, c := range l.clients {
+		if now.Sub(c.usage.LastSeen) > idleTimeout {
+			delete(l.clients, key)
+		}
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Usage returns the usage of every tracked client, busiest first
+func (l *Limiter) Usage() []Usage {
+	l.mu.Lock()
+	usage := make([]Usage, 0, len(l.clients))
+	for _, c := range l.clients {
+		usage = append(usage, c.usage)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Requests != usage[j].Requests {
+			return usage[i].Requests > usage[j].Requests
+		}
+		return usage[i].Client < usage[j].Client
+	})
+	return usage
+}
+
+// clientKey identifies the client making r and returns a display name
+func (l *Limiter) clientKey(r *http.Request) (key, name string, role auth.Role) {
+	if p := auth.PrincipalFromContext(r.Context()); p != nil {
+		if p.KeyID != "" {
+			return "key:" + p.KeyID, p.Subject, p.Role
+		}
+		return "user:" + p.Subject, p.Subject, p.Role
+	}
+	return "ip:" + l.clientIP(r), "", auth.RoleAnonymous
+}
+
+// clientIP returns the address of the client making r
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.cfg.TrustProxy {
+		// The client can send its own X-Forwarded-For, so only the
+		// rightmost address, from the proxy, identifies it
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			last := fwd[len(fwd)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := net.ParseIP(strings.TrimSpace(last)); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// cost returns the tokens charged for r
+func (l *Limiter) cost(r *http.Request) int {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			if cost, ok := l.cfg.Costs[tpl]; ok {
+				return cost
+			}
+		}
+	}
+	return 1
+}
+
+// Limit is middleware that enforces the default limiter. It must run after
+// auth.Authenticate so that authenticated clients get their role's tier.
+func Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default.ServeHTTP(w, r, next)
+	})
+}
+
+// ServeHTTP checks r against the limiter, setting RateLimit-* headers, and
+// calls next if the request is allowed
+func (l *Limiter) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	key, name, role := l.clientKey(r)
+	tier, ok := l.cfg.Tiers[role]
+	if !ok {
+		tier = l.cfg.Tiers[auth.RoleAnonymous]
+	}
+
+	res := l.Allow(key, name, tier, l.cost(r))
+	if !tier.Unlimited() {
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(tier.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", tier.Burst, ceilSeconds(secondsToDuration(float64(tier.Burst)/tier.Rate))))
+	}
+
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "Rate limit exceeded").
+			WithDetails(map[string]interface{}{"tier": tier.Name, "retry_after": ceilSeconds(res.RetryAfter)}))
+		return
+	}
+	next.ServeHTTP(w, r)
+}
+
+// LimitFailedAuth is middleware that counts requests whose credentials are
+// rejected with 401 against the client's IP address, and rejects requests from an address
+// that has run out before their credentials are checked. It must run before
+// auth.Authenticate.
+func LimitFailedAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default.ServeFailedAuth(w, r, next)
+	})
+}
+
+// ServeFailedAuth calls next unless the client's IP address has run out of
+// failed authentications, and charges it one if next responds 401 to
+// credentials. Anonymous requests refused with 401 are not failures.
+func (l *Limiter) ServeFailedAuth(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	tier := l.cfg.FailedAuth
+	if tier.Unlimited() {
+		next.ServeHTTP(w, r)
+		return
+	}
+	key := "auth:" + l.clientIP(r)
+	if wait := l.waiting(key, tier); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(wait)))
+		apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many failed authentications").
+			WithDetails(map[string]interface{}{"tier": tier.Name, "retry_after": ceilSeconds(wait)}))
+		return
+	}
+
+	rec := middleware.NewStatusRecorder(w)
+	next.ServeHTTP(rec, r)
+	if rec.Status == http.StatusUnauthorized && auth.HasCredentials(r) {
+		l.Allow(key, "", tier, 1)
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
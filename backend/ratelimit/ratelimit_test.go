@@ -0,0 +1,251 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/auth"
+	"github.com/gorilla/mux"
+)
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := ParseTiers("anonymous=60/m:10, admin=0/s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anon := tiers[auth.RoleAnonymous]
+	if anon.Rate != 1 || anon.Burst != 10 {
+		t.Errorf("unexpected anonymous tier %+v", anon)
+	}
+	if !tiers[auth.RoleAdmin].Unlimited() {
+		t.Errorf("expected admin tier to be unlimited")
+	}
+	if tiers[auth.RoleReader] != DefaultTiers[auth.RoleReader] {
+		t.Errorf("expected reader tier to keep its default")
+	}
+
+	for _, spec := range []string{"reader", "root=1/s", "reader=1/d", "reader=x/s", "reader=1/s:0"} {
+		if _, err := ParseTiers(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestAllowRefillsOverTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(Config{})
+	l.now = func() time.Time { return now }
+	tier := Tier{Name: "test", Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if res := l.Allow("ip:1.2.3.4", "", tier, 1); !res.Allowed {
+			t.Fatalf("request %d: expected allowed", i)
+		}
+	}
+	res := l.Allow("ip:1.2.3.4", "", tier, 1)
+	if res.Allowed {
+		t.Fatal("expected fourth request to be limited")
+	}
+	if res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("expected retry after 500ms, got %s", res.RetryAfter)
+	}
+
+	// Other clients have their own bucket
+	if res := l.Allow("ip:5.6.7.8", "", tier, 1); !res.Allowed {
+		t.Error("expected other client to be allowed")
+	}
+
+	now = now.Add(time.Second)
+	res = l.Allow("ip:1.2.3.4", "", tier, 1)
+	if !res.Allowed || res.Remaining != 1 {
+		t.Errorf("expected allowed with 1 remaining after refill, got %+v", res)
+	}
+
+	usage := l.Usage()
+	if len(usage) != 2 || usage[0].Client != "ip:1.2.3.4" || usage[0].Requests != 5 || usage[0].Limited != 1 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	keys, _ := auth.ParseStaticKeys("ops:admin:" + auth.HashAPIKey("ipk_admin"))
+	auth.Init(auth.Config{KeyStores: []auth.KeyStore{keys}})
+	defer auth.Init(auth.Config{})
+
+	l := NewLimiter(Config{
+		Tiers: map[auth.Role]Tier{
+			auth.RoleAnonymous: {Name: "anonymous", Rate: 1, Burst: 10},
+			auth.RoleAdmin:     {Name: "admin"},
+		},
+		Costs: map[string]int{"/officials/{id}/voting-allies": 6},
+	})
+
+	router := mux.NewRouter()
+	router.Use(auth.Authenticate, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l.ServeHTTP(w, r, next)
+		})
+	})
+	router.HandleFunc("/officials/{id}/voting-allies", func(w http.ResponseWriter, r *http.Request) {})
+
+	do := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/officials/1/voting-allies", nil)
+		r.RemoteAddr = "203.0.113.7:5000"
+		if key != "" {
+			r.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := do("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "10" || rec.Header().Get("RateLimit-Remaining") != "4" {
+		t.Errorf("unexpected headers %v", rec.Header())
+	}
+
+	rec = do("")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("expected Retry-After 2, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Admins are unlimited and don't get rate limit headers
+	for i := 0; i < 5; i++ {
+		if rec := do("ipk_admin"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("admin request %d: got %d %v", i, rec.Code, rec.Header())
+		}
+	}
+}
+
+func TestClientIPTrustProxy(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.4")
+
+	if ip := NewLimiter(Config{}).clientIP(r); ip != "10.0.0.1" {
+		t.Errorf("expected remote address without TrustProxy, got %s", ip)
+	}
+	if ip := NewLimiter(Config{TrustProxy: true}).clientIP(r); ip != "198.51.100.4" {
+		t.Errorf("expected forwarded address with TrustProxy, got %s", ip)
+	}
+}
+
+func TestClientIPSpoofedForwardedFor(t *testing.T) {
+	keys, _ := auth.ParseStaticKeys("ops:admin:" + auth.HashAPIKey("ipk_admin"))
+	auth.Init(auth.Config{KeyStores: []auth.KeyStore{keys}})
+	defer auth.Init(auth.Config{})
+
+	l := NewLimiter(Config{TrustProxy: true, FailedAuth: Tier{Name: "failed_auth", Rate: 1, Burst: 2}})
+	l.now = func() time.Time { return time.Unix(1700000000, 0) }
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.ServeFailedAuth(w, r, auth.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	})
+
+	// Each guess claims a new address, ahead of the one the proxy appended
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/officials", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("192.0.2.%d, 203.0.113.7", i))
+		r.Header.Set(auth.APIKeyHeader, "ipk_guess")
+		if ip := l.clientIP(r); ip != "203.0.113.7" {
+			t.Fatalf("expected the address the proxy appended, got %s", ip)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}[i]; rec.Code != want {
+			t.Errorf("guess %d: expected %d, got %d", i, want, rec.Code)
+		}
+	}
+}
+
+func TestFailedAuth(t *testing.T) {
+	keys, _ := auth.ParseStaticKeys("ops:admin:" + auth.HashAPIKey("ipk_admin"))
+	auth.Init(auth.Config{KeyStores: []auth.KeyStore{keys}})
+	defer auth.Init(auth.Config{})
+
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(Config{FailedAuth: Tier{Name: "failed_auth", Rate: 1, Burst: 2}})
+	l.now = func() time.Time { return now }
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.ServeFailedAuth(w, r, auth.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	})
+	do := func(ip, key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/officials", nil)
+		r.RemoteAddr = ip + ":5000"
+		if key != "" {
+			r.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	// Successful requests are not counted
+	for i := 0; i < 5; i++ {
+		if code := do("203.0.113.7", "ipk_admin"); code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if code := do("203.0.113.7", "ipk_guess"); code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: expected 401, got %d", i, code)
+		}
+	}
+
+	// The address is refused before its credentials are checked, even good ones
+	if code := do("203.0.113.7", "ipk_guess"); code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 after the burst of failures, got %d", code)
+	}
+	if code := do("203.0.113.7", "ipk_admin"); code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for a valid key from the same address, got %d", code)
+	}
+	if code := do("198.51.100.4", "ipk_guess"); code != http.StatusUnauthorized {
+		t.Errorf("expected other addresses to be unaffected, got %d", code)
+	}
+
+	now = now.Add(time.Second)
+	if code := do("203.0.113.7", "ipk_admin"); code != http.StatusOK {
+		t.Errorf("expected 200 once a failure is refilled, got %d", code)
+	}
+}
+
+func TestFailedAuthSkipsAnonymous(t *testing.T) {
+	keys, _ := auth.ParseStaticKeys("ops:admin:" + auth.HashAPIKey("ipk_admin"))
+	auth.Init(auth.Config{KeyStores: []auth.KeyStore{keys}})
+	defer auth.Init(auth.Config{})
+
+	l := NewLimiter(Config{FailedAuth: Tier{Name: "failed_auth", Rate: 1, Burst: 2}})
+	l.now = func() time.Time { return time.Unix(1700000000, 0) }
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.ServeFailedAuth(w, r, auth.Authenticate(auth.Require(auth.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))))
+	})
+
+	// Anonymous requests to an admin endpoint are refused, but guess no key
+	for i := 0; i < 5; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/admin/keys", nil)
+		r.RemoteAddr = "203.0.113.7:5000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("request %d: expected 401, got %d", i, rec.Code)
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "/admin/keys", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set(auth.APIKeyHeader, "ipk_admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a valid key after anonymous 401s, got %d", rec.Code)
+	}
+}
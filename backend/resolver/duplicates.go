@@ -0,0 +1,122 @@
+package resolver
+
+import "sort"
+
+// Pair is two stored people who may be the same person
+type Pair struct {
+	A       int      `json:"a"` // person IDs, A < B
+	B       int      `json:"b"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Cluster is a group of stored people who are likely one person, linked by
+// their pairs
+type Cluster struct {
+	People   []Candidate `json:"people"`   // by person ID
+	Pairs    []Pair      `json:"pairs"`    // best first
+	Survivor int         `json:"survivor"` // the person to merge the others into
+}
+
+// Similar scores how likely stored people a and b are the same person
+func Similar(a, b Candidate) Pair {
+	if a.PersonID > b.PersonID {
+		a, b = b, a
+	}
+	score, reasons := compare(candidateProfile(a), candidateProfile(b))
+	return Pair{A: a.PersonID, B: b.PersonID, Score: score, Reasons: reasons}
+}
+
+// Duplicates groups people into clusters of likely duplicates: people are
+// in one cluster when a chain of pairs scoring at least PlausibleScore links
+// them. Clusters are ordered by their best pair.
+func Duplicates(people []Candidate) []Cluster {
+	profiles := make([]profile, len(people))
+	for i, c := range people {
+		profiles[i] = candidateProfile(c)
+	}
+
+	// Union-find over people linked by a plausible pair
+	parent := make([]int, len(people))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	var pairs []Pair
+	var first []int // index of each pair's first person
+	paired := make([]bool, len(people))
+	for i := range people {
+		for j := i + 1; j < len(people); j++ {
+			if !sameLast(profiles[i].name.Last, profiles[j].name.Last) {
+				continue
+			}
+			score, reasons := compare(profiles[i], profiles[j])
+			if score < PlausibleScore {
+				continue
+			}
+			a, b := people[i].PersonID, people[j].PersonID
+			if a > b {
+				a, b = b, a
+			}
+			pairs = append(pairs, Pair{A: a, B: b, Score: score, Reasons: reasons})
+			first = append(first, i)
+			paired[i], paired[j] = true, true
+			parent[find(i)] = find(j)
+		}
+	}
+
+	byRoot := map[int]*Cluster{}
+	var roots []int
+	for i, c := range people {
+		if !paired[i] {
+			continue
+		}
+		root := find(i)
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &Cluster{}
+			byRoot[root] = cluster
+			roots = append(roots, root)
+		}
+		cluster.People = append(cluster.People, c)
+	}
+	for p, i := range first {
+		cluster := byRoot[find(i)]
+		cluster.Pairs = append(cluster.Pairs, pairs[p])
+	}
+
+	clusters := make([]Cluster, 0, len(roots))
+	for _, root := range roots {
+		cluster := byRoot[root]
+		sort.Slice(cluster.People, func(i, j int) bool { return cluster.People[i].PersonID < cluster.People[j].PersonID })
+		sort.SliceStable(cluster.Pairs, func(i, j int) bool { return cluster.Pairs[i].Score > cluster.Pairs[j].Score })
+		cluster.Survivor = survivor(cluster.People)
+		clusters = append(clusters, *cluster)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].Pairs[0].Score > clusters[j].Pairs[0].Score })
+	return clusters
+}
+
+// survivor picks the person to keep when merging people: the one with the
+// most external IDs, then the most terms, then the oldest
+func survivor(people []Candidate) int {
+	best := people[0]
+	for _, c := range people[1:] {
+		switch {
+		case len(c.ExternalIDs) != len(best.ExternalIDs):
+			if len(c.ExternalIDs) > len(best.ExternalIDs) {
+				best = c
+			}
+		case len(c.Terms) > len(best.Terms):
+			best = c
+		}
+	}
+	return best.PersonID
+}
@@ -0,0 +1,220 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/Jsanchez767/InfluencePower/backend/search"
+)
+
+// Name is a person's name split into normalized parts: lowercase, without
+// accents or punctuation, with hyphenated parts joined by "-"
+type Name struct {
+	First  string
+	Middle string // middle names and initials, space separated
+	Last   string // may have several words, e.g. "la spata"
+	Suffix string // e.g. "jr" or "iii"
+}
+
+// titles are dropped from the start of a name
+var titles = map[string]bool{
+	"ald": true, "alderman": true, "alderwoman": true, "alderperson": true,
+	"mayor": true, "clerk": true, "treasurer": true, "hon": true, "honorable": true,
+	"dr": true, "mr": true, "mrs": true, "ms": true,
+}
+
+// suffixes are generational suffixes, kept apart from the last name
+var suffixes = map[string]bool{"jr": true, "sr": true, "ii": true, "iii": true, "iv": true}
+
+// particles start multi-word last names, e.g. "De La Cruz"
+var particles = map[string]bool{
+	"de": true, "del": true, "della": true, "la": true, "le": true, "da": true,
+	"di": true, "van": true, "von": true, "st": true, "mc": true, "mac": true,
+}
+
+// nicknames maps common short forms to the first name they stand for
+var nicknames = map[string]string{
+	"al": "albert", "alex": "alexander", "andy": "andrew", "tony": "anthony",
+	"ben": "benjamin", "bill": "william", "billy": "william", "will": "william",
+	"bob": "robert", "bobby": "robert", "rob": "robert", "robbie": "robert",
+	"chris": "christopher", "dan": "daniel", "danny": "daniel", "dave": "david",
+	"dick": "richard", "rick": "richard", "ricky": "richard", "rich": "richard",
+	"ed": "edward", "eddie": "edward", "ted": "edward", "greg": "gregory",
+	"jim": "james", "jimmy": "james", "jamie": "james", "joe": "joseph", "joey": "joseph",
+	"jon": "jonathan", "ken": "kenneth", "larry": "lawrence", "matt": "matthew",
+	"mike": "michael", "mick": "michael", "nick": "nicholas", "pat": "patrick",
+	"pete": "peter", "ray": "raymond", "ron": "ronald", "sam": "samuel",
+	"steve": "stephen", "steven": "stephen", "tom": "thomas", "tommy": "thomas",
+	"walt": "walter", "gil": "gilbert", "fred": "frederick", "frank": "francis",
+	"abby": "abigail", "beth": "elizabeth", "liz": "elizabeth", "betty": "elizabeth",
+	"cathy": "catherine", "kathy": "katherine", "kate": "katherine", "katie": "katherine",
+	"jen": "jennifer", "jenny": "jennifer", "jess": "jessica", "meg": "margaret",
+	"maggie": "margaret", "peggy": "margaret", "pam": "pamela", "sue": "susan",
+	"susie": "susan", "vicky": "victoria", "deb": "deborah", "debbie": "deborah",
+	"sandy": "sandra", "tina": "christina", "lupe": "guadalupe", "chuy": "jesus",
+	"pepe": "jose", "paco": "francisco", "nacho": "ignacio",
+}
+
+// ParseName splits a name written "First Middle Last Suffix" or
+// "Last, First Middle", dropping titles such as "Ald." and "Mayor"
+func ParseName(s string) Name {
+	var n Name
+	if before, after, ok := strings.Cut(s, ","); ok {
+		last, rest := words(before), words(after)
+		// "Smith, Jr." is a suffix, not "Last, First"
+		if len(rest) > 0 && !(len(rest) == 1 && suffixes[rest[0]]) {
+			last, n.Suffix = trimSuffix(last)
+			rest, suffix := trimSuffix(dropTitles(rest))
+			if n.Suffix == "" {
+				n.Suffix = suffix
+			}
+			n.Last = strings.Join(dropTitles(last), " ")
+			if len(rest) > 0 {
+				n.First = rest[0]
+				n.Middle = strings.Join(rest[1:], " ")
+			}
+			return n
+		}
+	}
+
+	parts, suffix := trimSuffix(dropTitles(words(s)))
+	n.Suffix = suffix
+	switch len(parts) {
+	case 0:
+		return n
+	case 1:
+		n.Last = parts[0]
+		return n
+	}
+	n.First = parts[0]
+	rest := parts[1:]
+	lastStart := len(rest) - 1
+	for lastStart > 0 && particles[rest[lastStart-1]] {
+		lastStart--
+	}
+	n.Middle = strings.Join(rest[:lastStart], " ")
+	n.Last = strings.Join(rest[lastStart:], " ")
+	return n
+}
+
+// words splits s on spaces and normalizes each word, joining the parts of
+// hyphenated words with "-"
+func words(s string) []string {
+	var out []string
+	for _, word := range strings.Fields(s) {
+		if w := strings.ReplaceAll(search.Normalize(word), " ", "-"); w != "" {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// dropTitles removes titles from the start of parts
+func dropTitles(parts []string) []string {
+	for len(parts) > 1 && titles[parts[0]] {
+		parts = parts[1:]
+	}
+	return parts
+}
+
+// trimSuffix removes a generational suffix from the end of parts
+func trimSuffix(parts []string) ([]string, string) {
+	if len(parts) > 1 && suffixes[parts[len(parts)-1]] {
+		return parts[:len(parts)-1], parts[len(parts)-1]
+	}
+	return parts, ""
+}
+
+// String returns the name as "first middle last suffix"
+func (n Name) String() string {
+	var parts []string
+	for _, p := range []string{n.First, n.Middle, n.Last, n.Suffix} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Canonical returns the full first name a nickname stands for, or first
+func Canonical(first string) string {
+	if full, ok := nicknames[first]; ok {
+		return full
+	}
+	return first
+}
+
+// Similarity of two names, from strongest to weakest
+const (
+	NameDifferent = iota // last names differ
+	NameLastOnly         // same last name, first names differ
+	NameInitial          // same last name, one first name is the other's initial or middle name
+	NameSame             // same last name and first name, counting nicknames
+)
+
+// Compare returns how similar names a and b are
+func Compare(a, b Name) int {
+	if !sameLast(a.Last, b.Last) {
+		return NameDifferent
+	}
+	switch {
+	case a.First == "" || b.First == "":
+		return NameInitial
+	case Canonical(a.First) == Canonical(b.First):
+		return NameSame
+	case initialOf(a.First, b.First) || initialOf(b.First, a.First):
+		return NameInitial
+	case containsWord(a.Middle, b.First) || containsWord(b.Middle, a.First):
+		return NameInitial
+	}
+	return NameLastOnly
+}
+
+// SameName reports whether a and b, as written, name the same person:
+// the same last name and the same first name, nickname or initial
+func SameName(a, b string) bool {
+	return Compare(ParseName(a), ParseName(b)) >= NameInitial
+}
+
+// sameLast reports whether two last names match, ignoring spaces and
+// hyphens, or one is a part of the other, e.g. "ramirez-rosa" and "rosa"
+func sameLast(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	squash := strings.NewReplacer(" ", "", "-", "")
+	if squash.Replace(a) == squash.Replace(b) {
+		return true
+	}
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '-' })
+	}
+	aParts, bParts := split(a), split(b)
+	if len(aParts) == len(bParts) {
+		return false
+	}
+	short, long := aParts, bParts
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	for _, p := range short {
+		if particles[p] || !containsWord(strings.Join(long, " "), p) {
+			return false
+		}
+	}
+	return true
+}
+
+// initialOf reports whether initial is the first letter of name
+func initialOf(initial, name string) bool {
+	return len(initial) == 1 && strings.HasPrefix(name, initial)
+}
+
+// containsWord reports whether space-separated words include w
+func containsWord(words, w string) bool {
+	for _, word := range strings.Fields(words) {
+		if word == w || initialOf(word, w) {
+			return true
+		}
+	}
+	return false
+}
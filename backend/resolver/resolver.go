@@ -0,0 +1,236 @@
+// Package resolver matches people from upstream sources, such as Legistar
+// office records, the ELMS export and manual entries, to stored people.
+// Candidates are scored on name, external IDs, ward, email and term dates;
+// a clear best match is linked, no plausible match means a new person and
+// anything in between is left for human review.
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record is a person as an upstream source describes them
+type Record struct {
+	Source      string            // e.g. "legistar" or "elms"
+	ExternalIDs map[string]string // e.g. legistar_id, keyed as in people.external_ids
+	Name        string
+	Email       string
+	Ward        int       // 0 when unknown or citywide
+	Start, End  time.Time // the record's term, zero when unknown or open
+}
+
+// Term is a stored term of a candidate
+type Term struct {
+	Ward  int       `json:"ward,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Candidate is a stored person a record may match
+type Candidate struct {
+	PersonID    int               `json:"person_id"`
+	Name        string            `json:"name"`
+	Email       string            `json:"email,omitempty"`
+	ExternalIDs map[string]string `json:"external_ids,omitempty"`
+	Terms       []Term            `json:"terms,omitempty"`
+}
+
+// Match is a candidate with its score, between 0 and 1, and the reasons for it
+type Match struct {
+	Candidate Candidate
+	Score     float64
+	Reasons   []string
+}
+
+// Decision is the outcome of resolving a record
+type Decision string
+
+const (
+	Matched   Decision = "matched"   // the record is Result.Match
+	New       Decision = "new"       // no stored person is the record
+	Ambiguous Decision = "ambiguous" // a person should decide between Result.Candidates
+)
+
+// Result is the outcome of resolving a record
+type Result struct {
+	Decision   Decision
+	Match      Match   // the matched candidate when Decision is Matched
+	Candidates []Match // plausible candidates, best first
+}
+
+// Thresholds a best match must reach to be linked, and below which a
+// candidate is not plausible
+const (
+	MatchScore     = 0.75
+	MatchMargin    = 0.15 // over the second best candidate
+	PlausibleScore = 0.45
+)
+
+// Resolve scores every candidate against r and decides whether r is one of
+// them
+func Resolve(r Record, candidates []Candidate) Result {
+	var plausible []Match
+	for _, c := range candidates {
+		if m := Score(r, c); m.Score >= PlausibleScore {
+			plausible = append(plausible, m)
+		}
+	}
+	sort.SliceStable(plausible, func(i, j int) bool { return plausible[i].Score > plausible[j].Score })
+
+	if len(plausible) == 0 {
+		return Result{Decision: New}
+	}
+	best := plausible[0]
+	if best.Score >= MatchScore && (len(plausible) == 1 || best.Score-plausible[1].Score >= MatchMargin) {
+		return Result{Decision: Matched, Match: best, Candidates: plausible}
+	}
+	return Result{Decision: Ambiguous, Candidates: plausible}
+}
+
+// Score scores how likely c is the person r describes. A shared external ID
+// is certain and a conflicting one rules c out; otherwise the score adds up
+// name, email, ward and date evidence.
+func Score(r Record, c Candidate) Match {
+	var terms []Term
+	if r.Ward > 0 || !r.Start.IsZero() {
+		terms = []Term{{Ward: r.Ward, Start: r.Start, End: r.End}}
+	}
+	a := profile{name: ParseName(r.Name), email: r.Email, ids: r.ExternalIDs, terms: terms}
+	score, reasons := compare(a, candidateProfile(c))
+	return Match{Candidate: c, Score: score, Reasons: reasons}
+}
+
+// profile is what people are compared on
+type profile struct {
+	name  Name
+	email string
+	ids   map[string]string
+	terms []Term
+}
+
+// candidateProfile returns the profile of a stored person
+func candidateProfile(c Candidate) profile {
+	return profile{name: ParseName(c.Name), email: c.Email, ids: c.ExternalIDs, terms: c.Terms}
+}
+
+// compare scores how likely a and b are the same person, with the reasons
+func compare(a, b profile) (float64, []string) {
+	keys := make([]string, 0, len(a.ids))
+	for key := range a.ids {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	conflict := ""
+	for _, key := range keys {
+		id, stored := a.ids[key], b.ids[key]
+		switch {
+		case id == "" || stored == "":
+		case stored == id:
+			return 1, []string{fmt.Sprintf("same %s", key)}
+		case conflict == "":
+			conflict = key
+		}
+	}
+	if conflict != "" {
+		return 0, []string{fmt.Sprintf("different %s", conflict)}
+	}
+
+	score := 0.0
+	var reasons []string
+	add := func(points float64, reason string) {
+		score += points
+		reasons = append(reasons, reason)
+	}
+
+	switch Compare(a.name, b.name) {
+	case NameSame:
+		add(0.6, "same name")
+		if a.name.Middle != "" && b.name.Middle != "" && a.name.Middle[0] != b.name.Middle[0] {
+			add(-0.1, "different middle name")
+		}
+		if a.name.Suffix != "" && b.name.Suffix != "" && a.name.Suffix != b.name.Suffix {
+			add(-0.3, "different suffix")
+		}
+	case NameInitial:
+		add(0.45, "same last name, first name matches an initial or middle name")
+	case NameLastOnly:
+		add(0.15, "same last name only")
+	}
+
+	if a.email != "" && strings.EqualFold(strings.TrimSpace(a.email), strings.TrimSpace(b.email)) {
+		add(0.3, "same email")
+	}
+
+	aWards, bWards := wards(a.terms), wards(b.terms)
+	switch {
+	case len(aWards) == 0 || len(bWards) == 0:
+	case shareWard(aWards, bWards):
+		add(0.2, "same ward")
+	default:
+		add(-0.15, "different ward")
+	}
+
+	if overlapping(a.terms, b.terms) {
+		add(0.1, "overlapping term")
+	}
+
+	return clamp(score), reasons
+}
+
+// wards returns the wards of terms, leaving out citywide ones
+func wards(terms []Term) map[int]bool {
+	ws := map[int]bool{}
+	for _, t := range terms {
+		if t.Ward > 0 {
+			ws[t.Ward] = true
+		}
+	}
+	return ws
+}
+
+// shareWard reports whether two sets of wards have one in common
+func shareWard(a, b map[int]bool) bool {
+	for w := range a {
+		if b[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapping reports whether a term of a with known dates overlaps one of b
+func overlapping(a, b []Term) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if !x.Start.IsZero() && !y.Start.IsZero() && overlaps(x.Start, x.End, y.Start, y.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlaps reports whether two date ranges overlap; a zero end is open
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	if !aEnd.IsZero() && !bStart.IsZero() && aEnd.Before(bStart) {
+		return false
+	}
+	if !bEnd.IsZero() && bEnd.Before(aStart) {
+		return false
+	}
+	return true
+}
+
+// clamp limits a score to between 0 and 1
+func clamp(score float64) float64 {
+	switch {
+	case score < 0:
+		return 0
+	case score > 1:
+		return 1
+	}
+	return float64(int(score*100+0.5)) / 100
+}
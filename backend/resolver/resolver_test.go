@@ -0,0 +1,123 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Name
+	}{
+		{"Brian Hopkins", Name{First: "brian", Last: "hopkins"}},
+		{"Hopkins, Brian K.", Name{First: "brian", Middle: "k", Last: "hopkins"}},
+		{"Ald. Jeylú Gutiérrez", Name{First: "jeylu", Last: "gutierrez"}},
+		{"Carlos Ramirez-Rosa", Name{First: "carlos", Last: "ramirez-rosa"}},
+		{"Daniel La Spata", Name{First: "daniel", Last: "la spata"}},
+		{"Walter Burnett, Jr.", Name{First: "walter", Last: "burnett", Suffix: "jr"}},
+		{"Burnett Jr., Walter", Name{First: "walter", Last: "burnett", Suffix: "jr"}},
+		{"Matthew J. O'Shea", Name{First: "matthew", Middle: "j", Last: "oshea"}},
+	}
+	for _, tt := range tests {
+		if got := ParseName(tt.in); got != tt.want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSameName(t *testing.T) {
+	same := [][2]string{
+		{"Hopkins, Brian", "Brian Hopkins"},
+		{"Bill Conway", "William Conway"},
+		{"Jeylu Gutierrez", "Jeylú Gutiérrez"},
+		{"Ramirez-Rosa, Carlos", "Carlos Ramirez Rosa"},
+		{"B. Hopkins", "Brian Hopkins"},
+		{"Nicole Lee", "Nicole T. Lee"},
+		{"Walter Burnett Jr.", "Walter Burnett"},
+	}
+	for _, pair := range same {
+		if !SameName(pair[0], pair[1]) {
+			t.Errorf("SameName(%q, %q) = false, want true", pair[0], pair[1])
+		}
+	}
+
+	different := [][2]string{
+		{"Brian Hopkins", "Brian Hopkinson"},
+		{"Brian Hopkins", "Bruce Hopkins"},
+		{"Carlos Rosa", "Carlos Ramirez"},
+	}
+	for _, pair := range different {
+		if SameName(pair[0], pair[1]) {
+			t.Errorf("SameName(%q, %q) = true, want false", pair[0], pair[1])
+		}
+	}
+}
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestResolve(t *testing.T) {
+	hopkins := Candidate{PersonID: 1, Name: "Brian Hopkins", Email: "ward02@cityofchicago.org", ExternalIDs: map[string]string{"legistar_id": "7"},
+		Terms: []Term{{Ward: 2, Start: date("2023-05-15")}}}
+	lee := Candidate{PersonID: 2, Name: "Nicole Lee", Terms: []Term{{Ward: 11, Start: date("2023-05-15")}}}
+	leeToo := Candidate{PersonID: 3, Name: "Nicole T. Lee"}
+	candidates := []Candidate{hopkins, lee, leeToo}
+
+	tests := []struct {
+		name   string
+		record Record
+		want   Decision
+		person int
+	}{
+		{"external id", Record{Name: "B. Hopkins", ExternalIDs: map[string]string{"legistar_id": "7"}}, Matched, 1},
+		{"conflicting external id", Record{Name: "Brian Hopkins", ExternalIDs: map[string]string{"legistar_id": "8"}}, New, 0},
+		{"name, ward and email", Record{Name: "Hopkins, Brian", Ward: 2, Email: "Ward02@cityofchicago.org"}, Matched, 1},
+		{"name and ward against a duplicate", Record{Name: "Nicole Lee", Ward: 11, Start: date("2023-05-15")}, Matched, 2},
+		{"name alone against a duplicate", Record{Name: "Nicole Lee"}, Ambiguous, 0},
+		{"unknown", Record{Name: "Anthony Quezada", Ward: 35}, New, 0},
+		{"other ward", Record{Name: "Brian Hopkins", Ward: 3}, Ambiguous, 0},
+	}
+	for _, tt := range tests {
+		got := Resolve(tt.record, candidates)
+		if got.Decision != tt.want {
+			t.Errorf("%s: decision %s, want %s (candidates %+v)", tt.name, got.Decision, tt.want, got.Candidates)
+			continue
+		}
+		if tt.want == Matched && got.Match.Candidate.PersonID != tt.person {
+			t.Errorf("%s: matched person %d, want %d", tt.name, got.Match.Candidate.PersonID, tt.person)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	people := []Candidate{
+		{PersonID: 1, Name: "Brian Hopkins", ExternalIDs: map[string]string{"legistar_id": "7"}, Terms: []Term{{Ward: 2, Start: date("2023-05-15")}}},
+		{PersonID: 2, Name: "Hopkins, Brian", Terms: []Term{{Ward: 2, Start: date("2023-05-15")}}},
+		{PersonID: 3, Name: "B. Hopkins"},
+		{PersonID: 4, Name: "Nicole Lee", Terms: []Term{{Ward: 11}}},
+		{PersonID: 5, Name: "Brian Hopkins", ExternalIDs: map[string]string{"legistar_id": "8"}},
+	}
+	clusters := Duplicates(people)
+	if len(clusters) != 1 {
+		t.Fatalf("expected one cluster, got %+v", clusters)
+	}
+	c := clusters[0]
+	var ids []int
+	for _, p := range c.People {
+		ids = append(ids, p.PersonID)
+	}
+	if len(ids) != 4 || ids[0] != 1 || ids[3] != 5 || c.Survivor != 1 {
+		t.Errorf("expected people 1, 2, 3 and 5 kept as 1, got %v kept as %d", ids, c.Survivor)
+	}
+	if p := c.Pairs[0]; p.A != 1 || p.B != 2 || p.Score < MatchScore {
+		t.Errorf("expected 1 and 2 as the best pair, got %+v", p)
+	}
+	for _, p := range c.Pairs {
+		if p.A == 1 && p.B == 5 {
+			t.Errorf("people with different Legistar IDs paired: %+v", p)
+		}
+	}
+}
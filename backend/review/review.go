@@ -0,0 +1,144 @@
+// Package review is the queue of sync decisions left for a person: an
+// upstream person who may be one of several stored people, an office record
+// whose ward or position could not be worked out, or a term from a source
+// without term dates. Sync jobs queue
+// items with the raw upstream record and the candidate resolutions, admins
+// resolve or dismiss them, and the next sync applies the resolution to the
+// record instead of guessing again.
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/validate"
+)
+
+// Kinds of item. review_items.kind is not checked by the database, and the
+// schema comment names only the first kind; this is the full list.
+const (
+	KindPersonMatch = "person_match" // which stored person, if any, an upstream person is
+	KindPosition    = "position"     // which position an office record is for
+	KindTermStart   = "term_start"   // when a term from a source without term dates started
+)
+
+// Kinds lists every kind
+var Kinds = []string{KindPersonMatch, KindPosition, KindTermStart}
+
+// Statuses of an item
+const (
+	StatusOpen      = "open"
+	StatusResolved  = "resolved"  // the sync applies the resolution
+	StatusDismissed = "dismissed" // the sync skips the record
+)
+
+// Statuses lists every status
+var Statuses = []string{StatusOpen, StatusResolved, StatusDismissed}
+
+// Item is a queued decision, as stored in review_items
+type Item struct {
+	ID         int64           `json:"id"`
+	Kind       string          `json:"kind"`
+	Key        string          `json:"key"`    // kind, source and upstream record, e.g. "person_match/legistar/legistar_id=162"
+	Source     string          `json:"source"` // e.g. "legistar" or "elms"
+	Record     json.RawMessage `json:"record"` // the upstream record as fetched
+	Reason     string          `json:"reason"`
+	Candidates json.RawMessage `json:"candidates"` // the resolutions the sync considered, best first
+	Status     string          `json:"status"`
+	Resolution *Resolution     `json:"resolution,omitempty"`
+	ResolvedBy string          `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time      `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// Resolution is the decision on an item. A person_match item is resolved
+// with PersonID or NewPerson, a position item with PositionID or Ward, and a
+// term_start item with StartDate.
+type Resolution struct {
+	PersonID   int    `json:"person_id,omitempty" validate:"omitempty,min=1"`   // the stored person the record is
+	NewPerson  bool   `json:"new_person,omitempty"`                             // the record is a person not stored yet
+	PositionID int    `json:"position_id,omitempty" validate:"omitempty,min=1"` // the position the record is for
+	Ward       int    `json:"ward,omitempty" validate:"omitempty,ward"`         // the ward whose alderperson position the record is for
+	StartDate  string `json:"start_date,omitempty" validate:"omitempty,date"`   // the day the record's term started
+	Note       string `json:"note,omitempty" validate:"max=1000"`
+}
+
+// Validate checks that r decides an item of kind, returning validate.Errors
+// naming the fields at fault
+func (r Resolution) Validate(kind string) error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	invalid := func(field, message string) error {
+		return validate.Errors{{Field: field, Rule: "resolution", Message: message}}
+	}
+	switch kind {
+	case KindPersonMatch:
+		switch {
+		case r.StartDate != "":
+			return invalid("start_date", "only applies to term_start items")
+		case r.PositionID != 0:
+			return invalid("position_id", "only applies to position items")
+		case r.Ward != 0:
+			return invalid("ward", "only applies to position items")
+		case (r.PersonID != 0) == r.NewPerson:
+			return invalid("person_id", "give either person_id or new_person")
+		}
+	case KindPosition:
+		switch {
+		case r.StartDate != "":
+			return invalid("start_date", "only applies to term_start items")
+		case r.PersonID != 0:
+			return invalid("person_id", "only applies to person_match items")
+		case r.NewPerson:
+			return invalid("new_person", "only applies to person_match items")
+		case (r.PositionID != 0) == (r.Ward != 0):
+			return invalid("position_id", "give either position_id or ward")
+		}
+	case KindTermStart:
+		switch {
+		case r.PersonID != 0, r.NewPerson:
+			return invalid("person_id", "only applies to person_match items")
+		case r.PositionID != 0, r.Ward != 0:
+			return invalid("position_id", "only applies to position items")
+		case r.StartDate == "":
+			return invalid("start_date", "give start_date")
+		}
+	}
+	return nil
+}
+
+// Filter selects items to list
+type Filter struct {
+	Kind   string
+	Status string
+	Limit  int // no limit if zero
+}
+
+// Store keeps review items. Sync jobs create items through their plans;
+// the store is what admins read and decide them through.
+type Store interface {
+	// List returns the items matching filter, oldest first
+	List(ctx context.Context, filter Filter) ([]Item, error)
+	// Get returns an item, or ErrNotFound
+	Get(ctx context.Context, id int64) (Item, error)
+	// Resolve records the resolution of an open item, or returns
+	// ErrNotFound or ErrNotOpen
+	Resolve(ctx context.Context, id int64, resolution Resolution, by string) (Item, error)
+	// Dismiss closes an open item without a resolution, so the sync skips
+	// its record, or returns ErrNotFound or ErrNotOpen
+	Dismiss(ctx context.Context, id int64, note, by string) (Item, error)
+}
+
+// Default is the store behind the admin endpoints, set when the server
+// starts
+var Default Store
+
+// Errors returned by stores
+var (
+	ErrNotFound = errors.New("review item not found")
+	ErrNotOpen  = errors.New("review item is already resolved or dismissed")
+)
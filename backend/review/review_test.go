@@ -0,0 +1,44 @@
+package review
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jsanchez767/InfluencePower/backend/validate"
+)
+
+func TestResolutionValidate(t *testing.T) {
+	tests := []struct {
+		kind       string
+		resolution Resolution
+		field      string // the field at fault, or "" if valid
+	}{
+		{KindPersonMatch, Resolution{PersonID: 4}, ""},
+		{KindPersonMatch, Resolution{NewPerson: true, Note: "a different Nicole Lee"}, ""},
+		{KindPersonMatch, Resolution{}, "person_id"},
+		{KindPersonMatch, Resolution{PersonID: 4, NewPerson: true}, "person_id"},
+		{KindPersonMatch, Resolution{PersonID: 4, Ward: 2}, "ward"},
+		{KindPosition, Resolution{PositionID: 12}, ""},
+		{KindPosition, Resolution{Ward: 2}, ""},
+		{KindPosition, Resolution{Ward: 51}, "ward"},
+		{KindPosition, Resolution{PositionID: 12, Ward: 2}, "position_id"},
+		{KindPosition, Resolution{NewPerson: true, Ward: 2}, "new_person"},
+		{KindPosition, Resolution{Ward: 2, StartDate: "2023-05-15"}, "start_date"},
+		{KindTermStart, Resolution{StartDate: "2023-05-15"}, ""},
+		{KindTermStart, Resolution{}, "start_date"},
+		{KindTermStart, Resolution{StartDate: "May 15"}, "start_date"},
+		{KindTermStart, Resolution{StartDate: "2023-05-15", Ward: 2}, "position_id"},
+	}
+	for _, tt := range tests {
+		err := tt.resolution.Validate(tt.kind)
+		var fieldErrs validate.Errors
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s %+v: unexpected error %v", tt.kind, tt.resolution, err)
+		case tt.field != "" && !errors.As(err, &fieldErrs):
+			t.Errorf("%s %+v: expected an error on %s, got %v", tt.kind, tt.resolution, tt.field, err)
+		case tt.field != "" && fieldErrs[0].Field != tt.field:
+			t.Errorf("%s %+v: error on %s, want %s", tt.kind, tt.resolution, fieldErrs[0].Field, tt.field)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// PostgrestStore keeps items in the review_items table
+// (db/schema_review.sql)
+type PostgrestStore struct {
+	client func(ctx context.Context) *postgrest.Client
+}
+
+// NewPostgrestStore creates a store using the clients db.WithContext returns
+func NewPostgrestStore() *PostgrestStore {
+	return &PostgrestStore{client: db.WithContext}
+}
+
+// itemColumns are the review_items columns read back
+const itemColumns = "id, kind, key, source, record, reason, candidates, status, resolution, resolved_by, resolved_at, created_at, updated_at"
+
+// itemRow is the review_items table representation
+type itemRow struct {
+	ID         int64           `json:"id"`
+	Kind       string          `json:"kind"`
+	Key        string          `json:"key"`
+	Source     string          `json:"source"`
+	Record     json.RawMessage `json:"record"`
+	Reason     string          `json:"reason"`
+	Candidates json.RawMessage `json:"candidates"`
+	Status     string          `json:"status"`
+	Resolution *Resolution     `json:"resolution"`
+	ResolvedBy *string         `json:"resolved_by"`
+	ResolvedAt *time.Time      `json:"resolved_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func (row itemRow) toItem() Item {
+	item := Item{
+		ID:         row.ID,
+		Kind:       row.Kind,
+		Key:        row.Key,
+		Source:     row.Source,
+		Record:     row.Record,
+		Reason:     row.Reason,
+		Candidates: row.Candidates,
+		Status:     row.Status,
+		Resolution: row.Resolution,
+		ResolvedAt: row.ResolvedAt,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+	if row.ResolvedBy != nil {
+		item.ResolvedBy = *row.ResolvedBy
+	}
+	return item
+}
+
+// List implements Store
+func (s *PostgrestStore) List(ctx context.Context, filter Filter) ([]Item, error) {
+	query := s.client(ctx).From("review_items").Select(itemColumns, "", false)
+	if filter.Kind != "" {
+		query = query.Eq("kind", filter.Kind)
+	}
+	if filter.Status != "" {
+		query = query.Eq("status", filter.Status)
+	}
+	query = query.Order("id", &postgrest.OrderOpts{Ascending: true})
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit, "")
+	}
+
+	var rows []itemRow
+	if _, err := query.ExecuteTo(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list review items: %w", err)
+	}
+	items := make([]Item, len(rows))
+	for i, row := range rows {
+		items[i] = row.toItem()
+	}
+	return items, nil
+}
+
+// Get implements Store
+func (s *PostgrestStore) Get(ctx context.Context, id int64) (Item, error) {
+	var rows []itemRow
+	_, err := s.client(ctx).From("review_items").
+		Select(itemColumns, "", false).
+		Eq("id", strconv.FormatInt(id, 10)).
+		ExecuteTo(&rows)
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to load review item %d: %w", id, err)
+	}
+	if len(rows) == 0 {
+		return Item{}, ErrNotFound
+	}
+	return rows[0].toItem(), nil
+}
+
+// Resolve implements Store
+func (s *PostgrestStore) Resolve(ctx context.Context, id int64, resolution Resolution, by string) (Item, error) {
+	return s.close(ctx, id, StatusResolved, resolution, by)
+}
+
+// Dismiss implements Store
+func (s *PostgrestStore) Dismiss(ctx context.Context, id int64, note, by string) (Item, error) {
+	return s.close(ctx, id, StatusDismissed, Resolution{Note: note}, by)
+}
+
+// close moves an open item to status. The update is conditioned on the
+// item being open, so of two admins deciding an item at once only one wins.
+func (s *PostgrestStore) close(ctx context.Context, id int64, status string, resolution Resolution, by string) (Item, error) {
+	now := time.Now().UTC()
+	var rows []itemRow
+	_, err := s.client(ctx).From("review_items").
+		Update(map[string]interface{}{
+			"status":      status,
+			"resolution":  resolution,
+			"resolved_by": by,
+			"resolved_at": now,
+			"updated_at":  now,
+		}, "representation", "").
+		Eq("id", strconv.FormatInt(id, 10)).
+		Eq("status", StatusOpen).
+		ExecuteTo(&rows)
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to update review item %d: %w", id, err)
+	}
+	if len(rows) > 0 {
+		return rows[0].toItem(), nil
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return Item{}, err
+	}
+	return Item{}, ErrNotOpen
+}
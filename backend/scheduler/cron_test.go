@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@fortnightly",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday
+	from := time.Date(2024, 5, 1, 6, 30, 0, 0, chicago)
+
+	for _, tc := range []struct {
+		spec string
+		want time.Time
+	}{
+		{"30 6 * * *", time.Date(2024, 5, 2, 6, 30, 0, 0, chicago)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 6, 45, 0, 0, chicago)},
+		{"0 */6 * * *", time.Date(2024, 5, 1, 12, 0, 0, 0, chicago)},
+		{"0 5 * * 1", time.Date(2024, 5, 6, 5, 0, 0, 0, chicago)},
+		{"0 0 * * 7", time.Date(2024, 5, 5, 0, 0, 0, 0, chicago)},
+		{"0 9 1-5 * 1-5", time.Date(2024, 5, 1, 9, 0, 0, 0, chicago)},
+		{"0 0 15 * 6", time.Date(2024, 5, 4, 0, 0, 0, 0, chicago)}, // day of month or of week
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, chicago)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, chicago)},
+	} {
+		s, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q: expected %s, got %s", tc.spec, tc.want, got)
+		}
+	}
+
+	never, _ := ParseCron("0 0 30 2 *")
+	if got := never.Next(from); !got.IsZero() {
+		t.Errorf("February 30th: expected no next run, got %s", got)
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	known := func(name string) bool { return name == "votes" || name == "metrics" || name == "sync" }
+
+	schedules, err := ParseSchedules("votes=15 7 * * *; metrics=off; sync=@weekly", known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schedules["votes"].String(); got != "15 7 * * *" {
+		t.Errorf("votes: expected override, got %q", got)
+	}
+	if _, ok := schedules["metrics"]; ok {
+		t.Error("metrics: expected no schedule")
+	}
+	if got := schedules["sync"].String(); got != "@weekly" {
+		t.Errorf("sync: expected @weekly, got %q", got)
+	}
+	if got := schedules["people"].String(); got != DefaultSchedules["people"] {
+		t.Errorf("people: expected default, got %q", got)
+	}
+
+	for _, spec := range []string{"officials=0 5 * * *", "votes", "votes=0 25 * * *"} {
+		if _, err := ParseSchedules(spec, known); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Package scheduler runs jobs on cron schedules inside a long-running
+// process. A lock per job in the shared store keeps instances from running
+// the same job at once, and every run is recorded for the admin API.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // schedules may name a zone the container lacks
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/logging"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+// DefaultSchedules are the cron schedules of the scheduled jobs. Legislation,
+// votes and events are synced daily and people, terms and bodies weekly, well
+// within the readiness freshness thresholds; metrics are recomputed after the
+// votes they count. Soft-deleted rows past their retention are purged
+// nightly.
+var DefaultSchedules = map[string]string{
+	"bodies":  "0 5 * * 1",
+	"people":  "10 5 * * 1",
+	"terms":   "20 5 * * 1",
+	"matters": "0 6 * * *",
+	"votes":   "30 6 * * *",
+	"events":  "0 */6 * * *",
+	"metrics": "0 8 * * *",
+	"purge":   "0 3 * * *",
+}
+
+// scheduleOff disables a default schedule
+const scheduleOff = "off"
+
+// ParseSchedules parses schedules like "votes=30 7 * * *;metrics=off" over
+// DefaultSchedules. Entries are separated by semicolons, as cron expressions
+// contain commas. known reports whether a job name exists.
+func ParseSchedules(spec string, known func(name string) bool) (map[string]*Schedule, error) {
+	specs := make(map[string]string, len(DefaultSchedules))
+	for name, expr := range DefaultSchedules {
+		specs[name] = expr
+	}
+
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, expr, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule %q: expected job=cron expression", part)
+		}
+		name = strings.TrimSpace(name)
+		if !known(name) {
+			return nil, fmt.Errorf("invalid schedule %q: unknown job %q", part, name)
+		}
+		specs[name] = strings.TrimSpace(expr)
+	}
+
+	schedules := make(map[string]*Schedule, len(specs))
+	for name, expr := range specs {
+		if expr == scheduleOff {
+			continue
+		}
+		s, err := ParseCron(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		schedules[name] = s
+	}
+	return schedules, nil
+}
+
+// Errors returned by Trigger
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrRunning    = errors.New("job is already running")
+)
+
+// DefaultLockTTL is how long a job's lock lasts without being renewed. A
+// running job renews it every third of the TTL, so the lock only lapses when
+// the instance holding it dies.
+const DefaultLockTTL = 10 * time.Minute
+
+// DefaultHistory is how many runs Runs returns when no limit is given
+const DefaultHistory = 50
+
+// Task is a job the scheduler can run. Run returns the records the job
+// handled per entity.
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) (map[string]db.SyncCounts, error)
+}
+
+// Config configures a Scheduler
+type Config struct {
+	Schedules map[string]*Schedule // cron schedule per task name; tasks without one only run when triggered
+	Location  *time.Location       // zone schedules are read in; UTC if nil
+	LockTTL   time.Duration        // DefaultLockTTL if zero
+	Instance  string               // identifies this process in locks and runs; host:pid if empty
+}
+
+// Scheduler runs tasks on their schedules or when triggered
+type Scheduler struct {
+	cfg   Config
+	tasks map[string]Task
+	store Store
+	now   func() time.Time
+
+	stop    context.Context // done when Start returns
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// Default is the scheduler behind the admin endpoints, set when the server
+// starts
+var Default *Scheduler
+
+// New creates a scheduler for tasks that records runs in store
+func New(cfg Config, store Store, tasks []Task) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = DefaultLockTTL
+	}
+	if cfg.Instance == "" {
+		host, _ := os.Hostname()
+		cfg.Instance = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+
+	s := &Scheduler{
+		cfg:     cfg,
+		tasks:   make(map[string]Task, len(tasks)),
+		store:   store,
+		now:     time.Now,
+		running: make(map[string]bool),
+	}
+	s.stop, s.cancel = context.WithCancel(context.Background())
+	for _, t := range tasks {
+		s.tasks[t.Name] = t
+	}
+	return s
+}
+
+// Start runs scheduled tasks until ctx is done, then cancels the tasks
+// still running and waits for them to record their outcome
+func (s *Scheduler) Start(ctx context.Context) {
+	defer s.wg.Wait()
+	defer s.cancel()
+
+	if len(s.cfg.Schedules) == 0 {
+		<-ctx.Done()
+		return
+	}
+	slog.InfoContext(ctx, "scheduler started", "jobs", len(s.cfg.Schedules), "instance", s.cfg.Instance)
+
+	next := make(map[string]time.Time, len(s.cfg.Schedules))
+	for name, schedule := range s.cfg.Schedules {
+		next[name] = schedule.Next(s.now().In(s.cfg.Location))
+	}
+
+	for {
+		wake := time.Time{}
+		for _, t := range next {
+			if !t.IsZero() && (wake.IsZero() || t.Before(wake)) {
+				wake = t
+			}
+		}
+		if wake.IsZero() {
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(wake.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := s.now().In(s.cfg.Location)
+		for _, name := range sortedNames(next) {
+			if next[name].After(now) {
+				continue
+			}
+			next[name] = s.cfg.Schedules[name].Next(now)
+			if _, err := s.begin(ctx, name, TriggerSchedule); err != nil && !errors.Is(err, ErrRunning) {
+				slog.ErrorContext(ctx, "scheduled job not started", "job", name, "error", err)
+			}
+		}
+	}
+}
+
+// Trigger starts task name now and returns its run. The run carries ctx's
+// request ID but is not cancelled with ctx, so a request can start a long
+// job and return. It fails with ErrRunning when the job is running here or
+// on another instance.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*Run, error) {
+	return s.begin(context.WithoutCancel(ctx), name, TriggerManual)
+}
+
+// begin takes the job's lock, records its run and starts it
+func (s *Scheduler) begin(ctx context.Context, name, trigger string) (*Run, error) {
+	task, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrUnknownJob
+	}
+
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		return nil, ErrRunning
+	}
+	s.running[name] = true
+	s.mu.Unlock()
+	release := func() {
+		s.mu.Lock()
+		delete(s.running, name)
+		s.mu.Unlock()
+	}
+
+	// Scheduled runs get their own request ID; manual ones keep the caller's
+	if trigger == TriggerSchedule || middleware.RequestIDFromContext(ctx) == "" {
+		ctx = middleware.WithRequestID(ctx, middleware.NewRequestID())
+	}
+	ctx = logging.With(ctx, "scheduled_job", name, "trigger", trigger)
+
+	locked, err := s.store.Lock(ctx, name, s.cfg.Instance, s.cfg.LockTTL)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if !locked {
+		release()
+		slog.InfoContext(ctx, "job is running on another instance")
+		return nil, ErrRunning
+	}
+
+	run := &Run{
+		Job:       name,
+		Trigger:   trigger,
+		Instance:  s.cfg.Instance,
+		Status:    StatusRunning,
+		StartedAt: s.now().UTC(),
+		RequestID: middleware.RequestIDFromContext(ctx),
+	}
+	if err := s.store.StartRun(ctx, run); err != nil {
+		s.store.Unlock(ctx, name, s.cfg.Instance)
+		release()
+		return nil, err
+	}
+
+	started := *run
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer release()
+		s.execute(ctx, task, run)
+	}()
+	return &started, nil
+}
+
+// execute runs task, renewing its lock, and records the outcome
+func (s *Scheduler) execute(ctx context.Context, task Task, run *Run) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopOnShutdown := context.AfterFunc(s.stop, cancel)
+	defer stopOnShutdown()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renew(ctx, task.Name)
+	}()
+
+	slog.InfoContext(ctx, "scheduled job started", "run_id", run.ID)
+	counts, err := task.Run(ctx)
+	cancel()
+	<-renewed
+
+	finished := s.now().UTC()
+	run.FinishedAt = &finished
+	run.Counts = counts
+	run.Status = StatusSucceeded
+	for _, c := range counts {
+		if c.Failed > 0 {
+			run.Status = StatusPartial
+		}
+	}
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	}
+
+	// The job's context is done; record the outcome regardless
+	record := context.WithoutCancel(ctx)
+	if err := s.store.FinishRun(record, run); err != nil {
+		slog.ErrorContext(record, "failed to record job run", "run_id", run.ID, "error", err)
+	}
+	if err := s.store.Unlock(record, task.Name, s.cfg.Instance); err != nil {
+		slog.WarnContext(record, "failed to release job lock", "error", err)
+	}
+	slog.InfoContext(record, "scheduled job finished", "run_id", run.ID, "status", run.Status,
+		"duration_ms", finished.Sub(run.StartedAt).Milliseconds())
+}
+
+// renew extends the job's lock until ctx is done
+func (s *Scheduler) renew(ctx context.Context, name string) {
+	ticker := time.NewTicker(s.cfg.LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.store.Lock(ctx, name, s.cfg.Instance, s.cfg.LockTTL); err != nil {
+				slog.WarnContext(ctx, "failed to renew job lock", "error", err)
+			}
+		}
+	}
+}
+
+// JobStatus describes a task for the admin API
+type JobStatus struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule,omitempty"` // cron expression; empty for jobs only run when triggered
+	Timezone  string     `json:"timezone"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	Running   bool       `json:"running"` // on this instance
+	LastRun   *Run       `json:"last_run,omitempty"`
+}
+
+// Jobs returns the status of every task, by name
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobStatus, error) {
+	now := s.now().In(s.cfg.Location)
+	jobs := make([]JobStatus, 0, len(s.tasks))
+	for _, name := range sortedNames(s.tasks) {
+		status := JobStatus{Name: name, Timezone: s.cfg.Location.String()}
+		if schedule, ok := s.cfg.Schedules[name]; ok {
+			status.Schedule = schedule.String()
+			if next := schedule.Next(now); !next.IsZero() {
+				status.NextRunAt = &next
+			}
+		}
+		s.mu.Lock()
+		status.Running = s.running[name]
+		s.mu.Unlock()
+
+		last, err := s.store.Runs(ctx, name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(last) > 0 {
+			status.LastRun = &last[0]
+		}
+		jobs = append(jobs, status)
+	}
+	return jobs, nil
+}
+
+// Runs returns the most recent runs of job, or of every job if job is empty
+func (s *Scheduler) Runs(ctx context.Context, job string, limit int) ([]Run, error) {
+	if job != "" {
+		if _, ok := s.tasks[job]; !ok {
+			return nil, ErrUnknownJob
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultHistory
+	}
+	return s.store.Runs(ctx, job, limit)
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	"github.com/Jsanchez767/InfluencePower/backend/middleware"
+)
+
+// blockingTask returns a task that runs until release is closed
+func blockingTask(name string, release <-chan struct{}, counts map[string]db.SyncCounts, err error) Task {
+	return Task{Name: name, Run: func(ctx context.Context) (map[string]db.SyncCounts, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return counts, err
+	}}
+}
+
+// waitForRun waits until the run with id has finished
+func waitForRun(t *testing.T, store Store, job string, id int64) Run {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runs, _ := store.Runs(context.Background(), job, DefaultHistory)
+		for _, run := range runs {
+			if run.ID == id && run.Status != StatusRunning {
+				return run
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("run %d of %s did not finish", id, job)
+	return Run{}
+}
+
+func TestTriggerRecordsRun(t *testing.T) {
+	store := NewMemoryStore()
+	release := make(chan struct{})
+	counts := map[string]db.SyncCounts{"votes": {Fetched: 3, Upserted: 2, Failed: 1}}
+	s := New(Config{Instance: "test"}, store, []Task{blockingTask("votes", release, counts, nil)})
+
+	ctx := middleware.WithRequestID(context.Background(), "req-1")
+	run, err := s.Trigger(ctx, "votes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != StatusRunning || run.Trigger != TriggerManual || run.RequestID != "req-1" {
+		t.Errorf("unexpected started run %+v", run)
+	}
+
+	if _, err := s.Trigger(ctx, "votes"); !errors.Is(err, ErrRunning) {
+		t.Errorf("expected ErrRunning while running, got %v", err)
+	}
+	if _, err := s.Trigger(ctx, "officials"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("expected ErrUnknownJob, got %v", err)
+	}
+
+	close(release)
+	finished := waitForRun(t, store, "votes", run.ID)
+	if finished.Status != StatusPartial || finished.FinishedAt == nil || finished.Counts["votes"].Failed != 1 {
+		t.Errorf("expected a finished partial run, got %+v", finished)
+	}
+
+	// The lock is released once the run is recorded
+	if locked, _ := store.Lock(ctx, "votes", "other", time.Minute); !locked {
+		t.Error("expected the lock to be released")
+	}
+}
+
+func TestTriggerRecordsFailure(t *testing.T) {
+	store := NewMemoryStore()
+	release := make(chan struct{})
+	close(release)
+	s := New(Config{Instance: "test"}, store, []Task{blockingTask("metrics", release, nil, errors.New("upstream down"))})
+
+	run, err := s.Trigger(context.Background(), "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished := waitForRun(t, store, "metrics", run.ID)
+	if finished.Status != StatusFailed || finished.Error != "upstream down" {
+		t.Errorf("expected a failed run, got %+v", finished)
+	}
+}
+
+func TestLockKeepsInstancesApart(t *testing.T) {
+	store := NewMemoryStore()
+	release := make(chan struct{})
+	defer close(release)
+	tasks := []Task{blockingTask("votes", release, nil, nil)}
+	a := New(Config{Instance: "a"}, store, tasks)
+	b := New(Config{Instance: "b"}, store, tasks)
+
+	if _, err := a.Trigger(context.Background(), "votes"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Trigger(context.Background(), "votes"); !errors.Is(err, ErrRunning) {
+		t.Errorf("expected ErrRunning on the second instance, got %v", err)
+	}
+
+	// An expired lock is taken over
+	store.Lock(context.Background(), "stale", "dead", -time.Second)
+	if locked, _ := store.Lock(context.Background(), "stale", "b", time.Minute); !locked {
+		t.Error("expected an expired lock to be taken over")
+	}
+}
+
+func TestStopCancelsRunningJobs(t *testing.T) {
+	store := NewMemoryStore()
+	s := New(Config{Instance: "test"}, store, []Task{blockingTask("votes", make(chan struct{}), nil, nil)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+
+	run, err := s.Trigger(context.Background(), "votes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return")
+	}
+
+	runs, _ := store.Runs(context.Background(), "votes", 1)
+	if len(runs) != 1 || runs[0].ID != run.ID || runs[0].Status != StatusFailed {
+		t.Errorf("expected the cancelled run to be recorded as failed, got %+v", runs)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	votes, _ := ParseCron("30 6 * * *")
+	chicago, _ := time.LoadLocation("America/Chicago")
+	s := New(Config{Schedules: map[string]*Schedule{"votes": votes}, Location: chicago, Instance: "test"},
+		NewMemoryStore(), []Task{{Name: "votes"}, {Name: "headshots"}})
+	s.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	jobs, err := s.Jobs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "headshots" || jobs[1].Name != "votes" {
+		t.Fatalf("expected headshots and votes, got %+v", jobs)
+	}
+	if jobs[0].Schedule != "" || jobs[0].NextRunAt != nil {
+		t.Errorf("headshots: expected no schedule, got %+v", jobs[0])
+	}
+	want := time.Date(2024, 5, 2, 6, 30, 0, 0, chicago)
+	if jobs[1].NextRunAt == nil || !jobs[1].NextRunAt.Equal(want) || jobs[1].Timezone != "America/Chicago" {
+		t.Errorf("votes: expected next run at %s, got %+v", want, jobs[1])
+	}
+}
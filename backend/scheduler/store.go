@@ -0,0 +1,295 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/apierror"
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Triggers of a run
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Statuses of a run
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusPartial   = "partial" // finished, but some records could not be written
+	StatusFailed    = "failed"
+)
+
+// Run is one execution of a job, as stored in job_runs
+type Run struct {
+	ID         int64                    `json:"id"`
+	Job        string                   `json:"job"`
+	Trigger    string                   `json:"trigger"`
+	Instance   string                   `json:"instance"` // host and process that ran it
+	Status     string                   `json:"status"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt *time.Time               `json:"finished_at,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+	RequestID  string                   `json:"request_id,omitempty"`
+	Counts     map[string]db.SyncCounts `json:"counts,omitempty"` // records handled per entity
+}
+
+// Store holds job locks and run history
+type Store interface {
+	// Lock takes or renews the lock on job for holder until ttl passes. It
+	// reports false when another holder's lock has not expired.
+	Lock(ctx context.Context, job, holder string, ttl time.Duration) (bool, error)
+	// Unlock releases holder's lock on job
+	Unlock(ctx context.Context, job, holder string) error
+	// StartRun records a new run and sets its ID
+	StartRun(ctx context.Context, run *Run) error
+	// FinishRun records the outcome of a started run
+	FinishRun(ctx context.Context, run *Run) error
+	// Runs returns the most recent runs, newest first, of job or of every
+	// job if job is empty
+	Runs(ctx context.Context, job string, limit int) ([]Run, error)
+}
+
+// MemoryStore keeps locks and runs in process, for a single instance and for
+// tests
+type MemoryStore struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+	runs  []Run
+}
+
+type memoryLock struct {
+	holder string
+	until  time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{locks: make(map[string]memoryLock)}
+}
+
+// Lock implements Store
+func (s *MemoryStore) Lock(ctx context.Context, job, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if l, ok := s.locks[job]; ok && l.holder != holder && now.Before(l.until) {
+		return false, nil
+	}
+	s.locks[job] = memoryLock{holder: holder, until: now.Add(ttl)}
+	return true, nil
+}
+
+// Unlock implements Store
+func (s *MemoryStore) Unlock(ctx context.Context, job, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[job].holder == holder {
+		delete(s.locks, job)
+	}
+	return nil
+}
+
+// StartRun implements Store
+func (s *MemoryStore) StartRun(ctx context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.ID = int64(len(s.runs) + 1)
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+// FinishRun implements Store
+func (s *MemoryStore) FinishRun(ctx context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run.ID < 1 || int(run.ID) > len(s.runs) {
+		return fmt.Errorf("run %d not found", run.ID)
+	}
+	s.runs[run.ID-1] = *run
+	return nil
+}
+
+// Runs implements Store
+func (s *MemoryStore) Runs(ctx context.Context, job string, limit int) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := []Run{}
+	for i := len(s.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		if job == "" || s.runs[i].Job == job {
+			runs = append(runs, s.runs[i])
+		}
+	}
+	return runs, nil
+}
+
+// PostgrestStore keeps locks in job_locks and runs in job_runs
+// (db/schema_scheduler.sql), so instances sharing a database run each job
+// once
+type PostgrestStore struct {
+	client func(ctx context.Context) *postgrest.Client
+}
+
+// NewPostgrestStore creates a store using the clients db.WithContext returns
+func NewPostgrestStore() *PostgrestStore {
+	return &PostgrestStore{client: db.WithContext}
+}
+
+// lockRow is the job_locks table representation
+type lockRow struct {
+	Job         string    `json:"job"`
+	Holder      string    `json:"holder"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Lock implements Store. It inserts the lock, or takes it over when it has
+// expired or is already held by holder; Postgres re-checks the update's
+// conditions under the row lock, so only one instance wins.
+func (s *PostgrestStore) Lock(ctx context.Context, job, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	row := lockRow{Job: job, Holder: holder, LockedUntil: now.Add(ttl)}
+
+	_, _, err := s.client(ctx).From("job_locks").Insert(row, false, "", "minimal", "").Execute()
+	if err == nil {
+		return true, nil
+	}
+	if apierror.UpstreamCode(err) != "23505" {
+		return false, fmt.Errorf("failed to lock job %s: %w", job, err)
+	}
+
+	var taken []lockRow
+	_, err = s.client(ctx).From("job_locks").
+		Update(row, "representation", "").
+		Eq("job", job).
+		Or(fmt.Sprintf(`locked_until.lt."%s",holder.eq."%s"`, now.Format(time.RFC3339Nano), holder), "").
+		ExecuteTo(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock job %s: %w", job, err)
+	}
+	return len(taken) > 0, nil
+}
+
+// Unlock implements Store
+func (s *PostgrestStore) Unlock(ctx context.Context, job, holder string) error {
+	_, _, err := s.client(ctx).From("job_locks").
+		Delete("minimal", "").
+		Eq("job", job).
+		Eq("holder", holder).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to unlock job %s: %w", job, err)
+	}
+	return nil
+}
+
+// runRow is the job_runs table representation
+type runRow struct {
+	ID         int64                    `json:"id,omitempty"`
+	Job        string                   `json:"job"`
+	Trigger    string                   `json:"trigger"`
+	Instance   string                   `json:"instance"`
+	Status     string                   `json:"status"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt *time.Time               `json:"finished_at"`
+	Error      *string                  `json:"error"`
+	RequestID  *string                  `json:"request_id"`
+	Counts     map[string]db.SyncCounts `json:"counts"`
+}
+
+func toRow(run *Run) runRow {
+	row := runRow{
+		Job:        run.Job,
+		Trigger:    run.Trigger,
+		Instance:   run.Instance,
+		Status:     run.Status,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		Counts:     run.Counts,
+	}
+	if run.Error != "" {
+		row.Error = &run.Error
+	}
+	if run.RequestID != "" {
+		row.RequestID = &run.RequestID
+	}
+	return row
+}
+
+func (row runRow) toRun() Run {
+	run := Run{
+		ID:         row.ID,
+		Job:        row.Job,
+		Trigger:    row.Trigger,
+		Instance:   row.Instance,
+		Status:     row.Status,
+		StartedAt:  row.StartedAt,
+		FinishedAt: row.FinishedAt,
+		Counts:     row.Counts,
+	}
+	if row.Error != nil {
+		run.Error = *row.Error
+	}
+	if row.RequestID != nil {
+		run.RequestID = *row.RequestID
+	}
+	return run
+}
+
+// runColumns are the job_runs columns read back
+const runColumns = "id, job, trigger, instance, status, started_at, finished_at, error, request_id, counts"
+
+// StartRun implements Store
+func (s *PostgrestStore) StartRun(ctx context.Context, run *Run) error {
+	var created []runRow
+	_, err := s.client(ctx).From("job_runs").
+		Insert(toRow(run), false, "", "representation", "").
+		ExecuteTo(&created)
+	if err != nil {
+		return fmt.Errorf("failed to record run of %s: %w", run.Job, err)
+	}
+	if len(created) == 0 {
+		return fmt.Errorf("failed to record run of %s: no row returned", run.Job)
+	}
+	run.ID = created[0].ID
+	return nil
+}
+
+// FinishRun implements Store
+func (s *PostgrestStore) FinishRun(ctx context.Context, run *Run) error {
+	_, _, err := s.client(ctx).From("job_runs").
+		Update(toRow(run), "minimal", "").
+		Eq("id", strconv.FormatInt(run.ID, 10)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record outcome of run %d: %w", run.ID, err)
+	}
+	return nil
+}
+
+// Runs implements Store
+func (s *PostgrestStore) Runs(ctx context.Context, job string, limit int) ([]Run, error) {
+	query := s.client(ctx).From("job_runs").Select(runColumns, "", false)
+	if job != "" {
+		query = query.Eq("job", job)
+	}
+	var rows []runRow
+	_, err := query.
+		Order("started_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(limit, "").
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job runs: %w", err)
+	}
+
+	runs := make([]Run, len(rows))
+	for i, row := range rows {
+		runs[i] = row.toRun()
+	}
+	return runs, nil
+}
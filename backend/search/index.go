@@ -0,0 +1,438 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Document types returned in search hits and facets
+const (
+	TypePerson    = "person"
+	TypeMatter    = "matter"
+	TypeCommittee = "committee"
+	TypeMeeting   = "meeting"
+)
+
+// Document is a single searchable record
+type Document struct {
+	Type    string
+	ID      string
+	Title   string
+	Body    string
+	Aliases []string // Alternate titles, e.g. a person's name in "Last, First" order
+	Keys    []string // Exact identifiers such as file numbers
+	Date    string
+}
+
+// Query describes a search request against an Index
+type Query struct {
+	Text   string
+	Types  []string // Restrict hits to these document types (facets are unaffected)
+	Limit  int
+	Offset int
+}
+
+// Hit is a ranked search result
+type Hit struct {
+	Type    string  `json:"type"`
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet,omitempty"`
+	Date    string  `json:"date,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// Results is the response of a search
+type Results struct {
+	Query  string         `json:"query"`
+	Total  int            `json:"total"`
+	Hits   []Hit          `json:"hits"`
+	Facets map[string]int `json:"facets"`
+}
+
+const (
+	fieldTitle = iota
+	fieldAlias
+	fieldBody
+	numFields
+)
+
+// fieldWeights boosts matches in titles and names over matches in body text
+var fieldWeights = [numFields]float64{3.0, 2.5, 1.0}
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// prefixWeight discounts terms that only match a query term by prefix
+	prefixWeight = 0.5
+	// maxExpansions caps how many index terms a prefix may expand to
+	maxExpansions = 25
+	// keyBoost guarantees exact identifier matches rank first
+	keyBoost = 100.0
+)
+
+type posting struct {
+	doc int
+	tf  [numFields]int
+}
+
+// Index is an immutable in-memory inverted index over a set of documents
+type Index struct {
+	docs     []Document
+	postings map[string][]posting
+	terms    []string // sorted, for prefix expansion
+	lengths  [][numFields]int
+	avgLen   [numFields]float64
+	keys     map[string][]int
+}
+
+// NewIndex builds an index over docs
+func NewIndex(docs []Document) *Index {
+	ix := &Index{
+		docs:     docs,
+		postings: make(map[string][]posting),
+		lengths:  make([][numFields]int, len(docs)),
+		keys:     make(map[string][]int),
+	}
+
+	var totals [numFields]int
+	for i, doc := range docs {
+		counts := make(map[string]*[numFields]int)
+		add := func(f int, text string) {
+			for _, t := range tokenize(text) {
+				if stopWords[t.term] {
+					continue
+				}
+				c, ok := counts[t.term]
+				if !ok {
+					c = new([numFields]int)
+					counts[t.term] = c
+				}
+				c[f]++
+				ix.lengths[i][f]++
+			}
+		}
+
+		add(fieldTitle, doc.Title)
+		for _, alias := range doc.Aliases {
+			add(fieldAlias, alias)
+		}
+		add(fieldBody, doc.Body)
+
+		for term, c := range counts {
+			ix.postings[term] = append(ix.postings[term], posting{doc: i, tf: *c})
+		}
+		for _, key := range doc.Keys {
+			k := strings.ToUpper(strings.TrimSpace(key))
+			if k != "" {
+				ix.keys[k] = append(ix.keys[k], i)
+			}
+		}
+		for f := 0; f < numFields; f++ {
+			totals[f] += ix.lengths[i][f]
+		}
+	}
+
+	for f := 0; f < numFields; f++ {
+		if len(docs) > 0 {
+			ix.avgLen[f] = float64(totals[f]) / float64(len(docs))
+		}
+		if ix.avgLen[f] == 0 {
+			ix.avgLen[f] = 1
+		}
+	}
+
+	ix.terms = make([]string, 0, len(ix.postings))
+	for term := range ix.postings {
+		ix.terms = append(ix.terms, term)
+	}
+	sort.Strings(ix.terms)
+
+	return ix
+}
+
+// Len returns the number of indexed documents
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// expand returns the index terms matching a query term and their weights.
+// Exact matches always count; prefixes are only tried for terms of three or
+// more characters so that "hop" finds "hopkins".
+func (ix *Index) expand(term string, prefix bool) map[string]float64 {
+	out := make(map[string]float64)
+	if _, ok := ix.postings[term]; ok {
+		out[term] = 1
+	}
+	if !prefix || len(term) < 3 {
+		return out
+	}
+
+	i := sort.SearchStrings(ix.terms, term)
+	for n := 0; i < len(ix.terms) && n < maxExpansions; i++ {
+		t := ix.terms[i]
+		if !strings.HasPrefix(t, term) {
+			break
+		}
+		if t != term {
+			out[t] = prefixWeight
+			n++
+		}
+	}
+	return out
+}
+
+// score computes the BM25F contribution of one posting
+func (ix *Index) score(p posting, idf float64) float64 {
+	var wtf float64
+	for f := 0; f < numFields; f++ {
+		if p.tf[f] == 0 {
+			continue
+		}
+		norm := 1 - bm25B + bm25B*float64(ix.lengths[p.doc][f])/ix.avgLen[f]
+		wtf += fieldWeights[f] * float64(p.tf[f]) / norm
+	}
+	return idf * wtf * (bm25K1 + 1) / (wtf + bm25K1)
+}
+
+// Search ranks documents against q. Documents matching every query term are
+// preferred; if none do, documents matching any term are returned instead.
+func (ix *Index) Search(q Query) Results {
+	results := Results{Query: q.Text, Hits: []Hit{}, Facets: map[string]int{}}
+
+	queryTerms := terms(q.Text)
+	scores := make(map[int]float64)
+	matched := make(map[int]int)
+	highlight := make(map[string]bool)
+
+	n := float64(len(ix.docs))
+	for qi, term := range queryTerms {
+		// Only the last term is treated as a prefix, mirroring type-ahead input
+		expansions := ix.expand(term, qi == len(queryTerms)-1)
+		best := make(map[int]float64)
+		for t, weight := range expansions {
+			highlight[t] = true
+			postings := ix.postings[t]
+			df := float64(len(postings))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for _, p := range postings {
+				if s := weight * ix.score(p, idf); s > best[p.doc] {
+					best[p.doc] = s
+				}
+			}
+		}
+		for doc, s := range best {
+			scores[doc] += s
+			matched[doc]++
+		}
+	}
+
+	required := len(queryTerms)
+	all := false
+	for _, count := range matched {
+		if count == required {
+			all = true
+			break
+		}
+	}
+
+	normQuery := Normalize(q.Text)
+	reversedQuery := Normalize(ReverseName(q.Text))
+	candidates := make(map[int]float64)
+	for doc, s := range scores {
+		if all && matched[doc] < required {
+			continue
+		}
+		if required > 0 {
+			s *= float64(matched[doc]) / float64(required)
+		}
+		s *= ix.phraseBoost(doc, normQuery, reversedQuery)
+		candidates[doc] = s
+	}
+
+	// Exact identifier lookups (file numbers) always match, even if the
+	// tokenizer split the identifier into unrelated terms
+	key := strings.ToUpper(strings.TrimSpace(q.Text))
+	if fileNumber, ok := NormalizeFileNumber(q.Text); ok {
+		key = fileNumber
+	}
+	for _, doc := range ix.keys[key] {
+		candidates[doc] += keyBoost
+	}
+
+	typeFilter := make(map[string]bool)
+	for _, t := range q.Types {
+		typeFilter[t] = true
+	}
+
+	type ranked struct {
+		doc int
+		hit Hit
+	}
+	var hits []ranked
+	for doc, s := range candidates {
+		d := ix.docs[doc]
+		results.Facets[d.Type]++
+		if len(typeFilter) > 0 && !typeFilter[d.Type] {
+			continue
+		}
+		hits = append(hits, ranked{doc: doc, hit: Hit{
+			Type:  d.Type,
+			ID:    d.ID,
+			Title: d.Title,
+			Date:  d.Date,
+			Score: math.Round(s*1000) / 1000,
+		}})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i].hit, hits[j].hit
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	})
+
+	results.Total = len(hits)
+	if q.Offset > 0 {
+		if q.Offset >= len(hits) {
+			hits = nil
+		} else {
+			hits = hits[q.Offset:]
+		}
+	}
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+
+	for _, r := range hits {
+		r.hit.Snippet = snippet(ix.docs[r.doc], highlight)
+		results.Hits = append(results.Hits, r.hit)
+	}
+	return results
+}
+
+// phraseBoost rewards documents whose title or alias contains the whole query
+func (ix *Index) phraseBoost(doc int, normQuery, reversedQuery string) float64 {
+	if normQuery == "" {
+		return 1
+	}
+	d := ix.docs[doc]
+	boost := 1.0
+	check := func(text string) {
+		norm := Normalize(text)
+		for _, q := range []string{normQuery, reversedQuery} {
+			switch {
+			case norm == q:
+				boost = math.Max(boost, 2)
+			case strings.Contains(norm, q):
+				boost = math.Max(boost, 1.5)
+			}
+		}
+	}
+	check(d.Title)
+	for _, alias := range d.Aliases {
+		check(alias)
+	}
+	return boost
+}
+
+const (
+	snippetWidth   = 160
+	snippetContext = 50
+)
+
+// snippet returns an HTML-escaped excerpt of the best matching field with
+// matched terms wrapped in <mark> tags
+func snippet(d Document, highlight map[string]bool) string {
+	for _, text := range []string{d.Body, d.Title} {
+		if s, ok := highlightText(text, highlight); ok {
+			return s
+		}
+	}
+	if d.Body != "" {
+		s, _ := highlightText(d.Body, nil)
+		return s
+	}
+	return ""
+}
+
+// highlightText excerpts text around the first highlighted term. It reports
+// false when no term in text was highlighted.
+func highlightText(text string, highlight map[string]bool) (string, bool) {
+	tokens := tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if highlight[t.term] {
+			first = i
+			break
+		}
+	}
+	if first < 0 && highlight != nil {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if len(text) > snippetWidth {
+		if first >= 0 {
+			start = tokens[first].start - snippetContext
+		}
+		if start < 0 {
+			start = 0
+		}
+		// Snap to the start of a word
+		for _, t := range tokens {
+			if t.start >= start {
+				start = t.start
+				break
+			}
+		}
+		end = start + snippetWidth
+		if end >= len(text) {
+			end = len(text)
+		} else {
+			// Snap to the end of a word, keeping the highlighted one whole
+			// even when it is longer than the snippet
+			snapped := start
+			for i := len(tokens) - 1; i >= 0; i-- {
+				if tokens[i].end <= end {
+					snapped = tokens[i].end
+					break
+				}
+			}
+			if first >= 0 && snapped < tokens[first].end {
+				snapped = tokens[first].end
+			}
+			if snapped > start {
+				end = snapped
+			}
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, t := range tokens {
+		if t.start < start || t.end > end || !highlight[t.term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:t.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		b.WriteString("</mark>")
+		pos = t.end
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String()), first >= 0
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func testIndex() *Index {
+	return NewIndex([]Document{
+		{Type: TypePerson, ID: "1", Title: "Brian Hopkins", Aliases: []string{"Hopkins, Brian"}, Body: "Alderman, Ward 2"},
+		{Type: TypePerson, ID: "2", Title: "Jeylú Gutiérrez", Aliases: []string{"Gutiérrez, Jeylú"}, Body: "Alderman, Ward 14"},
+		{Type: TypePerson, ID: "3", Title: "Matthew O'Shea", Body: "Alderman, Ward 19"},
+		{Type: TypeMatter, ID: "100", Title: "Amendment of Municipal Code regarding zoning in Ward 2", Keys: []string{"O2024-1234"}, Body: "An ordinance amending the zoning classification sponsored by Hopkins"},
+		{Type: TypeMatter, ID: "101", Title: "Appointment of board members", Keys: []string{"A2024-77"}, Body: "Appointment to the Chicago Park District board"},
+		{Type: TypeCommittee, ID: "200", Title: "Committee on Zoning, Landmarks and Building Standards"},
+		{Type: TypeMeeting, ID: "300", Title: "Committee on Zoning, Landmarks and Building Standards", Date: "2024-03-01", Body: "City Hall, Council Chambers"},
+	})
+}
+
+func TestSearchLegistarNameFormat(t *testing.T) {
+	ix := testIndex()
+
+	for _, q := range []string{"Hopkins, Brian", "brian hopkins", "hopk"} {
+		res := ix.Search(Query{Text: q, Types: []string{TypePerson}})
+		if len(res.Hits) == 0 || res.Hits[0].ID != "1" {
+			t.Errorf("query %q: expected person 1 first, got %+v", q, res.Hits)
+		}
+	}
+}
+
+func TestSearchFoldsAccentsAndApostrophes(t *testing.T) {
+	ix := testIndex()
+
+	if res := ix.Search(Query{Text: "gutierrez"}); len(res.Hits) == 0 || res.Hits[0].ID != "2" {
+		t.Errorf("expected accent-insensitive match, got %+v", res.Hits)
+	}
+	if res := ix.Search(Query{Text: "oshea"}); len(res.Hits) == 0 || res.Hits[0].ID != "3" {
+		t.Errorf("expected apostrophe-insensitive match, got %+v", res.Hits)
+	}
+}
+
+func TestSearchFileNumber(t *testing.T) {
+	ix := testIndex()
+
+	for _, q := range []string{"O2024-1234", "o2024 - 1234"} {
+		res := ix.Search(Query{Text: q})
+		if len(res.Hits) == 0 || res.Hits[0].ID != "100" {
+			t.Errorf("query %q: expected matter 100 first, got %+v", q, res.Hits)
+		}
+	}
+}
+
+func TestSearchFacetsIgnoreTypeFilter(t *testing.T) {
+	ix := testIndex()
+
+	res := ix.Search(Query{Text: "zoning", Types: []string{TypeCommittee}})
+	if res.Total != 1 || res.Hits[0].Type != TypeCommittee {
+		t.Fatalf("expected one committee hit, got %+v", res.Hits)
+	}
+	if res.Facets[TypeMatter] != 1 || res.Facets[TypeMeeting] != 1 || res.Facets[TypeCommittee] != 1 {
+		t.Errorf("unexpected facets: %v", res.Facets)
+	}
+}
+
+func TestSearchRequiresAllTermsWhenPossible(t *testing.T) {
+	ix := testIndex()
+
+	res := ix.Search(Query{Text: "zoning ordinance"})
+	if res.Total != 1 || res.Hits[0].ID != "100" {
+		t.Errorf("expected only matter 100, got %+v", res.Hits)
+	}
+}
+
+func TestSearchSnippetHighlights(t *testing.T) {
+	ix := testIndex()
+
+	res := ix.Search(Query{Text: "park district"})
+	if len(res.Hits) == 0 {
+		t.Fatal("expected a hit")
+	}
+	snippet := res.Hits[0].Snippet
+	if !strings.Contains(snippet, "<mark>Park</mark> <mark>District</mark>") {
+		t.Errorf("expected highlighted terms in snippet, got %q", snippet)
+	}
+}
+
+func TestSnippetKeepsLongHighlightedWord(t *testing.T) {
+	// The only word ending inside the snippet window comes before it
+	text := "hello " + strings.Repeat("-", 60) + strings.Repeat("x", 170)
+	ix := NewIndex([]Document{{Type: TypeMatter, ID: "1", Title: "Long token", Body: text}})
+
+	res := ix.Search(Query{Text: "xxxxxxxxx"})
+	if len(res.Hits) == 0 {
+		t.Fatal("expected a hit")
+	}
+	if snippet := res.Hits[0].Snippet; !strings.Contains(snippet, "<mark>"+strings.Repeat("x", 170)+"</mark>") {
+		t.Errorf("expected the whole highlighted word in snippet, got %q", snippet)
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	ix := testIndex()
+
+	all := ix.Search(Query{Text: "ward"})
+	page := ix.Search(Query{Text: "ward", Limit: 1, Offset: 1})
+	if page.Total != all.Total {
+		t.Errorf("expected total %d, got %d", all.Total, page.Total)
+	}
+	if len(page.Hits) != 1 || page.Hits[0].ID != all.Hits[1].ID {
+		t.Errorf("expected second hit %q, got %+v", all.Hits[1].ID, page.Hits)
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	cases := map[string]string{
+		"Hopkins, Brian":          "Brian Hopkins",
+		"La Spata, Daniel":        "Daniel La Spata",
+		"Brandon Johnson":         "Brandon Johnson",
+		"Sigcho-Lopez, Byron M. ": "Byron M. Sigcho-Lopez",
+	}
+	for in, want := range cases {
+		if got := ReverseName(in); got != want {
+			t.Errorf("ReverseName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
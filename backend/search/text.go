@@ -0,0 +1,139 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// token is a normalized term together with its byte offsets in the source text
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// accentFolds maps common accented Latin letters to their ASCII base letter
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'õ': 'o', 'ø': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ñ': 'n', 'ç': 'c', 'ý': 'y', 'ÿ': 'y',
+}
+
+// stopWords are skipped when indexing and querying free text
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true,
+	"for": true, "from": true, "in": true, "of": true, "on": true, "or": true,
+	"the": true, "to": true, "with": true,
+}
+
+// fileNumberPattern matches Legistar file numbers such as O2024-1234 or SO2023-55
+var fileNumberPattern = regexp.MustCompile(`(?i)^([a-z]{1,3})\s*(\d{4})\s*-\s*(\d+)$`)
+
+// fold lowercases a rune and strips its accent
+func fold(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := accentFolds[r]; ok {
+		return base
+	}
+	return r
+}
+
+// Normalize lowercases s, strips accents and collapses punctuation to single spaces
+func Normalize(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range s {
+		r = fold(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if r == '\'' || r == '’' {
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// tokenize splits s into normalized terms, keeping the offsets of each term
+// so that snippets can be highlighted against the original text
+func tokenize(s string) []token {
+	var tokens []token
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && b.Len() > 0 {
+			tokens = append(tokens, token{term: b.String(), start: start, end: end})
+		}
+		b.Reset()
+		start = -1
+	}
+
+	for i, r := range s {
+		f := fold(r)
+		switch {
+		case unicode.IsLetter(f) || unicode.IsDigit(f):
+			if start < 0 {
+				start = i
+			}
+			b.WriteRune(f)
+		case (r == '\'' || r == '’') && start >= 0:
+			// Apostrophes join names like O'Shea into a single term
+		default:
+			flush(i)
+		}
+	}
+	flush(len(s))
+	return tokens
+}
+
+// terms returns the distinct normalized query terms of s, without stop words
+func terms(s string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range tokenize(s) {
+		if stopWords[t.term] || seen[t.term] {
+			continue
+		}
+		seen[t.term] = true
+		out = append(out, t.term)
+	}
+	return out
+}
+
+// NormalizeFileNumber returns the canonical form of a Legistar file number
+// (e.g. "o2024 - 1234" -> "O2024-1234") and whether s looked like one
+func NormalizeFileNumber(s string) (string, bool) {
+	m := fileNumberPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+	return strings.ToUpper(m[1]) + m[2] + "-" + m[3], true
+}
+
+// ReverseName converts a Legistar "Last, First Middle" name into "First Middle Last".
+// Names without a comma are returned unchanged.
+func ReverseName(name string) string {
+	parts := strings.SplitN(name, ",", 2)
+	if len(parts) != 2 {
+		return strings.TrimSpace(name)
+	}
+	last := strings.TrimSpace(parts[0])
+	first := strings.TrimSpace(parts[1])
+	if first == "" {
+		return last
+	}
+	if last == "" {
+		return first
+	}
+	return first + " " + last
+}
@@ -0,0 +1,90 @@
+// Package server runs the HTTP server with timeouts and a graceful shutdown
+// that fails readiness before draining in-flight requests.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/config"
+)
+
+var draining atomic.Bool
+
+// Draining reports whether the server has begun shutting down. Readiness
+// checks fail while it is true so load balancers stop sending traffic.
+func Draining() bool {
+	return draining.Load()
+}
+
+// Server wraps an http.Server with the configured shutdown behaviour
+type Server struct {
+	HTTP *http.Server
+
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+}
+
+// New creates a server listening on addr with the configured timeouts
+func New(addr string, handler http.Handler, cfg config.Server) *Server {
+	return &Server{
+		HTTP: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		shutdownDelay:   cfg.ShutdownDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// Run listens on the configured address and serves until ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.HTTP.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve accepts connections on ln until ctx is cancelled, then marks the
+// server as draining, waits for the shutdown delay and gracefully shuts down.
+// It returns nil when every in-flight request finished within the timeout.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	draining.Store(false)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.HTTP.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	draining.Store(true)
+	slog.Info("shutting down; readiness failing", "drain_delay", s.shutdownDelay.String(), "timeout", s.shutdownTimeout.String())
+	time.Sleep(s.shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.HTTP.Shutdown(shutdownCtx); err != nil {
+		s.HTTP.Close()
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/config"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			if Draining() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	cfg := config.Default().Server
+	cfg.ShutdownDelay = 100 * time.Millisecond
+	cfg.ShutdownTimeout = 5 * time.Second
+	srv := New("", handler, cfg)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+	<-started
+
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	if !Draining() {
+		t.Fatal("expected server to be draining after cancellation")
+	}
+
+	// New requests are still served during the shutdown delay, with
+	// readiness failing
+	resp, err := http.Get(base + "/ready")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected readiness 503 while draining, got %d", resp.StatusCode)
+	}
+
+	close(release)
+	if got := <-slow; got != "done" {
+		t.Errorf("in-flight request was dropped: %s", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestNewAppliesTimeouts(t *testing.T) {
+	cfg := config.Default().Server
+	srv := New(":0", http.NotFoundHandler(), cfg)
+	if srv.HTTP.ReadHeaderTimeout != cfg.ReadHeaderTimeout || srv.HTTP.WriteTimeout != cfg.WriteTimeout ||
+		srv.HTTP.ReadTimeout != cfg.ReadTimeout || srv.HTTP.IdleTimeout != cfg.IdleTimeout {
+		t.Errorf("timeouts not applied: %+v", srv.HTTP)
+	}
+}
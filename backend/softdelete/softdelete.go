@@ -0,0 +1,303 @@
+// Package softdelete marks people, terms and votes deleted instead of
+// removing them, so a mistaken delete can be restored. Deleting a person
+// marks their terms and votes too, with the same time, and restoring the
+// person restores exactly those rows. Reads leave marked rows out; the
+// purge job removes them after a retention period.
+package softdelete
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jsanchez767/InfluencePower/backend/db"
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+// Entity is a table whose rows are soft-deleted
+type Entity struct {
+	Table string // e.g. "people"
+	Sync  string // the sync_state entity of the table, which is also its cache tag
+
+	// Dependents are the tables whose rows belong to a row through their
+	// person_id, deleted and restored with it
+	Dependents []string
+	// Person is the column naming the person a row belongs to, if any; a
+	// row cannot be restored while its person is deleted
+	Person string
+}
+
+// Entities lists every soft-deleted table, dependents after the table they
+// depend on
+var Entities = []Entity{
+	{Table: "people", Sync: db.EntityPeople, Dependents: []string{"terms", "votes"}},
+	{Table: "terms", Sync: db.EntityTerms, Person: "person_id"},
+	{Table: "votes", Sync: db.EntityVotes, Person: "person_id"},
+}
+
+// Lookup returns the entity whose rows are in table
+func Lookup(table string) (Entity, bool) {
+	for _, e := range Entities {
+		if e.Table == table {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+// Row is a row marked deleted, or restored
+type Row struct {
+	Entity    string          `json:"entity"` // the table, e.g. "people"
+	ID        string          `json:"id"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	DeletedBy string          `json:"deleted_by"`
+	Row       json.RawMessage `json:"row"` // the row as it was deleted
+}
+
+// Errors returned by Delete and Restore
+var (
+	ErrNotFound      = errors.New("row not found")
+	ErrDeleted       = errors.New("row is already deleted")
+	ErrNotDeleted    = errors.New("row is not deleted")
+	ErrPersonDeleted = errors.New("the row's person is deleted")
+)
+
+// toRow converts a row read from table
+func toRow(table string, row map[string]interface{}) (Row, error) {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return Row{}, err
+	}
+	var marks struct {
+		ID        json.Number `json:"id"`
+		DeletedAt *time.Time  `json:"deleted_at"`
+		DeletedBy *string     `json:"deleted_by"`
+	}
+	if err := json.Unmarshal(raw, &marks); err != nil {
+		return Row{}, fmt.Errorf("unexpected %s row: %w", table, err)
+	}
+	r := Row{Entity: table, ID: marks.ID.String(), Row: raw}
+	if marks.DeletedAt != nil {
+		r.DeletedAt = *marks.DeletedAt
+	}
+	if marks.DeletedBy != nil {
+		r.DeletedBy = *marks.DeletedBy
+	}
+	return r, nil
+}
+
+// toRows converts rows read from table
+func toRows(table string, rows []map[string]interface{}) ([]Row, error) {
+	out := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		r, err := toRow(table, row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// idString returns an id read from a row as a string
+func idString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return strings.Trim(string(b), `"`)
+}
+
+// load returns the row of table with id, or ErrNotFound
+func load(client *postgrest.Client, table, id string) (map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	_, err := client.From(table).Select("*", "", false).Eq("id", id).ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s %s: %w", table, id, err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return rows[0], nil
+}
+
+// mark sets or clears the deleted marks of the rows matched by filter,
+// returning them as they were before
+func mark(client *postgrest.Client, table string, marks map[string]interface{}, filter func(*postgrest.FilterBuilder) *postgrest.FilterBuilder) ([]map[string]interface{}, error) {
+	var before []map[string]interface{}
+	_, err := filter(client.From(table).Select("*", "", false)).ExecuteTo(&before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", table, err)
+	}
+	if len(before) == 0 {
+		return nil, nil
+	}
+	_, _, err = filter(client.From(table).Update(marks, "minimal", "")).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark %s: %w", table, err)
+	}
+	return before, nil
+}
+
+// marked are the rows a Delete or Restore has changed so far, by table in
+// the order they were changed, as they were before
+type marked struct {
+	tables []string
+	rows   [][]map[string]interface{}
+}
+
+func (m *marked) add(table string, rows []map[string]interface{}) {
+	m.tables = append(m.tables, table)
+	m.rows = append(m.rows, rows)
+}
+
+// undo puts back the marks of the rows changed so far, last first, after
+// a later step failed with err. PostgREST runs each request in its own
+// transaction, so a cascade is all-or-nothing only if it is undone. It
+// returns err, noting any marks that could not be put back.
+func (m *marked) undo(client *postgrest.Client, err error) error {
+	for i := len(m.tables) - 1; i >= 0; i-- {
+		if undoErr := revert(client, m.tables[i], m.rows[i]); undoErr != nil {
+			return fmt.Errorf("%w; undoing the marks of %s also failed: %v", err, strings.Join(m.tables[:i+1], ", "), undoErr)
+		}
+	}
+	return err
+}
+
+// revert sets the marks of rows of table back to the ones they hold
+func revert(client *postgrest.Client, table string, rows []map[string]interface{}) error {
+	type marks struct{ at, by interface{} }
+	groups := map[marks][]string{}
+	var order []marks
+	for _, row := range rows {
+		m := marks{row["deleted_at"], row["deleted_by"]}
+		if _, ok := groups[m]; !ok {
+			order = append(order, m)
+		}
+		groups[m] = append(groups[m], idString(row["id"]))
+	}
+	for _, m := range order {
+		_, _, err := client.From(table).
+			Update(map[string]interface{}{"deleted_at": m.at, "deleted_by": m.by}, "minimal", "").
+			In("id", groups[m]).
+			Execute()
+		if err != nil {
+			return fmt.Errorf("failed to revert %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Delete marks the row of e with id deleted by by, and its dependents with
+// it. It returns the rows marked, as they were, the row first. If a step
+// fails, the marks already set are cleared again.
+func Delete(client *postgrest.Client, e Entity, id, by string) ([]Row, error) {
+	row, err := load(client, e.Table, id)
+	if err != nil {
+		return nil, err
+	}
+	if row["deleted_at"] != nil {
+		return nil, ErrDeleted
+	}
+
+	now := time.Now().UTC()
+	marks := map[string]interface{}{"deleted_at": now, "deleted_by": by}
+	var done marked
+	deleted, err := mark(client, e.Table, marks, func(q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+		return q.Eq("id", id).Is("deleted_at", "null")
+	})
+	if err != nil {
+		return nil, err
+	}
+	done.add(e.Table, deleted)
+	rows, err := toRows(e.Table, deleted)
+	if err != nil {
+		return nil, done.undo(client, err)
+	}
+	for _, table := range e.Dependents {
+		deleted, err := mark(client, table, marks, func(q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+			return q.Eq("person_id", id).Is("deleted_at", "null")
+		})
+		if err != nil {
+			return nil, done.undo(client, err)
+		}
+		done.add(table, deleted)
+		dependents, err := toRows(table, deleted)
+		if err != nil {
+			return nil, done.undo(client, err)
+		}
+		rows = append(rows, dependents...)
+	}
+	for i := range rows {
+		rows[i].DeletedAt, rows[i].DeletedBy = now, by
+	}
+	return rows, nil
+}
+
+// Restore clears the deleted marks of the row of e with id and of the
+// dependents deleted with it. It returns the rows restored, as they were
+// deleted, the row first. If a step fails, the marks already cleared are
+// set again.
+func Restore(client *postgrest.Client, e Entity, id string) ([]Row, error) {
+	row, err := load(client, e.Table, id)
+	if err != nil {
+		return nil, err
+	}
+	deletedAt, ok := row["deleted_at"].(string)
+	if !ok {
+		return nil, ErrNotDeleted
+	}
+	if e.Person != "" && row[e.Person] != nil {
+		person, err := load(client, "people", idString(row[e.Person]))
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if person != nil && person["deleted_at"] != nil {
+			return nil, ErrPersonDeleted
+		}
+	}
+
+	marks := map[string]interface{}{"deleted_at": nil, "deleted_by": nil}
+	var done marked
+	restored, err := mark(client, e.Table, marks, func(q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+		return q.Eq("id", id).Eq("deleted_at", deletedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	done.add(e.Table, restored)
+	rows, err := toRows(e.Table, restored)
+	if err != nil {
+		return nil, done.undo(client, err)
+	}
+	for _, table := range e.Dependents {
+		restored, err := mark(client, table, marks, func(q *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+			return q.Eq("person_id", id).Eq("deleted_at", deletedAt)
+		})
+		if err != nil {
+			return nil, done.undo(client, err)
+		}
+		done.add(table, restored)
+		dependents, err := toRows(table, restored)
+		if err != nil {
+			return nil, done.undo(client, err)
+		}
+		rows = append(rows, dependents...)
+	}
+	return rows, nil
+}
+
+// List returns the deleted rows of e, most recently deleted first
+func List(client *postgrest.Client, e Entity, limit int) ([]Row, error) {
+	query := client.From(e.Table).
+		Select("*", "", false).
+		Not("deleted_at", "is", "null").
+		Order("deleted_at", &postgrest.OrderOpts{Ascending: false})
+	if limit > 0 {
+		query = query.Limit(limit, "")
+	}
+	var rows []map[string]interface{}
+	if _, err := query.ExecuteTo(&rows); err != nil {
+		return nil, fmt.Errorf("failed to list deleted %s: %w", e.Table, err)
+	}
+	return toRows(e.Table, rows)
+}
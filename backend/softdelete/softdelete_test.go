@@ -0,0 +1,74 @@
+package softdelete
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	postgrest "github.com/supabase-community/postgrest-go"
+)
+
+func TestToRowReadsMarks(t *testing.T) {
+	var row map[string]interface{}
+	json.Unmarshal([]byte(`{"id": 7, "full_name": "Jane Doe", "deleted_at": "2024-01-02T03:04:05Z", "deleted_by": "key:admin"}`), &row)
+	r, err := toRow("people", row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Entity != "people" || r.ID != "7" || r.DeletedBy != "key:admin" || r.DeletedAt.Format("2006-01-02") != "2024-01-02" {
+		t.Errorf("unexpected row %+v", r)
+	}
+
+	if r, err := toRow("terms", map[string]interface{}{"id": 8, "deleted_at": nil}); err != nil || r.ID != "8" || !r.DeletedAt.IsZero() {
+		t.Errorf("expected a live row, got %+v, %v", r, err)
+	}
+}
+
+func TestDeleteUndoesMarksWhenCascadeFails(t *testing.T) {
+	var mu sync.Mutex
+	var updates []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		table := path.Base(r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			switch table {
+			case "people":
+				w.Write([]byte(`[{"id": 7, "full_name": "Jane Doe", "deleted_at": null, "deleted_by": null}]`))
+			case "terms":
+				w.Write([]byte(`[{"id": 1, "person_id": 7, "deleted_at": null, "deleted_by": null}]`))
+			default:
+				w.Write([]byte(`[{"id": 9, "person_id": 7, "deleted_at": null, "deleted_by": null}]`))
+			}
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var marks map[string]interface{}
+		json.Unmarshal(body, &marks)
+		mu.Lock()
+		updates = append(updates, fmt.Sprintf("%s %v", table, marks["deleted_at"] != nil))
+		mu.Unlock()
+		if table == "votes" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"message": "unavailable"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	rows, err := Delete(postgrest.NewClient(srv.URL, "", nil), Entities[0], "7", "key:admin")
+	if err == nil || len(rows) != 0 {
+		t.Fatalf("expected the failed delete to return no rows and an error, got %+v, %v", rows, err)
+	}
+	// The person and terms are marked, the votes fail, then the terms and
+	// the person are cleared again
+	want := []string{"people true", "terms true", "votes true", "terms false", "people false"}
+	if fmt.Sprint(updates) != fmt.Sprint(want) {
+		t.Errorf("expected updates %v, got %v", want, updates)
+	}
+}
@@ -0,0 +1,146 @@
+// Package tracing sets up OpenTelemetry tracing and provides the spans shared
+// by the API server and the sync jobs.
+//
+// Tracing is off unless an exporter is configured: spans are still created
+// and propagated, but they are dropped by the default no-op provider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters accepted by Config.Exporter
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+)
+
+// ParentEnv holds a W3C traceparent that sync jobs continue, so a run started
+// by another traced process joins its trace
+const ParentEnv = "TRACEPARENT"
+
+// instrumentationName identifies this module's tracer
+const instrumentationName = "github.com/Jsanchez767/InfluencePower/backend"
+
+// Config selects the span exporter. The OTLP exporter reads its endpoint,
+// headers and timeout from the standard OTEL_EXPORTER_OTLP_* variables.
+type Config struct {
+	Exporter    string // none or otlp
+	ServiceName string
+}
+
+// FromEnv reads the configuration of a sync job from OTEL_TRACES_EXPORTER and
+// OTEL_SERVICE_NAME, defaulting the service name to job
+func FromEnv(job string) Config {
+	cfg := Config{Exporter: os.Getenv("OTEL_TRACES_EXPORTER"), ServiceName: os.Getenv("OTEL_SERVICE_NAME")}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = job
+	}
+	return cfg
+}
+
+// Validate reports an unknown exporter
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case "", ExporterNone, ExporterOTLP:
+		return nil
+	}
+	return fmt.Errorf("unknown trace exporter %q (want %s or %s)", c.Exporter, ExporterNone, ExporterOTLP)
+}
+
+// Setup installs the W3C trace context propagator and, for the OTLP exporter,
+// a batching tracer provider. The returned function flushes pending spans and
+// must be called before the process exits.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Exporter != ExporterOTLP {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of any span in ctx
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// StartJob starts the root span of a sync job run, continuing the trace in
+// TRACEPARENT when it is set
+func StartJob(ctx context.Context, job string) (context.Context, trace.Span) {
+	if parent := os.Getenv(ParentEnv); parent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": parent})
+	}
+	return Start(ctx, job, trace.WithAttributes(attribute.String("job", job)))
+}
+
+// End records err, if any, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject adds the trace context of ctx to outgoing request headers
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Traceparent returns the W3C traceparent of the span in ctx, for handing
+// the trace to a child process through TRACEPARENT
+func Traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier["traceparent"]
+}
+
+// SetupJob sets up tracing for a sync job from the environment and starts
+// its root span. The returned function ends the span and flushes it.
+func SetupJob(ctx context.Context, job string) (context.Context, func(), error) {
+	shutdown, err := Setup(ctx, FromEnv(job))
+	if err != nil {
+		return ctx, nil, err
+	}
+	ctx, span := StartJob(ctx, job)
+	return ctx, func() {
+		span.End()
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown(flushCtx)
+	}, nil
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// recordSpans installs a provider that keeps finished spans in memory
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	if _, err := Setup(context.Background(), Config{}); err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return exporter
+}
+
+func TestMiddlewareNamesSpanAfterRoute(t *testing.T) {
+	exporter := recordSpans(t)
+
+	var outgoing string
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/officials/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// Calls made while handling the request continue its trace
+		header := http.Header{}
+		Inject(r.Context(), header)
+		outgoing = header.Get("traceparent")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/officials/7", nil)
+	req.Header.Set("traceparent", parent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /officials/{id}" {
+		t.Errorf("unexpected span name %q", span.Name)
+	}
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected caller's trace to continue, got trace %s", got)
+	}
+	if span.Status.Code.String() != "Error" {
+		t.Errorf("expected error status for a 500, got %v", span.Status)
+	}
+	if outgoing == "" || outgoing == parent {
+		t.Errorf("expected outgoing traceparent for the server span, got %q", outgoing)
+	}
+}
+
+func TestStartJobContinuesTraceparent(t *testing.T) {
+	exporter := recordSpans(t)
+	t.Setenv(ParentEnv, parent)
+
+	ctx, span := StartJob(context.Background(), "sync_city_api")
+	_, child := Start(ctx, "sync votes")
+	child.End()
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("span %q is not in the parent trace", s.Name)
+		}
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Error("expected entity span to be a child of the job span")
+	}
+	if got := Traceparent(ctx); got == "" || got[3:35] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected traceparent %q", got)
+	}
+}
+
+func TestValidateRejectsUnknownExporter(t *testing.T) {
+	if err := (Config{Exporter: "jaeger"}).Validate(); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := Setup(context.Background(), Config{Exporter: ExporterNone}); err != nil {
+		t.Error(err)
+	}
+}
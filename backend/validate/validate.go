@@ -0,0 +1,277 @@
+// Package validate checks request bodies against declarative `validate` struct tags.
+//
+// Rules are comma separated, e.g. `validate:"required,oneof=yea|nay|abstain"`.
+// Supported rules:
+//
+//	required       value must be non-zero (non-empty string, non-nil pointer, ...)
+//	omitempty      skip remaining rules when the value is zero
+//	min=N, max=N   numeric bounds, or length bounds for strings and slices
+//	oneof=a|b|c    case-insensitive enumeration
+//	date           string in YYYY-MM-DD format
+//	notfuture      date or time must not be in the future
+//	email          plausible email address
+//	url            absolute http(s) URL
+//	ward=Field     ward number within the range for the jurisdiction named by
+//	               sibling Field (Chicago when Field is empty)
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout accepted by the date rule
+const DateLayout = "2006-01-02"
+
+// FieldError describes a single invalid field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors is the list of field errors found in a value
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// DefaultJurisdiction is used by the ward rule when no jurisdiction is given
+const DefaultJurisdiction = "chicago"
+
+// WardRanges maps lower-cased jurisdiction names to their valid ward/district range
+var WardRanges = map[string][2]int{
+	"chicago": {1, 50},
+}
+
+// Struct validates v, which must be a struct or a pointer to one. It returns
+// nil or an Errors value listing every invalid field.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return Errors{{Field: "", Rule: "required", Message: "body is required"}}
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected struct, got %s", rv.Kind())
+	}
+
+	var errs Errors
+	validateStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(rv reflect.Value, prefix string, errs *Errors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := prefix + jsonName(sf)
+		fv := rv.Field(i)
+
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			if fe := validateField(rv, fv, name, tag); fe != nil {
+				*errs = append(*errs, *fe)
+				continue
+			}
+		}
+
+		// Descend into nested structs other than time.Time
+		inner := fv
+		if inner.Kind() == reflect.Ptr && !inner.IsNil() {
+			inner = inner.Elem()
+		}
+		if inner.Kind() == reflect.Struct && inner.Type() != reflect.TypeOf(time.Time{}) {
+			validateStruct(inner, name+".", errs)
+		}
+	}
+}
+
+// validateField applies each rule in tag to fv, stopping at the first failure
+func validateField(parent, fv reflect.Value, name, tag string) *FieldError {
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		key, param, _ := strings.Cut(rule, "=")
+
+		if key == "omitempty" {
+			if isZero(fv) {
+				return nil
+			}
+			continue
+		}
+
+		if msg := check(parent, fv, key, param); msg != "" {
+			return &FieldError{Field: name, Rule: key, Message: msg}
+		}
+	}
+	return nil
+}
+
+// check returns an error message if fv violates the rule, or ""
+func check(parent, fv reflect.Value, rule, param string) string {
+	if rule == "required" {
+		if isZero(fv) {
+			return "is required"
+		}
+		return ""
+	}
+
+	// Other rules only apply to values that are present
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return ""
+		}
+		fv = fv.Elem()
+	}
+
+	switch rule {
+	case "min", "max":
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid %s parameter %q", rule, param))
+		}
+		n, isLen := measure(fv)
+		if rule == "min" && n < bound {
+			if isLen {
+				return fmt.Sprintf("must be at least %s characters", param)
+			}
+			return fmt.Sprintf("must be at least %s", param)
+		}
+		if rule == "max" && n > bound {
+			if isLen {
+				return fmt.Sprintf("must be at most %s characters", param)
+			}
+			return fmt.Sprintf("must be at most %s", param)
+		}
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		options := strings.Split(param, "|")
+		for _, opt := range options {
+			if strings.EqualFold(s, opt) {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "date":
+		if _, err := time.Parse(DateLayout, fv.String()); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "notfuture":
+		t, ok := asTime(fv)
+		if !ok {
+			return "must be a valid date"
+		}
+		if t.After(time.Now()) {
+			return "must not be in the future"
+		}
+	case "email":
+		addr, err := mail.ParseAddress(fv.String())
+		if err != nil || addr.Address != fv.String() {
+			return "must be a valid email address"
+		}
+	case "url":
+		u, err := url.Parse(fv.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an absolute http or https URL"
+		}
+	case "ward":
+		return checkWard(parent, fv, param)
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+	return ""
+}
+
+// checkWard validates a ward number against the range of the jurisdiction
+// named by the sibling field param
+func checkWard(parent, fv reflect.Value, param string) string {
+	jurisdiction := DefaultJurisdiction
+	if param != "" {
+		if jf := parent.FieldByName(param); jf.IsValid() {
+			if jf.Kind() == reflect.Ptr && !jf.IsNil() {
+				jf = jf.Elem()
+			}
+			if jf.Kind() == reflect.String && jf.String() != "" {
+				jurisdiction = strings.ToLower(jf.String())
+			}
+		}
+	}
+
+	bounds, ok := WardRanges[jurisdiction]
+	if !ok {
+		return fmt.Sprintf("unknown jurisdiction %q", jurisdiction)
+	}
+
+	n, _ := measure(fv)
+	if n < float64(bounds[0]) || n > float64(bounds[1]) {
+		return fmt.Sprintf("must be between %d and %d", bounds[0], bounds[1])
+	}
+	return ""
+}
+
+// measure returns the numeric value of fv, or its length for strings,
+// slices and maps (reporting true in that case)
+func measure(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false
+	case reflect.String:
+		return float64(len([]rune(fv.String()))), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), true
+	}
+	panic(fmt.Sprintf("validate: cannot measure %s", fv.Kind()))
+}
+
+// asTime converts a time.Time or YYYY-MM-DD / RFC 3339 string to a time
+func asTime(fv reflect.Value) (time.Time, bool) {
+	if t, ok := fv.Interface().(time.Time); ok {
+		return t, true
+	}
+	if fv.Kind() != reflect.String {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{DateLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, fv.String()); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isZero(fv reflect.Value) bool {
+	if fv.Kind() == reflect.String {
+		return strings.TrimSpace(fv.String()) == ""
+	}
+	return fv.IsZero()
+}
+
+// jsonName returns the JSON name of a struct field so errors match the request body
+func jsonName(sf reflect.StructField) string {
+	tag := sf.Tag.Get("json")
+	if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
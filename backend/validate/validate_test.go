@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type vote struct {
+	PersonID     int       `json:"person_id" validate:"required,min=1"`
+	Value        string    `json:"vote_value" validate:"required,oneof=yea|nay|abstain"`
+	Date         string    `json:"vote_date" validate:"required,date,notfuture"`
+	Ward         *int      `json:"ward" validate:"omitempty,ward=Jurisdiction"`
+	Jurisdiction string    `json:"jurisdiction"`
+	Email        string    `json:"email" validate:"omitempty,email"`
+	Recorded     time.Time `json:"recorded_at" validate:"notfuture"`
+}
+
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected validate.Errors, got %v", err)
+	}
+	out := make(map[string]string)
+	for _, fe := range errs {
+		out[fe.Field] = fe.Rule
+	}
+	return out
+}
+
+func TestStructValid(t *testing.T) {
+	ward := 50
+	v := vote{PersonID: 1, Value: "Yea", Date: "2024-01-15", Ward: &ward, Email: "ward50@cityofchicago.org"}
+	if err := Struct(&v); err != nil {
+		t.Fatalf("expected valid, got %v", err)
+	}
+}
+
+func TestStructReportsEveryField(t *testing.T) {
+	ward := 51
+	v := vote{Value: "banana", Date: "01/15/2024", Ward: &ward, Email: "nope", Recorded: time.Now().Add(time.Hour)}
+
+	got := fieldErrors(t, Struct(v))
+	want := map[string]string{
+		"person_id":   "required",
+		"vote_value":  "oneof",
+		"vote_date":   "date",
+		"ward":        "ward",
+		"email":       "email",
+		"recorded_at": "notfuture",
+	}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("field %s: expected rule %q, got %q", field, rule, got[field])
+		}
+	}
+}
+
+func TestWardRangePerJurisdiction(t *testing.T) {
+	WardRanges["evanston"] = [2]int{1, 9}
+	defer delete(WardRanges, "evanston")
+
+	ward := 12
+	v := vote{PersonID: 1, Value: "nay", Date: "2024-01-15", Ward: &ward, Jurisdiction: "Evanston"}
+	if got := fieldErrors(t, Struct(v)); got["ward"] != "ward" {
+		t.Errorf("expected ward 12 to be invalid in Evanston, got %v", got)
+	}
+
+	v.Jurisdiction = ""
+	if err := Struct(v); err != nil {
+		t.Errorf("expected ward 12 to be valid in Chicago, got %v", err)
+	}
+}
@@ -1,42 +1,7 @@
 import { useEffect, useState } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import type { Official } from '../types';
-
-interface OfficialMetrics {
-  avg_response_time_hours: number;
-  cases_resolved_total: number;
-  office_hours_held_total: number;
-  town_halls_attended_total: number;
-  bills_introduced_current_term: number;
-  bills_passed_current_term: number;
-  committee_attendance_rate: number;
-  voting_participation_rate: number;
-  transparency_score: number;
-  response_time_vs_avg: number;
-  productivity_vs_avg: number;
-  attendance_vs_avg: number;
-  total_votes_cast: number;
-  votes_yea: number;
-  votes_nay: number;
-  votes_present: number;
-}
-
-interface VotingAlly {
-  official_id: number;
-  name: string;
-  ward: number | null;
-  party: string;
-  alignment: number;
-  bloc: string;
-}
-
-interface RecentVote {
-  vote_result: string;
-  matters: {
-    matter_name: string;
-    matter_type: string;
-  };
-}
+import type { PersonMetrics, RecentVote, VotingAlly } from '../services/api.generated';
 
 interface WardMetrics {
   population: number;
@@ -53,7 +18,7 @@ export default function ProfilePage() {
   const { id } = useParams<{ id: string }>();
   const navigate = useNavigate();
   const [official, setOfficial] = useState<Official | null>(null);
-  const [metrics, setMetrics] = useState<OfficialMetrics | null>(null);
+  const [metrics, setMetrics] = useState<PersonMetrics | null>(null);
   const [wardMetrics, setWardMetrics] = useState<WardMetrics | null>(null);
   const [votingAllies, setVotingAllies] = useState<VotingAlly[]>([]);
   const [recentVotes, setRecentVotes] = useState<RecentVote[]>([]);
@@ -149,8 +114,8 @@ export default function ProfilePage() {
     );
   }
 
-  const billsIntroduced = metrics?.bills_introduced_current_term || 0;
-  const billsPassed = metrics?.bills_passed_current_term || 0;
+  const billsIntroduced = metrics?.bills_introduced || 0;
+  const billsPassed = metrics?.bills_passed || 0;
   const successRate = billsIntroduced > 0 ? Math.floor((billsPassed / billsIntroduced) * 100) : 0;
   const committeeSeats = Math.floor(Math.random() * 10 + 5);
 
@@ -376,15 +341,15 @@ function RecentVotes({ officialName, votes }: { officialName: string; votes: Rec
               </span>
               <span style={{
                 padding: '4px 12px',
-                background: vote.vote_result === 'Yea' || vote.vote_result === 'Yes' ? '#dcfce7' : 
-                          vote.vote_result === 'Nay' || vote.vote_result === 'No' ? '#fee2e2' : '#fef3c7',
-                color: vote.vote_result === 'Yea' || vote.vote_result === 'Yes' ? '#166534' : 
-                      vote.vote_result === 'Nay' || vote.vote_result === 'No' ? '#991b1b' : '#854d0e',
+                background: vote.vote_value === 'Yea' || vote.vote_value === 'Yes' ? '#dcfce7' : 
+                          vote.vote_value === 'Nay' || vote.vote_value === 'No' ? '#fee2e2' : '#fef3c7',
+                color: vote.vote_value === 'Yea' || vote.vote_value === 'Yes' ? '#166534' : 
+                      vote.vote_value === 'Nay' || vote.vote_value === 'No' ? '#991b1b' : '#854d0e',
                 borderRadius: '6px',
                 fontSize: '14px',
                 fontWeight: '700'
               }}>
-                {vote.vote_result}
+                {vote.vote_value}
               </span>
             </div>
           ))}
@@ -0,0 +1,680 @@
+// Code generated from the OpenAPI document by `go test ./api -run TestGeneratedClient -update`. DO NOT EDIT.
+
+export interface APIError {
+  code: string;
+  details?: unknown;
+  message: string;
+  request_id?: string;
+}
+
+export interface APIKey {
+  created_at: string;
+  expires_at?: string | null;
+  id: string;
+  name: string;
+  prefix: string;
+  revoked_at?: string | null;
+  role: string;
+}
+
+export interface CloseTermRequest {
+  end_date: string;
+}
+
+export interface Committee {
+  created_at: string;
+  description?: string;
+  id: number;
+  name: string;
+}
+
+export interface Component {
+  last_synced_at?: string | null;
+  latency_ms?: number | null;
+  max_age?: string;
+  message?: string;
+  name: string;
+  status: string;
+}
+
+export interface CreateAPIKeyRequest {
+  expires_at?: string | null;
+  name: string;
+  role: 'reader' | 'editor' | 'admin';
+}
+
+export interface CreateAPIKeyResponse {
+  created_at: string;
+  expires_at?: string | null;
+  id: string;
+  key: string;
+  name: string;
+  prefix: string;
+  revoked_at?: string | null;
+  role: string;
+}
+
+export interface DismissReviewItemRequest {
+  note?: string;
+}
+
+export interface Entry {
+  action: string;
+  actor: string;
+  after?: unknown;
+  before?: unknown;
+  created_at: string;
+  entity: string;
+  entity_id?: string;
+  id: number;
+  request_id?: string;
+}
+
+export interface ErrorResponse {
+  error: APIError;
+}
+
+export interface Field {
+  entity: string;
+  entity_id: string;
+  fetched_at: string;
+  field: string;
+  record_id?: string;
+  run_id?: string;
+  system: string;
+  updated_at: string;
+}
+
+export interface HealthStatus {
+  message: string;
+  status: string;
+}
+
+export interface Hit {
+  date?: string;
+  id: string;
+  score: number;
+  snippet?: string;
+  title: string;
+  type: string;
+}
+
+export interface Item {
+  candidates: unknown;
+  created_at: string;
+  id: number;
+  key: string;
+  kind: string;
+  reason: string;
+  record: unknown;
+  resolution?: Resolution | null;
+  resolved_at?: string | null;
+  resolved_by?: string;
+  source: string;
+  status: string;
+  updated_at: string;
+}
+
+export interface JobStatus {
+  last_run?: Run | null;
+  name: string;
+  next_run_at?: string | null;
+  running: boolean;
+  schedule?: string;
+  timezone: string;
+}
+
+export interface Official {
+  contact: string;
+  created_at: string;
+  email: string;
+  id: number;
+  image_url?: string;
+  name: string;
+  party: string;
+  role: 'Mayor' | 'Alderman' | 'City Clerk' | 'City Treasurer';
+  updated_at: string;
+  ward?: number | null;
+}
+
+export interface OfficialCommittee {
+  committee_id: number;
+  created_at: string;
+  id: number;
+  official_id: number;
+  role: string;
+}
+
+export interface Override {
+  created_at: string;
+  created_by: string;
+  entity: string;
+  entity_id: string;
+  field: string;
+  id: number;
+  reason: string;
+  updated_at: string;
+  value: unknown;
+}
+
+export interface Person {
+  created_at: string | null;
+  date_of_birth: string | null;
+  email: string | null;
+  external_ids: unknown;
+  facebook_url: string | null;
+  first_name: string;
+  full_name: string;
+  headshot_last_updated: string | null;
+  id: number;
+  image_url: string | null;
+  instagram_handle: string | null;
+  last_name: string;
+  middle_name: string | null;
+  party_affiliation: string | null;
+  phone: string | null;
+  suffix: string | null;
+  twitter_handle: string | null;
+  updated_at: string | null;
+  website: string | null;
+}
+
+export interface PersonMetrics {
+  amendments_proposed: number;
+  attendance_rate: number;
+  bills_failed: number;
+  bills_introduced: number;
+  bills_passed: number;
+  constituent_engagement_score: number;
+  last_calculated_at: string | null;
+  legislative_impact_score: number;
+  overall_score: number;
+  person_id: number;
+  total_votes: number;
+  transparency_score: number;
+  updated_at: string | null;
+  votes_absent: number;
+  votes_abstain: number;
+  votes_nay: number;
+  votes_yea: number;
+}
+
+export interface PersonPatch {
+  date_of_birth?: string | null;
+  email?: string | null;
+  facebook_url?: string | null;
+  first_name?: string | null;
+  full_name?: string | null;
+  image_url?: string | null;
+  instagram_handle?: string | null;
+  last_name?: string | null;
+  middle_name?: string | null;
+  party_affiliation?: string | null;
+  phone?: string | null;
+  suffix?: string | null;
+  twitter_handle?: string | null;
+  website?: string | null;
+}
+
+export interface PersonRequest {
+  date_of_birth?: string | null;
+  email?: string | null;
+  facebook_url?: string | null;
+  first_name: string;
+  full_name?: string;
+  image_url?: string | null;
+  instagram_handle?: string | null;
+  last_name: string;
+  middle_name?: string | null;
+  party_affiliation?: string | null;
+  phone?: string | null;
+  suffix?: string | null;
+  twitter_handle?: string | null;
+  website?: string | null;
+}
+
+export interface Position {
+  body_id: number | null;
+  body_name: string | null;
+  created_at: string | null;
+  district_name: string | null;
+  district_number: number | null;
+  id: number;
+  jurisdiction_id: number;
+  position_type: string;
+  seats: number;
+  title: string;
+  updated_at: string | null;
+}
+
+export interface PositionPatch {
+  body_id?: number | null;
+  body_name?: string | null;
+  district_name?: string | null;
+  seats?: number | null;
+  title?: string | null;
+}
+
+export interface PositionRequest {
+  body_id?: number | null;
+  body_name?: string | null;
+  district_name?: string | null;
+  district_number?: number | null;
+  jurisdiction_id: number;
+  position_type: string;
+  seats?: number;
+  title: string;
+}
+
+export interface PurgeCacheRequest {
+  entities?: string[];
+}
+
+export interface PurgeCacheResponse {
+  removed: number;
+}
+
+export interface QueueCount {
+  count: number;
+  kind: string;
+  status: string;
+}
+
+export interface RecentVote {
+  id: number;
+  matter_id: string | null;
+  matters: VoteMatter | null;
+  person_id: number | null;
+  person_name: string | null;
+  vote_date: string | null;
+  vote_id: string | null;
+  vote_value: string | null;
+}
+
+export interface Report {
+  checked_at: string;
+  components: Component[];
+  status: string;
+}
+
+export interface Resolution {
+  new_person?: boolean;
+  note?: string;
+  person_id?: number;
+  position_id?: number;
+  start_date?: string;
+  ward?: number;
+}
+
+export interface Row {
+  deleted_at: string;
+  deleted_by: string;
+  entity: string;
+  id: string;
+  row: unknown;
+}
+
+export interface Run {
+  counts?: Record<string, SyncCounts>;
+  error?: string;
+  finished_at?: string | null;
+  id: number;
+  instance: string;
+  job: string;
+  request_id?: string;
+  started_at: string;
+  status: string;
+  trigger: string;
+}
+
+export interface SearchResponse {
+  backend: string;
+  facets: Record<string, number>;
+  hits: Hit[];
+  query: string;
+  total: number;
+}
+
+export interface SetOverrideRequest {
+  entity: 'person' | 'term' | 'matter';
+  entity_id: string;
+  field: string;
+  reason: string;
+  value: unknown;
+}
+
+export interface SyncCounts {
+  records_failed: number;
+  records_fetched: number;
+  records_upserted: number;
+}
+
+export interface Task {
+  attempts: number;
+  batch: string;
+  created_at: string;
+  id: number;
+  key: string;
+  kind: string;
+  last_error?: string;
+  locked_by?: string;
+  locked_until?: string | null;
+  max_attempts: number;
+  payload: unknown;
+  result?: unknown;
+  run_at: string;
+  status: string;
+  updated_at: string;
+}
+
+export interface Term {
+  created_at: string | null;
+  election_type: string | null;
+  end_date: string | null;
+  external_guid: string | null;
+  external_id: number | null;
+  id: number;
+  person_id: number;
+  position_id: number;
+  start_date: string;
+  term_number: number | null;
+  updated_at: string | null;
+}
+
+export interface TermPatch {
+  election_type?: string | null;
+  end_date?: string | null;
+  start_date?: string | null;
+  term_number?: number | null;
+}
+
+export interface TermRequest {
+  election_type?: 'general' | 'special' | 'appointment' | null;
+  end_date?: string | null;
+  person_id: number;
+  start_date: string;
+  term_number?: number | null;
+}
+
+export interface Usage {
+  client: string;
+  first_seen: string;
+  last_seen: string;
+  limited: number;
+  name?: string;
+  remaining: number;
+  requests: number;
+  tier: string;
+}
+
+export interface VoteMatter {
+  matter_name: string | null;
+  matter_type_name: string | null;
+}
+
+export interface VotingAlly {
+  alignment: number;
+  bloc: string;
+  name: string;
+  official_id: number;
+  party: string;
+  ward: number | null;
+}
+
+export interface VotingRecord {
+  bill_title: string;
+  created_at: string;
+  description?: string;
+  id: number;
+  official_id: number;
+  vote: 'yes' | 'no' | 'abstain' | 'yea' | 'nay' | 'present' | 'absent' | 'excused';
+  vote_date: string;
+}
+
+export interface WardStatistic {
+  affordable_housing: number;
+  created_at: string;
+  id: number;
+  infrastructure_spending: number;
+  permit_processing: number;
+  response_311_time: number;
+  updated_at: string;
+  ward: number;
+}
+
+/** ApiError is thrown for non-2xx responses and carries the API error envelope */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly requestId?: string,
+    readonly details?: unknown
+  ) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
+export interface ClientOptions {
+  /** Base URL including the version prefix, e.g. http://localhost:8080/api/v1 */
+  baseUrl: string;
+  /** Extra headers sent with every request, e.g. X-API-Key */
+  headers?: Record<string, string>;
+}
+
+type QueryValue = string | number | boolean | undefined;
+
+export function createClient({ baseUrl, headers = {} }: ClientOptions) {
+  const request = async <T>(
+    method: string,
+    path: string,
+    {
+      query,
+      body,
+      headers: extra,
+    }: { query?: Record<string, QueryValue>; body?: unknown; headers?: Record<string, string | undefined> } = {}
+  ): Promise<T> => {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) params.set(key, String(value));
+    }
+    const sent: Record<string, string> = { ...headers };
+    for (const [key, value] of Object.entries(extra ?? {})) {
+      if (value !== undefined) sent[key] = value;
+    }
+    const qs = params.toString();
+    const response = await fetch(`${baseUrl}${path}${qs ? `?${qs}` : ''}`, {
+      method,
+      headers: body === undefined ? sent : { 'Content-Type': 'application/json', ...sent },
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const envelope = await response.json().catch(() => null);
+      const error = envelope?.error;
+      throw new ApiError(
+        response.status,
+        error?.code ?? 'unknown',
+        error?.message ?? response.statusText,
+        error?.request_id,
+        error?.details
+      );
+    }
+    if (response.status === 204) return undefined as T;
+    return response.json() as Promise<T>;
+  };
+
+  return {
+    /** End a current term (requires admin role) */
+    closeTerm: (id: number, body: CloseTermRequest, headers?: { 'If-Match'?: string }): Promise<Term> =>
+      request<Term>('POST', `/terms/${encodeURIComponent(String(id))}/close`, { body, headers }),
+    /** Create an API key (requires admin role) */
+    createApiKey: (body: CreateAPIKeyRequest): Promise<CreateAPIKeyResponse> =>
+      request<CreateAPIKeyResponse>('POST', `/admin/api-keys`, { body }),
+    /** @deprecated Removed; create a person with POST /people and give them a position with POST /positions/{id}/terms */
+    createOfficial: (): Promise<void> =>
+      request<void>('POST', `/officials`),
+    /** Create a person (requires admin role) */
+    createPerson: (body: PersonRequest): Promise<Person> =>
+      request<Person>('POST', `/people`, { body }),
+    /** Create a position (requires admin role) */
+    createPosition: (body: PositionRequest): Promise<Position> =>
+      request<Position>('POST', `/positions`, { body }),
+    /** Start a term of a person on a position (requires admin role) */
+    createTerm: (id: number, body: TermRequest): Promise<Term> =>
+      request<Term>('POST', `/positions/${encodeURIComponent(String(id))}/terms`, { body }),
+    /** Create a voting record on a stored matter, named by ID or name (requires admin role) */
+    createVotingRecord: (body: VotingRecord): Promise<VotingRecord> =>
+      request<VotingRecord>('POST', `/voting-records`, { body }),
+    /** Delete an official with their terms and votes; admins can restore them (requires admin role) */
+    deleteOfficial: (id: number): Promise<void> =>
+      request<void>('DELETE', `/officials/${encodeURIComponent(String(id))}`),
+    /** Unpin a field; the next sync writes the upstream value (requires editor role) */
+    deleteOverride: (id: number): Promise<void> =>
+      request<void>('DELETE', `/overrides/${encodeURIComponent(String(id))}`),
+    /** Delete a term; admins can restore it (requires admin role) */
+    deleteTerm: (id: number): Promise<void> =>
+      request<void>('DELETE', `/terms/${encodeURIComponent(String(id))}`),
+    /** Delete a voting record; admins can restore it (requires admin role) */
+    deleteVotingRecord: (id: number): Promise<void> =>
+      request<void>('DELETE', `/voting-records/${encodeURIComponent(String(id))}`),
+    /** Delete a dead unit of work (requires admin role) */
+    discardQueueTask: (id: number): Promise<void> =>
+      request<void>('DELETE', `/admin/queue/tasks/${encodeURIComponent(String(id))}`),
+    /** Close a review item without a decision; the sync skips its record (requires admin role) */
+    dismissReviewItem: (id: number, body: DismissReviewItemRequest): Promise<Item> =>
+      request<Item>('POST', `/admin/review/items/${encodeURIComponent(String(id))}/dismiss`, { body }),
+    /** List committees */
+    getCommittees: (): Promise<Committee[]> =>
+      request<Committee[]>('GET', `/committees`),
+    /** Check API health */
+    getHealth: (): Promise<HealthStatus> =>
+      request<HealthStatus>('GET', `/health`),
+    /** Check that the process is up */
+    getLiveness: (): Promise<HealthStatus> =>
+      request<HealthStatus>('GET', `/health/live`),
+    /** Get an official */
+    getOfficialById: (id: number): Promise<Official> =>
+      request<Official>('GET', `/officials/${encodeURIComponent(String(id))}`),
+    /** List an official's committee memberships */
+    getOfficialCommittees: (id: number): Promise<OfficialCommittee[]> =>
+      request<OfficialCommittee[]>('GET', `/officials/${encodeURIComponent(String(id))}/committees`),
+    /** Get an official's metrics */
+    getOfficialMetrics: (id: number): Promise<PersonMetrics> =>
+      request<PersonMetrics>('GET', `/officials/${encodeURIComponent(String(id))}/metrics`),
+    /** List current officials */
+    getOfficials: (): Promise<Official[]> =>
+      request<Official[]>('GET', `/officials`),
+    /** List officials by party */
+    getOfficialsByParty: (party: string): Promise<Official[]> =>
+      request<Official[]>('GET', `/officials/party/${encodeURIComponent(String(party))}`),
+    /** List officials by ward */
+    getOfficialsByWard: (ward: number): Promise<Official[]> =>
+      request<Official[]>('GET', `/officials/ward/${encodeURIComponent(String(ward))}`),
+    /** This OpenAPI document */
+    getOpenApi: (): Promise<unknown> =>
+      request<unknown>('GET', `/openapi.json`),
+    /** Get a person */
+    getPerson: (id: number): Promise<Person> =>
+      request<Person>('GET', `/people/${encodeURIComponent(String(id))}`),
+    /** List where each field of a person came from */
+    getPersonProvenance: (id: number): Promise<Field[]> =>
+      request<Field[]>('GET', `/people/${encodeURIComponent(String(id))}/provenance`),
+    /** Get a position */
+    getPosition: (id: number): Promise<Position> =>
+      request<Position>('GET', `/positions/${encodeURIComponent(String(id))}`),
+    /** Count queued units of work by kind and status (requires admin role) */
+    getQueueStats: (): Promise<QueueCount[]> =>
+      request<QueueCount[]>('GET', `/admin/queue`),
+    /** Check the database and data freshness */
+    getReadiness: (): Promise<Report> =>
+      request<Report>('GET', `/health/ready`),
+    /** List an official's recent votes */
+    getRecentVotes: (id: number): Promise<RecentVote[]> =>
+      request<RecentVote[]>('GET', `/officials/${encodeURIComponent(String(id))}/recent-votes`),
+    /** Get a review item with its upstream record and candidates (requires admin role) */
+    getReviewItem: (id: number): Promise<Item> =>
+      request<Item>('GET', `/admin/review/items/${encodeURIComponent(String(id))}`),
+    /** Get a term */
+    getTerm: (id: number): Promise<Term> =>
+      request<Term>('GET', `/terms/${encodeURIComponent(String(id))}`),
+    /** Get per-client usage since startup (requires admin role) */
+    getUsage: (query?: { client?: string }): Promise<Usage[]> =>
+      request<Usage[]>('GET', `/admin/usage`, { query }),
+    /** List the officials who vote most like an official */
+    getVotingAllies: (id: number): Promise<VotingAlly[]> =>
+      request<VotingAlly[]>('GET', `/officials/${encodeURIComponent(String(id))}/voting-allies`),
+    /** List an official's voting records */
+    getVotingRecords: (id: number): Promise<VotingRecord[]> =>
+      request<VotingRecord[]>('GET', `/officials/${encodeURIComponent(String(id))}/voting-records`),
+    /** Get the current official and metrics for a ward */
+    getWardMetrics: (ward: number): Promise<Record<string, unknown>> =>
+      request<Record<string, unknown>>('GET', `/wards/${encodeURIComponent(String(ward))}/metrics`),
+    /** Get ward statistics */
+    getWardStatistics: (ward: number): Promise<WardStatistic> =>
+      request<WardStatistic>('GET', `/wards/${encodeURIComponent(String(ward))}/statistics`),
+    /** List API keys (requires admin role) */
+    listApiKeys: (): Promise<APIKey[]> =>
+      request<APIKey[]>('GET', `/admin/api-keys`),
+    /** List writes through the API and sync jobs, newest first (requires admin role) */
+    listAuditLog: (query?: { entity?: string; entity_id?: string; actor?: string; before_id?: number; limit?: number }): Promise<Entry[]> =>
+      request<Entry[]>('GET', `/admin/audit`, { query }),
+    /** List deleted people, terms or votes, most recently deleted first (requires admin role) */
+    listDeleted: (entity: number, query?: { limit?: number }): Promise<Row[]> =>
+      request<Row[]>('GET', `/admin/deleted/${encodeURIComponent(String(entity))}`, { query }),
+    /** List recent job runs, newest first (requires admin role) */
+    listJobRuns: (query?: { job?: string; limit?: number }): Promise<Run[]> =>
+      request<Run[]>('GET', `/admin/jobs/runs`, { query }),
+    /** List scheduled jobs with their next and last runs (requires admin role) */
+    listJobs: (): Promise<JobStatus[]> =>
+      request<JobStatus[]>('GET', `/admin/jobs`),
+    /** List pinned field values (requires editor role) */
+    listOverrides: (query?: { entity?: string; entity_id?: string }): Promise<Override[]> =>
+      request<Override[]>('GET', `/overrides`, { query }),
+    /** List the terms of a position, latest first */
+    listPositionTerms: (id: number): Promise<Term[]> =>
+      request<Term[]>('GET', `/positions/${encodeURIComponent(String(id))}/terms`),
+    /** List queued units of work, newest first (requires admin role) */
+    listQueueTasks: (query?: { kind?: string; status?: string; limit?: number }): Promise<Task[]> =>
+      request<Task[]>('GET', `/admin/queue/tasks`, { query }),
+    /** List sync decisions left for review, oldest first (requires admin role) */
+    listReviewItems: (query?: { kind?: string; status?: string; limit?: number }): Promise<Item[]> =>
+      request<Item[]>('GET', `/admin/review/items`, { query }),
+    /** Drop cached responses, optionally only those built from the given entities (requires admin role) */
+    purgeCache: (body: PurgeCacheRequest): Promise<PurgeCacheResponse> =>
+      request<PurgeCacheResponse>('POST', `/admin/cache/purge`, { body }),
+    /** Decide a review item; the next sync applies the decision (requires admin role) */
+    resolveReviewItem: (id: number, body: Resolution): Promise<Item> =>
+      request<Item>('POST', `/admin/review/items/${encodeURIComponent(String(id))}/resolve`, { body }),
+    /** Restore a deleted row and the rows deleted with it (requires admin role) */
+    restoreDeleted: (entity: number, id: number): Promise<Row[]> =>
+      request<Row[]>('POST', `/admin/deleted/${encodeURIComponent(String(entity))}/${encodeURIComponent(String(id))}/restore`),
+    /** Make a dead unit of work pending again (requires admin role) */
+    retryQueueTask: (id: number): Promise<Task> =>
+      request<Task>('POST', `/admin/queue/tasks/${encodeURIComponent(String(id))}/retry`),
+    /** Revoke an API key (requires admin role) */
+    revokeApiKey: (id: string): Promise<void> =>
+      request<void>('DELETE', `/admin/api-keys/${encodeURIComponent(String(id))}`),
+    /** Search people, legislation, committees and meetings */
+    search: (query: { q: string; type?: string; limit?: number; offset?: number }): Promise<SearchResponse> =>
+      request<SearchResponse>('GET', `/search`, { query }),
+    /** Pin a field of a person, term or matter to a value that syncs keep (requires editor role) */
+    setOverride: (body: SetOverrideRequest): Promise<Override> =>
+      request<Override>('PUT', `/overrides`, { body }),
+    /** Start a job now (requires admin role) */
+    triggerJob: (name: string): Promise<void> =>
+      request<void>('POST', `/admin/jobs/${encodeURIComponent(String(name))}/runs`),
+    /** @deprecated Removed; change a person with PATCH /people/{id} */
+    updateOfficial: (id: number): Promise<void> =>
+      request<void>('PUT', `/officials/${encodeURIComponent(String(id))}`),
+    /** Change fields of a person with a JSON Merge Patch (requires admin role) */
+    updatePerson: (id: number, body: PersonPatch, headers?: { 'If-Match'?: string }): Promise<Person> =>
+      request<Person>('PATCH', `/people/${encodeURIComponent(String(id))}`, { body, headers }),
+    /** Change fields of a position with a JSON Merge Patch (requires admin role) */
+    updatePosition: (id: number, body: PositionPatch, headers?: { 'If-Match'?: string }): Promise<Position> =>
+      request<Position>('PATCH', `/positions/${encodeURIComponent(String(id))}`, { body, headers }),
+    /** Change the dates or details of a term with a JSON Merge Patch (requires admin role) */
+    updateTerm: (id: number, body: TermPatch, headers?: { 'If-Match'?: string }): Promise<Term> =>
+      request<Term>('PATCH', `/terms/${encodeURIComponent(String(id))}`, { body, headers }),
+  };
+}
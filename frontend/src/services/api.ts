@@ -1,7 +1,13 @@
-import { Official, VotingRecord, Committee, OfficialCommittee, WardStatistic } from '../types';
+import { Official, VotingRecord, Committee, OfficialCommittee, WardStatistic, SearchResults, SearchResultType } from '../types';
+import { createClient } from './api.generated';
 
 const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080/api/v1';
 
+// Typed client generated from the backend's OpenAPI document (GET /api/v1/openapi.json).
+// Prefer it for new code; the helpers below predate it.
+export const client = createClient({ baseUrl: API_BASE_URL });
+export { ApiError } from './api.generated';
+
 // Officials
 export const fetchOfficials = async (): Promise<Official[]> => {
   const response = await fetch(`${API_BASE_URL}/officials`);
@@ -54,6 +60,20 @@ export const fetchOfficialCommittees = async (officialId: number): Promise<Offic
   return response.json();
 };
 
+// Search
+export const search = async (
+  query: string,
+  options: { types?: SearchResultType[]; limit?: number; offset?: number } = {}
+): Promise<SearchResults> => {
+  const params = new URLSearchParams({ q: query });
+  if (options.types?.length) params.set('type', options.types.join(','));
+  if (options.limit !== undefined) params.set('limit', String(options.limit));
+  if (options.offset !== undefined) params.set('offset', String(options.offset));
+  const response = await fetch(`${API_BASE_URL}/search?${params}`);
+  if (!response.ok) throw new Error('Failed to search');
+  return response.json();
+};
+
 // Health check
 export const checkHealth = async (): Promise<{ status: string; message: string }> => {
   const response = await fetch(`${API_BASE_URL}/health`);
@@ -47,3 +47,22 @@ export interface WardStatistic {
   created_at?: string;
   updated_at?: string;
 }
+
+export type SearchResultType = 'person' | 'matter' | 'committee' | 'meeting';
+
+export interface SearchHit {
+  type: SearchResultType;
+  id: string;
+  title: string;
+  snippet?: string;
+  date?: string;
+  score: number;
+}
+
+export interface SearchResults {
+  query: string;
+  total: number;
+  hits: SearchHit[];
+  facets: Partial<Record<SearchResultType, number>>;
+  backend: 'postgres' | 'memory';
+}